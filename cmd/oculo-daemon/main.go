@@ -11,6 +11,7 @@
 //	--metrics   HTTP address for Prometheus metrics (default: 127.0.0.1:9877)
 //	--batch     Batch size for flush (default: 1000)
 //	--flush     Flush interval (default: 500ms)
+//	--config    Config file supplying flag defaults (default: ~/.oculo/config.json)
 package main
 
 import (
@@ -23,6 +24,7 @@ import (
 	"path/filepath"
 	"syscall"
 
+	"github.com/Mr-Dark-debug/oculo/internal/config"
 	"github.com/Mr-Dark-debug/oculo/internal/database"
 	"github.com/Mr-Dark-debug/oculo/internal/ingestion"
 )
@@ -34,7 +36,10 @@ func main() {
 	flag.StringVar(&cfg.DBPath, "db", cfg.DBPath, "Path to SQLite database file")
 	flag.StringVar(&cfg.MetricsAddr, "metrics", cfg.MetricsAddr, "Prometheus metrics HTTP address")
 	flag.IntVar(&cfg.BatchSize, "batch", cfg.BatchSize, "Batch size before flush")
-	flag.Parse()
+	flag.DurationVar(&cfg.FlushInterval, "flush", cfg.FlushInterval, "Maximum time between batch flushes")
+	if err := config.Parse(flag.CommandLine, os.Args[1:]); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
 
 	// Ensure the database directory exists
 	dbDir := filepath.Dir(cfg.DBPath)
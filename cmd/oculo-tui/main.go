@@ -6,7 +6,12 @@
 //
 // Flags:
 //
-//	--db    Path to SQLite database file (default: ~/.oculo/oculo.db)
+//	--db            Path to SQLite database file (default: ~/.oculo/oculo.db)
+//	--confirm-quit  Ask for confirmation before q quits
+//	--time-format   Go time layout for timestamps (e.g. "Jan 2 3:04PM")
+//	--theme         Color theme: default or colorblind
+//	--pricing       JSON file of model prices per 1K tokens
+//	--config        Config file supplying flag defaults (default: ~/.oculo/config.json)
 package main
 
 import (
@@ -16,6 +21,8 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/Mr-Dark-debug/oculo/internal/analysis"
+	"github.com/Mr-Dark-debug/oculo/internal/config"
 	"github.com/Mr-Dark-debug/oculo/internal/database"
 	"github.com/Mr-Dark-debug/oculo/internal/tui"
 
@@ -27,7 +34,27 @@ func main() {
 	defaultDB := filepath.Join(homeDir, ".oculo", "oculo.db")
 
 	dbPath := flag.String("db", defaultDB, "Path to SQLite database file")
-	flag.Parse()
+	confirmQuit := flag.Bool("confirm-quit", false, "Ask for confirmation before q quits")
+	timeFormat := flag.String("time-format", "", "Go time layout for timestamps (default: 2006-01-02 15:04:05.000)")
+	themeName := flag.String("theme", "", "Color theme: default or colorblind (default: last used)")
+	pricingPath := flag.String("pricing", "", "JSON file of model prices per 1K tokens, e.g. {\"my-model\": [0.001, 0.002]}")
+	if err := config.Parse(flag.CommandLine, os.Args[1:]); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	theme, err := tui.ParseTheme(*themeName)
+	if err != nil {
+		log.Fatalf("Invalid --theme: %v", err)
+	}
+	var pricing analysis.PricingTable
+	if *pricingPath != "" {
+		path, err := config.ExpandHome(*pricingPath)
+		if err == nil {
+			pricing, err = analysis.LoadPricing(path)
+		}
+		if err != nil {
+			log.Fatalf("Failed to load pricing: %v", err)
+		}
+	}
 
 	// Open the database in read-only mode for the TUI
 	store, err := database.NewDBService(*dbPath)
@@ -37,7 +64,13 @@ func main() {
 	}
 	defer store.Close()
 
-	model := tui.NewModel(store)
+	model := tui.NewModelWithOptions(store, tui.Options{
+		ConfirmQuit: *confirmQuit,
+		TimeLayout:  *timeFormat,
+		PrefsPath:   filepath.Join(homeDir, ".oculo", "tui.json"),
+		Theme:       theme,
+		Pricing:     pricing,
+	})
 	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion())
 
 	if _, err := p.Run(); err != nil {
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// colorMode is the global --color setting: auto, always or never.
+var colorMode = "auto"
+
+// ANSI SGR codes used by the CLI's colored output.
+const (
+	colorRed    = "31"
+	colorGreen  = "32"
+	colorYellow = "33"
+	colorBold   = "1"
+)
+
+// extractColorFlag removes the global --color, --color=MODE and
+// --no-color flags given before the subcommand name. It returns the
+// remaining arguments and the chosen mode. Anything after the
+// subcommand is left to its FlagSet, which takes the same flags through
+// colorFlags, so the value of another flag is never taken for one.
+func extractColorFlag(args []string) ([]string, string, error) {
+	mode := colorMode
+	i := 0
+leading:
+	for ; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		switch name {
+		case "no-color":
+			mode = "never"
+		case "color":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return nil, "", fmt.Errorf("--color needs a value: auto, always or never")
+				}
+				i++
+				value = args[i]
+			}
+			mode = value
+		default:
+			break leading
+		}
+	}
+	if err := checkColorMode(mode); err != nil {
+		return nil, "", err
+	}
+	return args[i:], mode, nil
+}
+
+// colorFlags adds --color and --no-color to a subcommand's FlagSet so
+// they also work after the subcommand name. They set colorMode.
+func colorFlags(fs *flag.FlagSet) {
+	fs.Func("color", "Colorize output: auto, always or never", func(v string) error {
+		if err := checkColorMode(v); err != nil {
+			return err
+		}
+		colorMode = v
+		return nil
+	})
+	fs.BoolFunc("no-color", "Same as --color never", func(v string) error {
+		off, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		if off {
+			colorMode = "never"
+		}
+		return nil
+	})
+}
+
+// checkColorMode rejects a --color value other than auto, always or
+// never.
+func checkColorMode(mode string) error {
+	if mode != "auto" && mode != "always" && mode != "never" {
+		return fmt.Errorf("invalid --color %q: want auto, always or never", mode)
+	}
+	return nil
+}
+
+// colorEnabled reports whether output to w should be colored. In auto
+// mode that means w is a terminal and NO_COLOR is not set.
+func colorEnabled(w io.Writer) bool {
+	switch colorMode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	return os.Getenv("NO_COLOR") == "" && isTerminal(w)
+}
+
+// isTerminal reports whether w is a character device such as a TTY.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// paint wraps s in the given SGR code when w gets colored output.
+func paint(w io.Writer, code, s string) string {
+	if !colorEnabled(w) {
+		return s
+	}
+	return "\033[" + code + "m" + s + "\033[0m"
+}
+
+// statusColor picks the color for a trace or span status.
+func statusColor(status string) string {
+	switch status {
+	case "error", "failed":
+		return colorRed
+	case "running":
+		return colorYellow
+	default:
+		return colorGreen
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+	"github.com/Mr-Dark-debug/oculo/internal/ingestion"
+)
+
+func TestExtractColorFlag(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		rest []string
+		mode string
+	}{
+		{[]string{"query", "--limit", "5"}, []string{"query", "--limit", "5"}, "auto"},
+		{[]string{"--color", "never", "watch"}, []string{"watch"}, "never"},
+		{[]string{"--color=always", "--no-color", "status"}, []string{"status"}, "never"},
+		// After the subcommand the flags are its FlagSet's to parse
+		{[]string{"watch", "--color=always", "--agent", "x"}, []string{"watch", "--color=always", "--agent", "x"}, "auto"},
+		{[]string{"query", "--search", "--color"}, []string{"query", "--search", "--color"}, "auto"},
+		{[]string{"--help"}, []string{"--help"}, "auto"},
+	} {
+		rest, mode, err := extractColorFlag(tt.args)
+		if err != nil || mode != tt.mode || !reflect.DeepEqual(rest, tt.rest) {
+			t.Errorf("extractColorFlag(%q) = %q, %q, %v; want %q, %q", tt.args, rest, mode, err, tt.rest, tt.mode)
+		}
+	}
+
+	for _, bad := range [][]string{{"--color", "rainbow"}, {"--color"}} {
+		if _, _, err := extractColorFlag(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestColorFlagsOnSubcommand(t *testing.T) {
+	withColorMode(t, "auto")
+
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	search := fs.String("search", "", "")
+	colorFlags(fs)
+	if err := fs.Parse([]string{"--search", "--color", "--color=never"}); err != nil {
+		t.Fatal(err)
+	}
+	if *search != "--color" || colorMode != "never" {
+		t.Errorf("expected search %q and mode never, got %q and %q", "--color", *search, colorMode)
+	}
+
+	// Positional words after the flags are left alone
+	fs = flag.NewFlagSet("tag add", flag.ContinueOnError)
+	colorFlags(fs)
+	if err := fs.Parse([]string{"--no-color=false", "t1", "--color"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := fs.Args(); !reflect.DeepEqual(got, []string{"t1", "--color"}) {
+		t.Errorf("expected positional args kept, got %q", got)
+	}
+
+	fs = flag.NewFlagSet("watch", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	colorFlags(fs)
+	if err := fs.Parse([]string{"--color", "rainbow"}); err == nil {
+		t.Error("expected an invalid mode to be rejected")
+	}
+}
+
+// withColorMode sets the global --color mode for the rest of the test.
+func withColorMode(t *testing.T, mode string) {
+	t.Helper()
+	prev := colorMode
+	colorMode = mode
+	t.Cleanup(func() { colorMode = prev })
+}
+
+func TestColorNeverHasNoEscapes(t *testing.T) {
+	store := newTestDB(t)
+	seedTrace(t, store, "trace-1", "bot", 1000)
+	metrics := &ingestion.IngestionMetrics{SpansIngested: 10, ErrorCount: 2, Uptime: 5}
+	prev := &ingestion.IngestionMetrics{Uptime: 4}
+
+	render := func() string {
+		var out bytes.Buffer
+		if err := newWatcher(store, database.TraceFilter{}, &out).poll(); err != nil {
+			t.Fatalf("poll: %v", err)
+		}
+		renderTop(&out, metrics, computeRates(prev, metrics, time.Second), time.Second)
+		return out.String()
+	}
+
+	withColorMode(t, "never")
+	if out := render(); strings.Contains(out, "\033[") {
+		t.Errorf("expected no ANSI escapes with --color never, got %q", out)
+	}
+
+	withColorMode(t, "always")
+	if out := render(); !strings.Contains(out, "\033[") {
+		t.Errorf("expected ANSI escapes with --color always, got %q", out)
+	}
+}
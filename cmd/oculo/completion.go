@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// completionCommand is a subcommand with the flags and positional
+// words it accepts, as offered by the generated completion scripts.
+type completionCommand struct {
+	name  string
+	flags []string
+	args  []string
+}
+
+// completionCommands lists every subcommand of oculo with its flags
+// and positional words.
+// Keep it in step with the switch in main and each command's FlagSet;
+// --config is added by config.Parse to every command that takes flags.
+var completionCommands = []completionCommand{
+	{"analyze", []string{"trace", "db", "format", "out", "o", "pricing", "config"}, nil},
+	{"query", []string{"db", "agent", "trace", "search", "limit", "since", "until", "meta", "jsonl", "fields", "out", "o", "config"}, nil},
+	{"watch", []string{"db", "agent", "interval", "config"}, nil},
+	{"export", []string{"db", "trace", "agent", "out", "config"}, nil},
+	{"import", []string{"db", "in", "config"}, nil},
+	{"prune", []string{"db", "before", "agent", "yes", "config"}, nil},
+	{"diff", []string{"db", "base", "candidate", "format", "pricing", "config"}, nil},
+	{"stats", []string{"db", "agent", "since", "until", "format", "pricing", "config"}, nil},
+	{"cost", []string{"db", "trace", "agent", "format", "pricing", "config"}, nil},
+	{"vacuum", []string{"db", "metrics", "force", "config"}, nil},
+	{"tag", []string{"db", "trace", "filter", "limit", "config"}, []string{"add", "remove", "list"}},
+	{"validate", []string{"db", "config"}, nil},
+	{"status", []string{"metrics", "config"}, nil},
+	{"top", []string{"metrics", "interval", "config"}, nil},
+	{"completion", nil, completionShells},
+	{"version", nil, nil},
+	{"help", nil, nil},
+}
+
+// completionShells are the shells oculo completion can generate for.
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// cmdCompletion prints a shell completion script.
+func cmdCompletion() {
+	if len(os.Args) < 3 {
+		fmt.Fprintf(os.Stderr, "Usage: oculo completion %s\n", strings.Join(completionShells, "|"))
+		os.Exit(1)
+	}
+	if err := runCompletion(os.Args[2], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runCompletion writes the completion script for shell to w.
+func runCompletion(shell string, w io.Writer) error {
+	switch shell {
+	case "bash":
+		writeBashCompletion(w)
+	case "zsh":
+		writeZshCompletion(w)
+	case "fish":
+		writeFishCompletion(w)
+	default:
+		return fmt.Errorf("unsupported shell %q (want %s)", shell, strings.Join(completionShells, ", "))
+	}
+	return nil
+}
+
+func commandNames() []string {
+	names := make([]string, len(completionCommands))
+	for i, c := range completionCommands {
+		names[i] = c.name
+	}
+	return names
+}
+
+// words lists what may follow a subcommand: its positional words, then
+// its flags the way oculo accepts them on the command line, with one
+// dash for single letters and two otherwise.
+func (c completionCommand) words() string {
+	out := append([]string(nil), c.args...)
+	for _, f := range c.flags {
+		if len(f) == 1 {
+			out = append(out, "-"+f)
+		} else {
+			out = append(out, "--"+f)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func writeBashCompletion(w io.Writer) {
+	fmt.Fprintln(w, "# bash completion for oculo")
+	fmt.Fprintln(w, "# Load with: source <(oculo completion bash)")
+	fmt.Fprintln(w, "_oculo() {")
+	fmt.Fprintln(w, `    local cur="${COMP_WORDS[COMP_CWORD]}"`)
+	fmt.Fprintln(w, "    if [ \"$COMP_CWORD\" -eq 1 ]; then")
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(commandNames(), " "))
+	fmt.Fprintln(w, "        return")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, `    local words=""`)
+	fmt.Fprintln(w, `    case "${COMP_WORDS[1]}" in`)
+	for _, c := range completionCommands {
+		if words := c.words(); words != "" {
+			fmt.Fprintf(w, "        %s) words=%q ;;\n", c.name, words)
+		}
+	}
+	fmt.Fprintln(w, "    esac")
+	fmt.Fprintln(w, `    COMPREPLY=($(compgen -W "$words" -- "$cur"))`)
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -F _oculo oculo")
+}
+
+func writeZshCompletion(w io.Writer) {
+	fmt.Fprintln(w, "#compdef oculo")
+	fmt.Fprintln(w, "# zsh completion for oculo")
+	fmt.Fprintln(w, "# Load with: source <(oculo completion zsh)")
+	fmt.Fprintln(w, "_oculo() {")
+	fmt.Fprintln(w, "    if (( CURRENT == 2 )); then")
+	fmt.Fprintf(w, "        compadd -- %s\n", strings.Join(commandNames(), " "))
+	fmt.Fprintln(w, "        return")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, "    case $words[2] in")
+	for _, c := range completionCommands {
+		if words := c.words(); words != "" {
+			fmt.Fprintf(w, "        %s) compadd -- %s ;;\n", c.name, words)
+		}
+	}
+	fmt.Fprintln(w, "    esac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "compdef _oculo oculo")
+}
+
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprintln(w, "# fish completion for oculo")
+	fmt.Fprintln(w, "# Load with: oculo completion fish | source")
+	fmt.Fprintln(w, "complete -c oculo -f")
+	fmt.Fprintf(w, "complete -c oculo -n __fish_use_subcommand -a %q\n", strings.Join(commandNames(), " "))
+	for _, c := range completionCommands {
+		cond := "__fish_seen_subcommand_from " + c.name
+		if len(c.args) > 0 {
+			fmt.Fprintf(w, "complete -c oculo -n %q -a %q\n", cond, strings.Join(c.args, " "))
+		}
+		for _, f := range c.flags {
+			if len(f) == 1 {
+				fmt.Fprintf(w, "complete -c oculo -n %q -s %s\n", cond, f)
+			} else {
+				fmt.Fprintf(w, "complete -c oculo -n %q -l %s\n", cond, f)
+			}
+		}
+	}
+}
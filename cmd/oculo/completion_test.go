@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBashCompletionNamesEverySubcommand(t *testing.T) {
+	var out bytes.Buffer
+	if err := runCompletion("bash", &out); err != nil {
+		t.Fatalf("completion: %v", err)
+	}
+	script := out.String()
+	for _, name := range []string{
+		"analyze", "query", "watch", "export", "import", "prune", "diff",
+		"stats", "cost", "vacuum", "tag", "validate", "status", "top", "completion", "version",
+	} {
+		if !strings.Contains(script, name) {
+			t.Errorf("bash completion does not mention %q", name)
+		}
+	}
+	if !strings.Contains(script, "--trace") || !strings.Contains(script, "complete -F _oculo oculo") {
+		t.Errorf("expected flags and a complete registration, got:\n%s", script)
+	}
+}
+
+func TestCompletionShells(t *testing.T) {
+	for _, shell := range completionShells {
+		var out bytes.Buffer
+		if err := runCompletion(shell, &out); err != nil || !strings.Contains(out.String(), "analyze") {
+			t.Errorf("%s: err=%v, script:\n%s", shell, err, out.String())
+		}
+	}
+	if err := runCompletion("powershell", &bytes.Buffer{}); err == nil {
+		t.Error("expected an unsupported shell to be rejected")
+	}
+}
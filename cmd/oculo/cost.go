@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/Mr-Dark-debug/oculo/internal/analysis"
+	"github.com/Mr-Dark-debug/oculo/internal/config"
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+)
+
+// cmdCost prints where a trace's, or an agent's, LLM spend went.
+func cmdCost(defaultDB string) {
+	fs := flag.NewFlagSet("cost", flag.ExitOnError)
+	dbPath := fs.String("db", defaultDB, "Path to SQLite database")
+	traceID := fs.String("trace", "", "Trace ID to price")
+	agentName := fs.String("agent", "", "Price every trace from this agent")
+	outputFormat := fs.String("format", "table", "Output format: table, json, csv")
+	pricingPath := pricingFlag(fs)
+	parseFlags(fs)
+
+	if (*traceID == "") == (*agentName == "") {
+		fmt.Fprintln(os.Stderr, "Error: exactly one of --trace or --agent is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	pricing, err := loadPricing(*pricingPath)
+	if err != nil {
+		log.Fatalf("Failed to load pricing: %v", err)
+	}
+
+	store, err := database.NewDBService(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer store.Close()
+
+	if err := runCost(store, *traceID, *agentName, *outputFormat, pricing, os.Stdout); err != nil {
+		log.Fatalf("Cost failed: %v", err)
+	}
+}
+
+// costRow is the spend on one operation with one model.
+type costRow struct {
+	OperationName    string  `json:"operation_name"`
+	Model            string  `json:"model"`
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	EstimatedCost    float64 `json:"estimated_cost_usd"`
+	Percentage       float64 `json:"percentage"`
+	IsEstimated      bool    `json:"is_estimated,omitempty"`
+}
+
+// costSummary is the output of oculo cost.
+type costSummary struct {
+	TraceID               string    `json:"trace_id,omitempty"`
+	AgentName             string    `json:"agent_name,omitempty"`
+	Traces                int       `json:"traces"`
+	TotalPromptTokens     int       `json:"total_prompt_tokens"`
+	TotalCompletionTokens int       `json:"total_completion_tokens"`
+	TotalEstimatedCost    float64   `json:"total_estimated_cost_usd"`
+	Operations            []costRow `json:"operations"`
+}
+
+// add folds one trace's cost report into the summary, grouping its
+// entries by operation and model.
+func (c *costSummary) add(report *analysis.CostReport) {
+	c.Traces++
+	c.TotalPromptTokens += report.TotalPromptTokens
+	c.TotalCompletionTokens += report.TotalCompletionTokens
+	c.TotalEstimatedCost += report.TotalEstimatedCost
+
+	for _, e := range report.Entries {
+		row := c.row(e.OperationName, e.Model)
+		row.Calls++
+		row.PromptTokens += e.PromptTokens
+		row.CompletionTokens += e.CompletionTokens
+		row.EstimatedCost += e.EstimatedCost
+		row.IsEstimated = row.IsEstimated || e.IsEstimated
+	}
+}
+
+// row returns the row for an operation and model, adding it if needed.
+func (c *costSummary) row(operation, model string) *costRow {
+	for i := range c.Operations {
+		if c.Operations[i].OperationName == operation && c.Operations[i].Model == model {
+			return &c.Operations[i]
+		}
+	}
+	c.Operations = append(c.Operations, costRow{OperationName: operation, Model: model})
+	return &c.Operations[len(c.Operations)-1]
+}
+
+// finish sorts the operations by cost, most expensive first, and
+// works out each one's share of the total.
+func (c *costSummary) finish() {
+	sort.SliceStable(c.Operations, func(i, j int) bool {
+		return c.Operations[i].EstimatedCost > c.Operations[j].EstimatedCost
+	})
+	for i := range c.Operations {
+		if c.TotalEstimatedCost > 0 {
+			c.Operations[i].Percentage = math.Round(c.Operations[i].EstimatedCost/c.TotalEstimatedCost*10000) / 100
+		}
+	}
+}
+
+// runCost writes the cost breakdown of a trace, or of every trace from
+// agentName when traceID is empty, to w.
+func runCost(store database.Store, traceID, agentName, format string, pricing analysis.PricingTable, w io.Writer) error {
+	if format != "table" && format != "json" && format != "csv" {
+		return fmt.Errorf("unknown format: %s", format)
+	}
+
+	analyzer := analysis.NewAnalyzerWithPricing(store, pricing)
+	summary := &costSummary{TraceID: traceID, AgentName: agentName, Operations: []costRow{}}
+	if traceID != "" {
+		report, err := analyzer.AttributeCosts(traceID)
+		if err != nil {
+			return err
+		}
+		summary.add(report)
+	} else {
+		const page = 100
+		for offset := 0; ; offset += page {
+			traces, err := store.QueryTraces(database.TraceFilter{AgentName: &agentName, Limit: page, Offset: offset})
+			if err != nil {
+				return err
+			}
+			for _, t := range traces {
+				report, err := analyzer.AttributeCosts(t.TraceID)
+				if err != nil {
+					return err
+				}
+				summary.add(report)
+			}
+			if len(traces) < page {
+				break
+			}
+		}
+		if summary.Traces == 0 {
+			return fmt.Errorf("no traces found for agent %s", agentName)
+		}
+	}
+	summary.finish()
+
+	switch format {
+	case "json":
+		return writeJSON(w, summary)
+	case "csv":
+		return writeCostCSV(w, summary)
+	}
+	return writeCostTable(w, summary)
+}
+
+func writeCostTable(w io.Writer, c *costSummary) error {
+	if len(c.Operations) == 0 {
+		_, err := fmt.Fprintln(w, "No LLM calls found.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OPERATION\tMODEL\tCALLS\tPROMPT TOK\tCOMPL TOK\tEST. COST\tSHARE")
+	for _, r := range c.Operations {
+		// A star marks a cost priced at the default rate because the
+		// model has no known price.
+		mark := ""
+		if r.IsEstimated {
+			mark = "*"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t$%.4f%s\t%.1f%%\n",
+			r.OperationName, r.Model, r.Calls, r.PromptTokens, r.CompletionTokens, r.EstimatedCost, mark, r.Percentage)
+	}
+	fmt.Fprintf(tw, "total\t\t\t%d\t%d\t$%.4f\t\n", c.TotalPromptTokens, c.TotalCompletionTokens, c.TotalEstimatedCost)
+	return tw.Flush()
+}
+
+// pricingFlag adds the --pricing flag, a JSON file of model prices that
+// override the built-in ones. The config file's pricing_file fills it.
+func pricingFlag(fs *flag.FlagSet) *string {
+	return fs.String("pricing", "", "JSON file of model prices per 1K tokens, e.g. {\"my-model\": [0.001, 0.002]}")
+}
+
+// loadPricing reads the --pricing file, if one was given. A leading
+// "~/" is expanded, as config files tend to use it.
+func loadPricing(path string) (analysis.PricingTable, error) {
+	if path == "" {
+		return nil, nil
+	}
+	path, err := config.ExpandHome(path)
+	if err != nil {
+		return nil, err
+	}
+	return analysis.LoadPricing(path)
+}
+
+func writeCostCSV(w io.Writer, c *costSummary) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"operation", "model", "calls", "prompt_tokens", "completion_tokens", "estimated_cost_usd", "percentage", "is_estimated"})
+	for _, r := range c.Operations {
+		cw.Write([]string{
+			r.OperationName, r.Model, strconv.Itoa(r.Calls),
+			strconv.Itoa(r.PromptTokens), strconv.Itoa(r.CompletionTokens),
+			strconv.FormatFloat(r.EstimatedCost, 'f', 4, 64), strconv.FormatFloat(r.Percentage, 'f', 2, 64),
+			strconv.FormatBool(r.IsEstimated),
+		})
+	}
+	cw.Write([]string{
+		"total", "", "",
+		strconv.Itoa(c.TotalPromptTokens), strconv.Itoa(c.TotalCompletionTokens),
+		strconv.FormatFloat(c.TotalEstimatedCost, 'f', 4, 64), "", "",
+	})
+	cw.Flush()
+	return cw.Error()
+}
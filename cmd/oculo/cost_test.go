@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Mr-Dark-debug/oculo/internal/analysis"
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+)
+
+// seedCostTrace adds LLM spans on two models to a seeded trace.
+func seedCostTrace(t *testing.T, store *database.DBService, traceID, agent string, start int64) {
+	t.Helper()
+	seedTrace(t, store, traceID, agent, start)
+	gpt, opus := "gpt-4o", "claude-3-opus"
+	for i, sp := range []*database.Span{
+		{OperationName: "plan", Model: &opus, PromptTokens: 900, CompletionTokens: 300},
+		{OperationName: "chat", Model: &gpt, PromptTokens: 400, CompletionTokens: 100},
+		{OperationName: "chat", Model: &gpt, PromptTokens: 200, CompletionTokens: 80},
+	} {
+		sp.SpanID = fmt.Sprintf("%s-llm%d", traceID, i)
+		sp.TraceID = traceID
+		sp.OperationType = "LLM"
+		sp.StartTime = start + int64(i+1)
+		sp.Status = "ok"
+		if err := store.InsertSpan(sp); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCostTotalMatchesAnalyzer(t *testing.T) {
+	store := newTestDB(t)
+	seedCostTrace(t, store, "trace-1", "bot", 1000)
+
+	want, err := analysis.NewAnalyzer(store).AttributeCosts("trace-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := runCost(store, "trace-1", "", "table", nil, &out); err != nil {
+		t.Fatalf("cost: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	last := lines[len(lines)-1]
+	if !strings.HasPrefix(last, "total") || !strings.Contains(last, fmt.Sprintf("$%.4f", want.TotalEstimatedCost)) {
+		t.Errorf("expected a total of $%.4f, got %q", want.TotalEstimatedCost, last)
+	}
+	if !strings.HasPrefix(lines[1], "plan") {
+		t.Errorf("expected the most expensive operation first, got %q", lines[1])
+	}
+
+	out.Reset()
+	if err := runCost(store, "trace-1", "", "json", nil, &out); err != nil {
+		t.Fatalf("cost: %v", err)
+	}
+	var summary costSummary
+	json.Unmarshal(out.Bytes(), &summary)
+	if math.Abs(summary.TotalEstimatedCost-want.TotalEstimatedCost) > 1e-9 {
+		t.Errorf("expected a JSON total of %f, got %f", want.TotalEstimatedCost, summary.TotalEstimatedCost)
+	}
+	// The two gpt-4o chat calls are grouped into one row.
+	if len(summary.Operations) != 3 || summary.Operations[1].Calls != 2 {
+		t.Errorf("expected plan, chat x2 and the seeded chat, got %+v", summary.Operations)
+	}
+	for i := 1; i < len(summary.Operations); i++ {
+		if summary.Operations[i].EstimatedCost > summary.Operations[i-1].EstimatedCost {
+			t.Errorf("operations not sorted by cost: %+v", summary.Operations)
+		}
+	}
+}
+
+func TestCostCSV(t *testing.T) {
+	store := newTestDB(t)
+	seedCostTrace(t, store, "trace-1", "bot", 1000)
+
+	var out bytes.Buffer
+	if err := runCost(store, "trace-1", "", "csv", nil, &out); err != nil {
+		t.Fatalf("cost: %v", err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV: %v", err)
+	}
+	if len(records) != 5 || records[0][0] != "operation" || records[4][0] != "total" {
+		t.Errorf("expected a header, 3 operations and a total, got %v", records)
+	}
+}
+
+func TestCostAcrossAgent(t *testing.T) {
+	store := newTestDB(t)
+	seedCostTrace(t, store, "a1", "bot", 1000)
+	seedCostTrace(t, store, "a2", "bot", 2000)
+	seedCostTrace(t, store, "b1", "other", 3000)
+
+	single, _ := analysis.NewAnalyzer(store).AttributeCosts("a1")
+
+	var out bytes.Buffer
+	if err := runCost(store, "", "bot", "json", nil, &out); err != nil {
+		t.Fatalf("cost: %v", err)
+	}
+	var summary costSummary
+	json.Unmarshal(out.Bytes(), &summary)
+	if summary.Traces != 2 {
+		t.Errorf("expected 2 traces, got %d", summary.Traces)
+	}
+	if math.Abs(summary.TotalEstimatedCost-2*single.TotalEstimatedCost) > 1e-9 {
+		t.Errorf("expected twice one trace's cost, got %f", summary.TotalEstimatedCost)
+	}
+	if summary.Operations[0].Calls != 2 {
+		t.Errorf("expected each operation grouped across traces, got %+v", summary.Operations[0])
+	}
+
+	if err := runCost(store, "", "nobody", "table", nil, &out); err == nil {
+		t.Error("expected an agent without traces to fail")
+	}
+}
+
+func TestCostPricingFile(t *testing.T) {
+	store := newTestDB(t)
+	own := "my-llama"
+	if err := store.InsertTrace(&database.Trace{TraceID: "trace-1", AgentName: "bot", Status: "completed"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.InsertSpan(&database.Span{SpanID: "s1", TraceID: "trace-1", OperationType: "LLM",
+		OperationName: "chat", Model: &own, PromptTokens: 1000, CompletionTokens: 1000, Status: "ok"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := runCost(store, "trace-1", "", "table", nil, &out); err != nil {
+		t.Fatalf("cost: %v", err)
+	}
+	if !strings.Contains(out.String(), "$0.0400*") {
+		t.Errorf("expected an unpriced model to be marked, got:\n%s", out.String())
+	}
+
+	path := filepath.Join(t.TempDir(), "pricing.json")
+	if err := os.WriteFile(path, []byte(`{"my-llama": [0.001, 0.002]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pricing, err := loadPricing(path)
+	if err != nil {
+		t.Fatalf("loadPricing: %v", err)
+	}
+	out.Reset()
+	if err := runCost(store, "trace-1", "", "table", pricing, &out); err != nil {
+		t.Fatalf("cost: %v", err)
+	}
+	if !strings.Contains(out.String(), "$0.0030") || strings.Contains(out.String(), "*") {
+		t.Errorf("expected the pricing file's rate, got:\n%s", out.String())
+	}
+}
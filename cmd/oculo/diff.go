@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/Mr-Dark-debug/oculo/internal/analysis"
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+)
+
+// cmdDiff compares a candidate trace against a baseline.
+func cmdDiff(defaultDB string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	dbPath := fs.String("db", defaultDB, "Path to SQLite database")
+	baseID := fs.String("base", "", "Baseline trace ID (required)")
+	candidateID := fs.String("candidate", "", "Candidate trace ID (required)")
+	outputFormat := fs.String("format", "markdown", "Output format: markdown, json")
+	pricingPath := pricingFlag(fs)
+	parseFlags(fs)
+
+	if *baseID == "" || *candidateID == "" {
+		fmt.Fprintln(os.Stderr, "Error: --base and --candidate are required")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	pricing, err := loadPricing(*pricingPath)
+	if err != nil {
+		log.Fatalf("Failed to load pricing: %v", err)
+	}
+
+	store, err := database.NewDBService(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer store.Close()
+
+	if err := runDiff(store, *baseID, *candidateID, *outputFormat, pricing, os.Stdout); err != nil {
+		log.Fatalf("Diff failed: %v", err)
+	}
+}
+
+// runDiff writes the comparison of two traces to w.
+func runDiff(store database.Store, baseID, candidateID, format string, pricing analysis.PricingTable, w io.Writer) error {
+	if format != "markdown" && format != "json" {
+		return fmt.Errorf("unknown format: %s", format)
+	}
+
+	analyzer := analysis.NewAnalyzerWithPricing(store, pricing)
+	comparison, err := analyzer.CompareTraces(baseID, candidateID)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		return writeJSON(w, comparison)
+	}
+	_, err = io.WriteString(w, analyzer.FormatComparison(comparison))
+	return err
+}
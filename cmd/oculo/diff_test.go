@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Mr-Dark-debug/oculo/internal/analysis"
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+)
+
+// seedDiffTraces stores a baseline with two LLM calls and a candidate
+// that adds a tool call and uses more tokens and time.
+func seedDiffTraces(t *testing.T) *database.DBService {
+	t.Helper()
+	store := newTestDB(t)
+	model := "gpt-4o"
+	spans := []*database.Span{
+		{SpanID: "b1", TraceID: "base", OperationType: "LLM", OperationName: "plan", StartTime: 0, DurationMs: 100, PromptTokens: 100, CompletionTokens: 50, Model: &model},
+		{SpanID: "b2", TraceID: "base", OperationType: "LLM", OperationName: "answer", StartTime: 100e6, DurationMs: 100, PromptTokens: 100, CompletionTokens: 50, Model: &model},
+		{SpanID: "c1", TraceID: "cand", OperationType: "LLM", OperationName: "plan", StartTime: 0, DurationMs: 100, PromptTokens: 100, CompletionTokens: 50, Model: &model},
+		{SpanID: "c2", TraceID: "cand", OperationType: "TOOL", OperationName: "search", StartTime: 100e6, DurationMs: 200},
+		{SpanID: "c3", TraceID: "cand", OperationType: "LLM", OperationName: "answer", StartTime: 300e6, DurationMs: 100, PromptTokens: 300, CompletionTokens: 50, Model: &model},
+	}
+	store.InsertTrace(&database.Trace{TraceID: "base", AgentName: "bot", StartTime: 0, Status: "completed"})
+	store.InsertTrace(&database.Trace{TraceID: "cand", AgentName: "bot", StartTime: 0, Status: "completed"})
+	for _, s := range spans {
+		s.Status = "ok"
+		if err := store.InsertSpan(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store
+}
+
+func TestDiffMarkdown(t *testing.T) {
+	store := seedDiffTraces(t)
+
+	var out bytes.Buffer
+	if err := runDiff(store, "base", "cand", "markdown", nil, &out); err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"| Spans | 2 | 3 | +1 (+50.0%) |",
+		"| Tokens | 300 | 500 | +200 (+66.7%) |",
+		"| Duration | 200ms | 400ms | +200ms (+100.0%) |",
+		"## Added Operations",
+		"- +1 × TOOL search",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in output:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Removed Operations") {
+		t.Errorf("expected no removed operations:\n%s", got)
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	store := seedDiffTraces(t)
+
+	var out bytes.Buffer
+	if err := runDiff(store, "base", "cand", "json", nil, &out); err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	var c analysis.TraceComparison
+	if err := json.Unmarshal(out.Bytes(), &c); err != nil {
+		t.Fatalf("decoding output: %v\n%s", err, out.String())
+	}
+	if c.SpanDelta != 1 || c.TokenDelta != 200 || c.DurationDeltaMs != 200 || c.CostDelta <= 0 {
+		t.Errorf("unexpected deltas: %+v", c)
+	}
+	if len(c.AddedOperations) != 1 || c.AddedOperations[0].OperationName != "search" {
+		t.Errorf("expected search to be added, got %+v", c.AddedOperations)
+	}
+
+	// Swapping the sides reports the tool call as removed
+	out.Reset()
+	runDiff(store, "cand", "base", "json", nil, &out)
+	json.Unmarshal(out.Bytes(), &c)
+	if len(c.RemovedOperations) != 1 || c.RemovedOperations[0].OperationName != "search" {
+		t.Errorf("expected search to be removed, got %+v", c.RemovedOperations)
+	}
+}
+
+// TestDiffPricing verifies the cost delta uses a custom pricing table.
+func TestDiffPricing(t *testing.T) {
+	store := seedDiffTraces(t)
+	pricing := analysis.PricingTable{"gpt-4o": {1, 0}}
+
+	var out bytes.Buffer
+	if err := runDiff(store, "base", "cand", "json", pricing, &out); err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	var c analysis.TraceComparison
+	if err := json.Unmarshal(out.Bytes(), &c); err != nil {
+		t.Fatalf("decoding output: %v\n%s", err, out.String())
+	}
+	if c.Base.EstimatedCost != 0.2 || c.Candidate.EstimatedCost != 0.4 || c.CostDelta != 0.2 {
+		t.Errorf("expected costs from the pricing table, got %+v", c)
+	}
+}
+
+func TestDiffErrors(t *testing.T) {
+	store := seedDiffTraces(t)
+	if err := runDiff(store, "base", "missing", "markdown", nil, &bytes.Buffer{}); err == nil {
+		t.Error("expected an error for a missing trace")
+	}
+	if err := runDiff(store, "base", "cand", "yaml", nil, &bytes.Buffer{}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+)
+
+// cmdExport writes one trace, or all of an agent's traces, to JSON.
+func cmdExport(defaultDB string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	dbPath := fs.String("db", defaultDB, "Path to SQLite database")
+	traceID := fs.String("trace", "", "Trace ID to export")
+	agentName := fs.String("agent", "", "Export every trace from this agent into the --out directory")
+	out := fs.String("out", "", "Output file (or directory with --agent); stdout if omitted for --trace")
+	parseFlags(fs)
+
+	if (*traceID == "") == (*agentName == "") {
+		fmt.Fprintln(os.Stderr, "Error: exactly one of --trace or --agent is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *agentName != "" && *out == "" {
+		fmt.Fprintln(os.Stderr, "Error: --out is required with --agent")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	store, err := database.NewDBService(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer store.Close()
+
+	if *agentName != "" {
+		err = runExportAgent(store, *agentName, *out, os.Stderr)
+	} else {
+		err = runExportTrace(store, *traceID, *out, os.Stdout)
+	}
+	if err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+}
+
+// cmdImport loads traces written by oculo export.
+func cmdImport(defaultDB string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	dbPath := fs.String("db", defaultDB, "Path to SQLite database")
+	in := fs.String("in", "", "Exported JSON file, or a directory of them (required)")
+	parseFlags(fs)
+
+	if *in == "" {
+		fmt.Fprintln(os.Stderr, "Error: --in is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	store, err := database.NewDBService(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer store.Close()
+
+	if err := runImport(store, *in, os.Stdout); err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+}
+
+// runExportTrace writes one trace to path, or to w when path is empty.
+func runExportTrace(store database.Store, traceID, path string, w io.Writer) error {
+	exp, err := store.ExportTrace(traceID)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return writeExport(w, exp)
+	}
+	if err := writeExportFile(path, exp); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Exported trace %s (%d spans) to %s\n", traceID, len(exp.Spans), path)
+	return nil
+}
+
+// runExportAgent writes each of an agent's traces to <dir>/<trace ID>.json.
+func runExportAgent(store database.Store, agentName, dir string, w io.Writer) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating %s: %w", dir, err)
+	}
+
+	const page = 100
+	count := 0
+	for offset := 0; ; offset += page {
+		traces, err := store.QueryTraces(database.TraceFilter{AgentName: &agentName, Limit: page, Offset: offset})
+		if err != nil {
+			return err
+		}
+		for _, t := range traces {
+			exp, err := store.ExportTrace(t.TraceID)
+			if err != nil {
+				return err
+			}
+			if err := writeExportFile(filepath.Join(dir, exportFileName(t.TraceID)), exp); err != nil {
+				return err
+			}
+			count++
+		}
+		if len(traces) < page {
+			break
+		}
+	}
+	if count == 0 {
+		return fmt.Errorf("no traces found for agent %s", agentName)
+	}
+	fmt.Fprintf(w, "Exported %d traces for agent %s to %s\n", count, agentName, dir)
+	return nil
+}
+
+// runImport imports an exported file, or every .json file in a directory.
+func runImport(store database.Store, path string, w io.Writer) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		if files, err = filepath.Glob(filepath.Join(path, "*.json")); err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no .json files in %s", path)
+		}
+	}
+
+	for _, file := range files {
+		exp, err := readExportFile(file)
+		if err != nil {
+			return err
+		}
+		if err := store.ImportTrace(exp); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		fmt.Fprintf(w, "Imported trace %s (%d spans) from %s\n", exp.Trace.TraceID, len(exp.Spans), file)
+	}
+	return nil
+}
+
+func writeExport(w io.Writer, exp *database.TraceExport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(exp)
+}
+
+func writeExportFile(path string, exp *database.TraceExport) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeExport(f, exp); err != nil {
+		f.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+func readExportFile(path string) (*database.TraceExport, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var exp database.TraceExport
+	if err := json.Unmarshal(b, &exp); err != nil {
+		return nil, fmt.Errorf("%s: not an oculo export: %w", path, err)
+	}
+	if exp.Trace == nil {
+		return nil, fmt.Errorf("%s: not an oculo export: no trace", path)
+	}
+	return &exp, nil
+}
+
+// exportFileName turns a trace ID into a safe file name.
+func exportFileName(traceID string) string {
+	return strings.NewReplacer("/", "_", `\`, "_").Replace(traceID) + ".json"
+}
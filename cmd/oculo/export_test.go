@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+)
+
+// newTestDB opens a fresh database file in a temporary directory.
+func newTestDB(t *testing.T) *database.DBService {
+	t.Helper()
+	store, err := database.NewDBService(filepath.Join(t.TempDir(), "oculo.db"))
+	if err != nil {
+		t.Fatalf("NewDBService: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// seedTrace inserts a completed trace with one LLM span, a memory event
+// and a tool call.
+func seedTrace(t *testing.T, store *database.DBService, traceID, agent string, start int64) {
+	t.Helper()
+	prompt, value, args := "hello", `{"goal":"x"}`, `{"q":1}`
+	steps := []error{
+		store.InsertTrace(&database.Trace{TraceID: traceID, AgentName: agent, StartTime: start, Status: "completed",
+			Metadata: map[string]string{"env": "test"}}),
+		store.InsertSpan(&database.Span{SpanID: traceID + "-s1", TraceID: traceID, OperationType: "LLM",
+			OperationName: "chat", StartTime: start, DurationMs: 40, Prompt: &prompt, PromptTokens: 12, Status: "ok"}),
+		store.InsertMemoryEvent(&database.MemoryEvent{EventID: traceID + "-e1", SpanID: traceID + "-s1",
+			Timestamp: start, Operation: "ADD", Key: "plan", NewValue: &value, Namespace: "default"}),
+		store.InsertToolCall(&database.ToolCall{SpanID: traceID + "-s1", ToolName: "search",
+			ArgumentsJSON: &args, Success: true, LatencyMs: 5}),
+	}
+	for _, err := range steps {
+		if err != nil {
+			t.Fatalf("seeding %s: %v", traceID, err)
+		}
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	src := newTestDB(t)
+	seedTrace(t, src, "trace-1", "bot", 1000)
+	src.AddTraceTags("trace-1", []string{"baseline", "ci"})
+
+	file := filepath.Join(t.TempDir(), "trace.json")
+	var out bytes.Buffer
+	if err := runExportTrace(src, "trace-1", file, &out); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if !strings.Contains(out.String(), file) {
+		t.Errorf("expected the output path to be reported, got %q", out.String())
+	}
+
+	dst := newTestDB(t)
+	if err := runImport(dst, file, &bytes.Buffer{}); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	want, _ := src.ExportTrace("trace-1")
+	got, err := dst.ExportTrace("trace-1")
+	if err != nil {
+		t.Fatalf("reading imported trace: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("imported trace differs:\ngot  %+v\nwant %+v", got, want)
+	}
+	if !reflect.DeepEqual(got.Tags, []string{"baseline", "ci"}) {
+		t.Errorf("expected the tags to survive the round trip, got %v", got.Tags)
+	}
+
+	if err := runImport(dst, file, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected importing twice to fail, got %v", err)
+	}
+}
+
+func TestExportAgentDirectory(t *testing.T) {
+	src := newTestDB(t)
+	seedTrace(t, src, "trace-1", "bot", 1000)
+	seedTrace(t, src, "trace-2", "bot", 2000)
+	seedTrace(t, src, "other", "someone-else", 3000)
+
+	dir := filepath.Join(t.TempDir(), "exports")
+	if err := runExportAgent(src, "bot", dir, &bytes.Buffer{}); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %v", files)
+	}
+
+	dst := newTestDB(t)
+	if err := runImport(dst, dir, &bytes.Buffer{}); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	traces, _ := dst.QueryTraces(database.TraceFilter{})
+	if len(traces) != 2 {
+		t.Errorf("expected 2 imported traces, got %d", len(traces))
+	}
+
+	if err := runExportAgent(src, "nobody", filepath.Join(t.TempDir(), "none"), &bytes.Buffer{}); err == nil {
+		t.Error("expected an error for an agent with no traces")
+	}
+}
+
+func TestExportImportErrors(t *testing.T) {
+	store := newTestDB(t)
+	if err := runExportTrace(store, "missing", "", &bytes.Buffer{}); err == nil {
+		t.Error("expected an error exporting a missing trace")
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.json")
+	os.WriteFile(bad, []byte(`{"spans":[]}`), 0o644)
+	if err := runImport(store, bad, &bytes.Buffer{}); err == nil {
+		t.Error("expected an error importing a file with no trace")
+	}
+	if err := runImport(store, filepath.Join(t.TempDir(), "nope.json"), &bytes.Buffer{}); err == nil {
+		t.Error("expected an error importing a missing file")
+	}
+}
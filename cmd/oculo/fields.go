@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// jsonFieldNames returns the JSON keys of struct type t, in field order.
+func jsonFieldNames(t reflect.Type) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// parseFields splits a --fields list and checks each name against the
+// JSON keys of T.
+func parseFields[T any](list string) ([]string, error) {
+	known := jsonFieldNames(reflect.TypeOf((*T)(nil)).Elem())
+	var fields []string
+	for _, f := range strings.Split(list, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		valid := false
+		for _, k := range known {
+			valid = valid || k == f
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown field %q (want one of: %s)", f, strings.Join(known, ", "))
+		}
+		fields = append(fields, f)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("--fields is empty")
+	}
+	return fields, nil
+}
+
+// projectFields reduces each item to the given JSON keys. Keys the item
+// omits, such as unset optional fields, come back as null.
+func projectFields[T any](items []T, fields []string) ([]map[string]json.RawMessage, error) {
+	rows := make([]map[string]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		b, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(b, &all); err != nil {
+			return nil, err
+		}
+		row := make(map[string]json.RawMessage, len(fields))
+		for _, f := range fields {
+			if v, ok := all[f]; ok {
+				row[f] = v
+			} else {
+				row[f] = json.RawMessage("null")
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// writeQueryResults writes items the way opts asks: projected to
+// opts.fields when given, and as JSON Lines with opts.jsonl.
+func writeQueryResults[T any](w io.Writer, items []T, opts queryOptions) error {
+	if opts.fields == "" {
+		return writeResults(w, items, opts.jsonl)
+	}
+	fields, err := parseFields[T](opts.fields)
+	if err != nil {
+		return err
+	}
+	rows, err := projectFields(items, fields)
+	if err != nil {
+		return err
+	}
+	return writeResults(w, rows, opts.jsonl)
+}
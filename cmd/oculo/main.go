@@ -8,22 +8,37 @@
 //
 //	analyze   Run semantic analysis on a trace
 //	query     Query traces and spans
+//	watch     Print new traces and spans as they arrive
+//	export    Write traces to JSON files
+//	import    Load traces written by export
+//	prune     Delete traces older than a cutoff
+//	diff      Compare a trace against a baseline
+//	stats     Show per-agent totals
+//	cost      Break down a trace's LLM cost
+//	vacuum    Compact the database file
+//	tag       Add, remove and list trace tags
+//	validate  Check the database for corruption
+//	top       Show live daemon ingestion rates
+//	completion  Print a shell completion script
 //	status    Show daemon status
 //	version   Print version information
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
-	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/Mr-Dark-debug/oculo/internal/analysis"
+	"github.com/Mr-Dark-debug/oculo/internal/config"
 	"github.com/Mr-Dark-debug/oculo/internal/database"
 	"github.com/Mr-Dark-debug/oculo/internal/ingestion"
+	"github.com/Mr-Dark-debug/oculo/pkg/timeutil"
 )
 
 var (
@@ -33,6 +48,14 @@ var (
 )
 
 func main() {
+	args, mode, err := extractColorFlag(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	colorMode = mode
+	os.Args = append(os.Args[:1], args...)
+
 	if len(os.Args) < 2 {
 		printUsage()
 		os.Exit(1)
@@ -46,8 +69,32 @@ func main() {
 		cmdAnalyze(defaultDB)
 	case "query":
 		cmdQuery(defaultDB)
+	case "watch":
+		cmdWatch(defaultDB)
+	case "export":
+		cmdExport(defaultDB)
+	case "import":
+		cmdImport(defaultDB)
+	case "prune":
+		cmdPrune(defaultDB)
+	case "diff":
+		cmdDiff(defaultDB)
+	case "stats":
+		cmdStats(defaultDB)
+	case "cost":
+		cmdCost(defaultDB)
+	case "vacuum":
+		cmdVacuum(defaultDB)
+	case "tag":
+		cmdTag(defaultDB)
+	case "validate":
+		cmdValidate(defaultDB)
 	case "status":
 		cmdStatus()
+	case "top":
+		cmdTop()
+	case "completion":
+		cmdCompletion()
 	case "version":
 		fmt.Printf("Oculo v%s (commit: %s, built: %s)\n", Version, GitCommit, BuildTime)
 	case "help", "--help", "-h":
@@ -68,10 +115,36 @@ Usage:
 Commands:
   analyze    Run semantic analysis on a trace
   query      Query traces and spans
+  watch      Print new traces and spans as they arrive
+  export     Write traces to JSON files
+  import     Load traces written by export
+  prune      Delete traces older than a cutoff
+  diff       Compare a trace against a baseline
+  stats      Show per-agent span, token, cost and error totals
+  cost       Break down LLM cost by operation for a trace or agent
+  vacuum     Checkpoint and compact the database file
+  tag        Add, remove and list trace tags
+  validate   Check the database for corruption and orphaned rows
   status     Show daemon status and metrics
+  top        Show live daemon ingestion rates
+  completion Print a bash, zsh or fish completion script
   version    Print version information
 
-Run 'oculo <command> --help' for details on each command.`)
+Global flags:
+  --color auto|always|never   Colorize output (default auto: only on a terminal)
+
+Run 'oculo <command> --help' for details on each command.
+Flag defaults can be set in ~/.oculo/config.json (see --config).`)
+}
+
+// parseFlags parses a command's flags, filling in those not given on
+// the command line from the config file. It adds --color and
+// --no-color first so they can follow the subcommand.
+func parseFlags(fs *flag.FlagSet) {
+	colorFlags(fs)
+	if err := config.Parse(fs, os.Args[2:]); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
 }
 
 // cmdAnalyze runs the full analysis suite on a trace and outputs a report.
@@ -80,7 +153,9 @@ func cmdAnalyze(defaultDB string) {
 	traceID := fs.String("trace", "", "Trace ID to analyze (required)")
 	dbPath := fs.String("db", defaultDB, "Path to SQLite database")
 	outputFormat := fs.String("format", "markdown", "Output format: markdown, json")
-	fs.Parse(os.Args[2:])
+	out := outputFlag(fs)
+	pricingPath := pricingFlag(fs)
+	parseFlags(fs)
 
 	if *traceID == "" {
 		fmt.Fprintln(os.Stderr, "Error: --trace is required")
@@ -88,39 +163,106 @@ func cmdAnalyze(defaultDB string) {
 		os.Exit(1)
 	}
 
+	pricing, err := loadPricing(*pricingPath)
+	if err != nil {
+		log.Fatalf("Failed to load pricing: %v", err)
+	}
+
 	store, err := database.NewDBService(*dbPath)
 	if err != nil {
 		log.Fatalf("Failed to open database: %v", err)
 	}
 	defer store.Close()
 
-	analyzer := analysis.NewAnalyzer(store)
-	report, err := analyzer.FullAnalysis(*traceID)
+	err = withOutput(*out, os.Stdout, func(w io.Writer) error {
+		return runAnalyze(store, *traceID, *outputFormat, pricing, w)
+	})
 	if err != nil {
 		log.Fatalf("Analysis failed: %v", err)
 	}
+}
 
-	switch *outputFormat {
-	case "json":
-		b, _ := json.MarshalIndent(report, "", "  ")
-		fmt.Println(string(b))
-	case "markdown":
-		fmt.Print(analyzer.FormatReport(report))
-	default:
-		fmt.Fprintf(os.Stderr, "Unknown format: %s\n", *outputFormat)
-		os.Exit(1)
+// runAnalyze writes the analysis report for a trace to w, pricing LLM
+// calls with pricing ahead of the built-in prices.
+func runAnalyze(store database.Store, traceID, format string, pricing analysis.PricingTable, w io.Writer) error {
+	if format != "markdown" && format != "json" {
+		return fmt.Errorf("unknown format: %s", format)
+	}
+
+	analyzer := analysis.NewAnalyzerWithPricing(store, pricing)
+	report, err := analyzer.FullAnalysis(traceID)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		return writeJSON(w, report)
 	}
+	_, err = io.WriteString(w, analyzer.FormatReport(report))
+	return err
+}
+
+// queryOptions are the filters of oculo query.
+type queryOptions struct {
+	agentName string
+	traceID   string
+	search    string
+	limit     int
+
+	// since and until bound the trace start time, inclusively. Both
+	// take anything timeutil.ParseRange accepts: "2024-03-01", "1h"
+	// (an hour ago), "now" or raw Unix nanoseconds.
+	since, until string
+
+	// meta holds --meta key=value pairs; a trace must match them all.
+	meta metaFlag
+
+	// jsonl writes one compact JSON object per line instead of an
+	// indented array.
+	jsonl bool
+
+	// fields is a comma-separated list of JSON keys to keep in each
+	// result; empty keeps them all.
+	fields string
+}
+
+// metaFlag collects repeated --meta key=value flags.
+type metaFlag map[string]string
+
+func (m metaFlag) String() string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (m metaFlag) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("want key=value, got %q", s)
+	}
+	m[key] = value
+	return nil
 }
 
 // cmdQuery lists traces or spans matching a filter.
 func cmdQuery(defaultDB string) {
 	fs := flag.NewFlagSet("query", flag.ExitOnError)
 	dbPath := fs.String("db", defaultDB, "Path to SQLite database")
-	agentName := fs.String("agent", "", "Filter by agent name")
-	traceID := fs.String("trace", "", "Show spans for a specific trace")
-	search := fs.String("search", "", "Full-text search over prompts/completions")
-	limit := fs.Int("limit", 20, "Maximum results")
-	fs.Parse(os.Args[2:])
+	opts := queryOptions{meta: make(metaFlag)}
+	fs.StringVar(&opts.agentName, "agent", "", "Filter by agent name")
+	fs.StringVar(&opts.traceID, "trace", "", "Show spans for a specific trace")
+	fs.StringVar(&opts.search, "search", "", "Full-text search over prompts/completions (within --trace if given)")
+	fs.IntVar(&opts.limit, "limit", 20, "Maximum results")
+	fs.StringVar(&opts.since, "since", "", "Only traces started at or after this time (e.g. 1h, 2024-03-01, Unix ns); trace listing only")
+	fs.StringVar(&opts.until, "until", "", "Only traces started at or before this time (e.g. now, 2024-03-02); trace listing only")
+	fs.Var(opts.meta, "meta", "Only traces whose metadata has key=value (repeatable); trace listing only")
+	fs.BoolVar(&opts.jsonl, "jsonl", false, "Write one JSON object per line instead of an array")
+	fs.StringVar(&opts.fields, "fields", "", "Comma-separated fields to output, e.g. span_id,duration_ms")
+	out := outputFlag(fs)
+	parseFlags(fs)
 
 	store, err := database.NewDBService(*dbPath)
 	if err != nil {
@@ -128,59 +270,86 @@ func cmdQuery(defaultDB string) {
 	}
 	defer store.Close()
 
-	if *search != "" {
-		results, err := store.SearchContent(*search, *limit)
+	err = withOutput(*out, os.Stdout, func(w io.Writer) error {
+		return runQuery(store, opts, w)
+	})
+	if err != nil {
+		log.Fatalf("Query failed: %v", err)
+	}
+}
+
+// runQuery writes the traces, spans or search results opts selects to
+// w as JSON, or as JSON Lines with opts.jsonl, keeping only opts.fields
+// when set.
+func runQuery(store database.Store, opts queryOptions, w io.Writer) error {
+	since, until, err := timeutil.ParseRange(opts.since, opts.until)
+	if err != nil {
+		return err
+	}
+	if (opts.search != "" || opts.traceID != "") && (opts.since != "" || opts.until != "") {
+		return fmt.Errorf("--since and --until only apply when listing traces, not with --trace or --search")
+	}
+	if (opts.search != "" || opts.traceID != "") && len(opts.meta) > 0 {
+		return fmt.Errorf("--meta only applies when listing traces, not with --trace or --search")
+	}
+
+	if opts.search != "" {
+		var results []*database.Span
+		var err error
+		if opts.traceID != "" {
+			results, err = store.SearchContentInTrace(opts.traceID, opts.search, opts.limit)
+		} else {
+			results, err = store.SearchContent(opts.search, opts.limit)
+		}
 		if err != nil {
-			log.Fatalf("Search failed: %v", err)
+			return fmt.Errorf("search failed: %w", err)
 		}
-		b, _ := json.MarshalIndent(results, "", "  ")
-		fmt.Println(string(b))
-		return
+		return writeQueryResults(w, results, opts)
 	}
 
-	if *traceID != "" {
-		spans, err := store.QueryTimeline(*traceID)
+	if opts.traceID != "" {
+		spans, err := store.QueryTimeline(opts.traceID)
 		if err != nil {
-			log.Fatalf("Query failed: %v", err)
+			return err
 		}
-		b, _ := json.MarshalIndent(spans, "", "  ")
-		fmt.Println(string(b))
-		return
+		return writeQueryResults(w, spans, opts)
 	}
 
-	filter := database.TraceFilter{Limit: *limit}
-	if *agentName != "" {
-		filter.AgentName = agentName
+	filter := database.TraceFilter{Limit: opts.limit, MetadataMatch: opts.meta}
+	if opts.agentName != "" {
+		filter.AgentName = &opts.agentName
+	}
+	if since != 0 {
+		filter.Since = &since
+	}
+	if until != 0 {
+		filter.Until = &until
 	}
 
 	traces, err := store.QueryTraces(filter)
 	if err != nil {
-		log.Fatalf("Query failed: %v", err)
+		return err
 	}
-	b, _ := json.MarshalIndent(traces, "", "  ")
-	fmt.Println(string(b))
+	return writeQueryResults(w, traces, opts)
 }
 
 // cmdStatus shows the current daemon status by querying the metrics endpoint.
 func cmdStatus() {
-	cfg := ingestion.DefaultConfig()
-	url := fmt.Sprintf("http://%s/api/metrics", cfg.MetricsAddr)
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	metricsAddr := fs.String("metrics", ingestion.DefaultConfig().MetricsAddr, "Daemon metrics HTTP address")
+	parseFlags(fs)
 
-	resp, err := http.Get(url)
+	url := fmt.Sprintf("http://%s/api/metrics", *metricsAddr)
+
+	metrics, err := fetchMetrics(url)
 	if err != nil {
-		fmt.Println("⚠ Oculo daemon is not running.")
+		fmt.Println(paint(os.Stdout, colorYellow, "⚠ Oculo daemon is not running."))
 		fmt.Printf("  Start it with: oculo-daemon\n")
 		fmt.Printf("  (tried: %s)\n", url)
 		os.Exit(1)
 	}
-	defer resp.Body.Close()
-
-	var metrics ingestion.IngestionMetrics
-	if err := json.NewDecoder(resp.Body).Decode(&metrics); err != nil {
-		log.Fatalf("Failed to decode metrics: %v", err)
-	}
 
-	fmt.Println("✅ Oculo daemon is running.")
+	fmt.Println(paint(os.Stdout, colorGreen, "✅ Oculo daemon is running."))
 	fmt.Println()
 	fmt.Printf("  Traces ingested:     %d\n", metrics.TracesIngested)
 	fmt.Printf("  Spans ingested:      %d\n", metrics.SpansIngested)
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+)
+
+func TestQueryTimeRange(t *testing.T) {
+	store := newTestDB(t)
+	seedTrace(t, store, "early", "bot", 1000)
+	seedTrace(t, store, "middle", "bot", 2000)
+	seedTrace(t, store, "late", "bot", 3000)
+
+	query := func(since, until string) []string {
+		t.Helper()
+		var out bytes.Buffer
+		if err := runQuery(store, queryOptions{limit: 20, since: since, until: until}, &out); err != nil {
+			t.Fatalf("query(%q, %q): %v", since, until, err)
+		}
+		var traces []*database.Trace
+		json.Unmarshal(out.Bytes(), &traces)
+		var ids []string
+		for _, tr := range traces {
+			ids = append(ids, tr.TraceID)
+		}
+		return ids
+	}
+
+	if got := query("1500", "2500"); len(got) != 1 || got[0] != "middle" {
+		t.Errorf("expected only middle inside 1500..2500, got %v", got)
+	}
+	// Both ends are inclusive
+	if got := query("2000", "3000"); len(got) != 2 {
+		t.Errorf("expected middle and late inside 2000..3000, got %v", got)
+	}
+	// The seeded traces are from 1970, so an hour ago excludes them all
+	if got := query("1h", "now"); len(got) != 0 {
+		t.Errorf("expected no traces in the last hour, got %v", got)
+	}
+
+	if err := runQuery(store, queryOptions{since: "3000", until: "1000"}, &bytes.Buffer{}); err == nil {
+		t.Error("expected an error for an inverted range")
+	}
+
+	// The range only filters trace listings, so it is refused rather
+	// than ignored alongside --trace or --search, after being parsed
+	for _, opts := range []queryOptions{
+		{traceID: "early", since: "1500"},
+		{search: "hello", until: "now"},
+		{traceID: "early", since: "not-a-time"},
+	} {
+		if err := runQuery(store, opts, &bytes.Buffer{}); err == nil {
+			t.Errorf("expected an error for %+v", opts)
+		}
+	}
+}
+
+func TestQueryMetadataFilter(t *testing.T) {
+	store := newTestDB(t)
+	for id, env := range map[string]string{"a": "prod", "b": "dev", "c": "prod"} {
+		store.InsertTrace(&database.Trace{TraceID: id, AgentName: "bot", StartTime: 1, Status: "completed",
+			Metadata: map[string]string{"env": env}})
+	}
+
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	opts := queryOptions{limit: 20, meta: make(metaFlag)}
+	fs.Var(opts.meta, "meta", "")
+	if err := fs.Parse([]string{"--meta", "env=prod"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := runQuery(store, opts, &out); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	var traces []*database.Trace
+	json.Unmarshal(out.Bytes(), &traces)
+	if len(traces) != 2 {
+		t.Fatalf("expected the 2 prod traces, got %d", len(traces))
+	}
+	for _, tr := range traces {
+		if tr.Metadata["env"] != "prod" {
+			t.Errorf("unexpected trace %s with env=%s", tr.TraceID, tr.Metadata["env"])
+		}
+	}
+
+	for _, other := range []queryOptions{{traceID: "a"}, {search: "hello"}} {
+		other.meta = opts.meta
+		if err := runQuery(store, other, &bytes.Buffer{}); err == nil {
+			t.Errorf("expected --meta to be refused with %+v", other)
+		}
+	}
+
+	for _, bad := range []string{"env", "=prod"} {
+		if err := make(metaFlag).Set(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestQueryJSONLines(t *testing.T) {
+	store := newTestDB(t)
+	seedTrace(t, store, "a", "bot", 1000)
+	seedTrace(t, store, "b", "bot", 2000)
+	seedTrace(t, store, "c", "bot", 3000)
+
+	for name, opts := range map[string]queryOptions{
+		"traces": {limit: 20, jsonl: true},
+		"spans":  {traceID: "a", jsonl: true},
+	} {
+		var out bytes.Buffer
+		if err := runQuery(store, opts, &out); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		want := 3
+		if name == "spans" {
+			want = 1
+		}
+		if len(lines) != want {
+			t.Fatalf("%s: expected %d lines, got %d:\n%s", name, want, len(lines), out.String())
+		}
+		for _, line := range lines {
+			var obj map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &obj); err != nil {
+				t.Errorf("%s: line is not a JSON object: %q (%v)", name, line, err)
+			}
+		}
+	}
+}
+
+func TestQueryFields(t *testing.T) {
+	store := newTestDB(t)
+	seedTrace(t, store, "a", "bot", 1000)
+
+	var out bytes.Buffer
+	if err := runQuery(store, queryOptions{traceID: "a", fields: "span_id,duration_ms"}, &out); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	var spans []map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &spans); err != nil {
+		t.Fatalf("decoding %s: %v", out.String(), err)
+	}
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if len(spans[0]) != 2 || spans[0]["span_id"] != "a-s1" || spans[0]["duration_ms"] != float64(40) {
+		t.Errorf("expected only span_id and duration_ms, got %v", spans[0])
+	}
+
+	// Trace listings are checked against the Trace fields instead.
+	out.Reset()
+	if err := runQuery(store, queryOptions{limit: 20, fields: "trace_id, end_time", jsonl: true}, &out); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if got := strings.TrimSpace(out.String()); got != `{"end_time":null,"trace_id":"a"}` {
+		t.Errorf("unexpected projected trace: %s", got)
+	}
+	if err := runQuery(store, queryOptions{limit: 20, fields: "duration_ms"}, &out); err == nil {
+		t.Error("expected a span-only field to be rejected for traces")
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// outputFlag adds -o/--out to fs.
+func outputFlag(fs *flag.FlagSet) *string {
+	path := fs.String("out", "", "Write output to this file instead of stdout")
+	fs.StringVar(path, "o", "", "Shorthand for --out")
+	return path
+}
+
+// withOutput calls write with stdout, or with the file at path when
+// one is given. The file's parent directories are created as needed,
+// and its path is printed to stdout once it is written. The output goes
+// to a temporary file that replaces path only when write succeeds, so a
+// failed run leaves no partial file and any earlier one untouched.
+func withOutput(path string, stdout io.Writer, write func(io.Writer) error) error {
+	if path == "" {
+		return write(stdout)
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // no-op once renamed
+
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0o644); err != nil {
+		f.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	fmt.Fprintf(stdout, "Wrote %s\n", path)
+	return nil
+}
+
+// writeJSON writes v to w as indented JSON.
+func writeJSON(w io.Writer, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+// writeJSONLines writes each item to w as compact JSON on its own line,
+// so consumers can process results one at a time.
+func writeJSONLines[T any](w io.Writer, items []T) error {
+	enc := json.NewEncoder(w)
+	for _, item := range items {
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeResults writes items as an indented JSON array, or as JSON
+// Lines when jsonl is set.
+func writeResults[T any](w io.Writer, items []T, jsonl bool) error {
+	if jsonl {
+		return writeJSONLines(w, items)
+	}
+	return writeJSON(w, items)
+}
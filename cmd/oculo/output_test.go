@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+)
+
+func TestAnalyzeWritesReportFile(t *testing.T) {
+	store := newTestDB(t)
+	seedTrace(t, store, "trace-1", "bot", 1000)
+
+	path := filepath.Join(t.TempDir(), "reports", "nested", "report.md")
+	var stdout bytes.Buffer
+	err := withOutput(path, &stdout, func(w io.Writer) error {
+		return runAnalyze(store, "trace-1", "markdown", nil, w)
+	})
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading report: %v", err)
+	}
+	if !strings.HasPrefix(string(b), "# Oculo Analysis Report") {
+		t.Errorf("expected a markdown report, got:\n%s", b)
+	}
+	if got := stdout.String(); got != "Wrote "+path+"\n" {
+		t.Errorf("expected only the path on stdout, got %q", got)
+	}
+}
+
+func TestQueryWritesJSONFile(t *testing.T) {
+	store := newTestDB(t)
+	seedTrace(t, store, "trace-1", "bot", 1000)
+	seedTrace(t, store, "trace-2", "other", 2000)
+
+	path := filepath.Join(t.TempDir(), "traces.json")
+	err := withOutput(path, &bytes.Buffer{}, func(w io.Writer) error {
+		return runQuery(store, queryOptions{agentName: "bot", limit: 20}, w)
+	})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+
+	b, _ := os.ReadFile(path)
+	var traces []*database.Trace
+	if err := json.Unmarshal(b, &traces); err != nil {
+		t.Fatalf("decoding %s: %v", path, err)
+	}
+	if len(traces) != 1 || traces[0].TraceID != "trace-1" {
+		t.Errorf("expected only trace-1, got %+v", traces)
+	}
+}
+
+func TestWithOutputStdout(t *testing.T) {
+	var stdout bytes.Buffer
+	withOutput("", &stdout, func(w io.Writer) error {
+		_, err := io.WriteString(w, "report")
+		return err
+	})
+	if stdout.String() != "report" {
+		t.Errorf("expected output on stdout without a path, got %q", stdout.String())
+	}
+}
+
+func TestWithOutputFailedWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.md")
+	failing := func(w io.Writer) error {
+		io.WriteString(w, "partial")
+		return errors.New("boom")
+	}
+
+	var stdout bytes.Buffer
+	if err := withOutput(path, &stdout, failing); err == nil {
+		t.Fatal("expected the write error to be returned")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected no files left behind, got %v", entries)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("expected nothing on stdout, got %q", stdout.String())
+	}
+
+	// An earlier report survives a failed rewrite
+	os.WriteFile(path, []byte("previous"), 0o644)
+	withOutput(path, &stdout, failing)
+	if b, _ := os.ReadFile(path); string(b) != "previous" {
+		t.Errorf("expected the earlier file kept, got %q", b)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected only the earlier file, got %v", entries)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+	"github.com/Mr-Dark-debug/oculo/pkg/timeutil"
+)
+
+// cmdPrune deletes traces older than a cutoff.
+func cmdPrune(defaultDB string) {
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	dbPath := fs.String("db", defaultDB, "Path to SQLite database")
+	before := fs.String("before", "", "Delete traces started before this time, e.g. 30d or 2024-01-01 (required)")
+	agentName := fs.String("agent", "", "Only prune traces from this agent")
+	yes := fs.Bool("yes", false, "Skip the confirmation prompt")
+	parseFlags(fs)
+
+	if *before == "" {
+		fmt.Fprintln(os.Stderr, "Error: --before is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+	cutoff, err := timeutil.ParseTimestamp(*before)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --before: %v\n", err)
+		os.Exit(1)
+	}
+
+	store, err := database.NewDBService(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer store.Close()
+
+	var confirm io.Reader
+	if !*yes {
+		confirm = os.Stdin
+	}
+	if err := runPrune(store, cutoff, *agentName, confirm, os.Stdout); err != nil {
+		log.Fatalf("Prune failed: %v", err)
+	}
+}
+
+// runPrune deletes traces started before cutoff. When confirm is not
+// nil it first asks for a "y" answer on it and does nothing otherwise.
+func runPrune(store database.Store, cutoff int64, agentName string, confirm io.Reader, w io.Writer) error {
+	scope := "all traces"
+	if agentName != "" {
+		scope = "traces from " + agentName
+	}
+	if confirm != nil {
+		fmt.Fprintf(w, "Delete %s started before %s? [y/N] ", scope, timeutil.FormatTimestampFull(cutoff))
+		answer, _ := bufio.NewReader(confirm).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Fprintln(w, "Aborted.")
+			return nil
+		}
+	}
+
+	n, err := store.PruneTracesBefore(cutoff, agentName)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Pruned %d traces.\n", n)
+	return nil
+}
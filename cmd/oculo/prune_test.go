@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+)
+
+func TestPruneRemovesOnlyOlderTraces(t *testing.T) {
+	store := newTestDB(t)
+	seedTrace(t, store, "old", "bot", 1000)
+	seedTrace(t, store, "new", "bot", 5000)
+
+	var out bytes.Buffer
+	if err := runPrune(store, 2000, "", nil, &out); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if !strings.Contains(out.String(), "Pruned 1 traces") {
+		t.Errorf("expected the count to be reported, got %q", out.String())
+	}
+	traces, _ := store.QueryTraces(database.TraceFilter{})
+	if len(traces) != 1 || traces[0].TraceID != "new" {
+		t.Errorf("expected only the newer trace to remain, got %v", traces)
+	}
+}
+
+func TestPruneScopedToAgent(t *testing.T) {
+	store := newTestDB(t)
+	seedTrace(t, store, "mine", "bot", 1000)
+	seedTrace(t, store, "theirs", "other", 1000)
+
+	if err := runPrune(store, 2000, "bot", nil, &bytes.Buffer{}); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	traces, _ := store.QueryTraces(database.TraceFilter{})
+	if len(traces) != 1 || traces[0].TraceID != "theirs" {
+		t.Errorf("expected the other agent's trace to remain, got %v", traces)
+	}
+}
+
+func TestPruneConfirmation(t *testing.T) {
+	store := newTestDB(t)
+	seedTrace(t, store, "old", "bot", 1000)
+
+	var out bytes.Buffer
+	if err := runPrune(store, 2000, "", strings.NewReader("n\n"), &out); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if traces, _ := store.QueryTraces(database.TraceFilter{}); len(traces) != 1 {
+		t.Errorf("expected nothing pruned without confirmation, got %d traces left", len(traces))
+	}
+	if !strings.Contains(out.String(), "[y/N]") || !strings.Contains(out.String(), "Aborted") {
+		t.Errorf("expected a prompt and an abort message, got %q", out.String())
+	}
+
+	if err := runPrune(store, 2000, "", strings.NewReader("y\n"), &bytes.Buffer{}); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if traces, _ := store.QueryTraces(database.TraceFilter{}); len(traces) != 0 {
+		t.Errorf("expected the trace pruned after confirming, got %d left", len(traces))
+	}
+}
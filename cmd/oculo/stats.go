@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Mr-Dark-debug/oculo/internal/analysis"
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+	"github.com/Mr-Dark-debug/oculo/pkg/timeutil"
+)
+
+// cmdStats prints per-agent totals across the database.
+func cmdStats(defaultDB string) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	dbPath := fs.String("db", defaultDB, "Path to SQLite database")
+	agentName := fs.String("agent", "", "Only include this agent")
+	since := fs.String("since", "", "Only traces started at or after this time (e.g. 24h, 2024-03-01)")
+	until := fs.String("until", "", "Only traces started at or before this time (e.g. now, 2024-03-02)")
+	outputFormat := fs.String("format", "table", "Output format: table, json")
+	pricingPath := pricingFlag(fs)
+	parseFlags(fs)
+
+	pricing, err := loadPricing(*pricingPath)
+	if err != nil {
+		log.Fatalf("Failed to load pricing: %v", err)
+	}
+
+	store, err := database.NewDBService(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer store.Close()
+
+	filter := database.TraceFilter{}
+	if *agentName != "" {
+		filter.AgentName = agentName
+	}
+	sinceNs, untilNs, err := timeutil.ParseRange(*since, *until)
+	if err != nil {
+		log.Fatalf("Stats failed: %v", err)
+	}
+	if sinceNs != 0 {
+		filter.Since = &sinceNs
+	}
+	if untilNs != 0 {
+		filter.Until = &untilNs
+	}
+
+	if err := runStats(store, filter, *outputFormat, pricing, os.Stdout); err != nil {
+		log.Fatalf("Stats failed: %v", err)
+	}
+}
+
+// agentStatsRow is one line of oculo stats: an agent's totals with
+// their estimated cost and error rate worked out.
+type agentStatsRow struct {
+	*database.AgentStats
+	EstimatedCost float64 `json:"estimated_cost_usd"`
+	ErrorRate     float64 `json:"error_rate"`
+}
+
+// statsReport is the output of oculo stats.
+type statsReport struct {
+	Agents []agentStatsRow `json:"agents"`
+	Total  agentStatsRow   `json:"total"`
+}
+
+// buildStatsReport prices each agent's token usage with pricing and
+// sums the totals.
+func buildStatsReport(stats []*database.AgentStats, pricing analysis.PricingTable) *statsReport {
+	report := &statsReport{
+		Agents: make([]agentStatsRow, 0, len(stats)),
+		Total:  agentStatsRow{AgentStats: &database.AgentStats{AgentName: "total"}},
+	}
+	total := report.Total.AgentStats
+	for _, st := range stats {
+		row := agentStatsRow{AgentStats: st}
+		for _, m := range st.Models {
+			cost, _ := pricing.Cost(m.Model, m.PromptTokens, m.CompletionTokens)
+			row.EstimatedCost += cost
+		}
+		row.ErrorRate = errorRate(st.ErrorCount, st.SpanCount)
+		report.Agents = append(report.Agents, row)
+
+		total.TraceCount += st.TraceCount
+		total.SpanCount += st.SpanCount
+		total.ErrorCount += st.ErrorCount
+		total.PromptTokens += st.PromptTokens
+		total.CompletionTokens += st.CompletionTokens
+		report.Total.EstimatedCost += row.EstimatedCost
+	}
+	report.Total.ErrorRate = errorRate(total.ErrorCount, total.SpanCount)
+	return report
+}
+
+func errorRate(errors, spans int) float64 {
+	if spans == 0 {
+		return 0
+	}
+	return float64(errors) / float64(spans)
+}
+
+// runStats writes per-agent totals for the traces matching filter to w.
+func runStats(store database.Store, filter database.TraceFilter, format string, pricing analysis.PricingTable, w io.Writer) error {
+	if format != "table" && format != "json" {
+		return fmt.Errorf("unknown format: %s", format)
+	}
+
+	stats, err := store.AggregateAgentStats(filter)
+	if err != nil {
+		return err
+	}
+	report := buildStatsReport(stats, pricing)
+
+	if format == "json" {
+		return writeJSON(w, report)
+	}
+	if len(report.Agents) == 0 {
+		_, err := fmt.Fprintln(w, "No traces found.")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "AGENT\tTRACES\tSPANS\tERRORS\tERROR %\tPROMPT TOK\tCOMPL TOK\tEST. COST\t")
+	for _, row := range append(report.Agents, report.Total) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.1f%%\t%d\t%d\t$%.4f\t\n",
+			row.AgentName, row.TraceCount, row.SpanCount, row.ErrorCount, row.ErrorRate*100,
+			row.PromptTokens, row.CompletionTokens, row.EstimatedCost)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/Mr-Dark-debug/oculo/internal/analysis"
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+)
+
+func TestStatsAggregatesPerAgent(t *testing.T) {
+	store := newTestDB(t)
+	seedTrace(t, store, "a1", "bot", 1000)
+	seedTrace(t, store, "a2", "bot", 2000)
+	seedTrace(t, store, "b1", "other", 3000)
+	model := "gpt-4"
+	if err := store.InsertSpan(&database.Span{SpanID: "b1-s2", TraceID: "b1", OperationType: "LLM",
+		OperationName: "retry", StartTime: 3100, Model: &model, PromptTokens: 100, CompletionTokens: 50,
+		Status: "error"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := runStats(store, database.TraceFilter{}, "json", nil, &out); err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	var report statsReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("decoding %s: %v", out.String(), err)
+	}
+	if len(report.Agents) != 2 {
+		t.Fatalf("expected 2 agents, got %d", len(report.Agents))
+	}
+
+	bot, other := report.Agents[0], report.Agents[1]
+	if bot.AgentName != "bot" || bot.TraceCount != 2 || bot.SpanCount != 2 || bot.PromptTokens != 24 || bot.ErrorCount != 0 {
+		t.Errorf("unexpected bot totals: %+v", *bot.AgentStats)
+	}
+	if other.SpanCount != 2 || other.ErrorCount != 1 || other.ErrorRate != 0.5 || other.CompletionTokens != 50 {
+		t.Errorf("unexpected other totals: %+v", *other.AgentStats)
+	}
+	wantCost := analysis.EstimateCost("unknown", 12, 0) + analysis.EstimateCost("gpt-4", 100, 50)
+	if math.Abs(other.EstimatedCost-wantCost) > 1e-9 {
+		t.Errorf("expected other's cost to be %f, got %f", wantCost, other.EstimatedCost)
+	}
+
+	total := report.Total
+	if total.TraceCount != 3 || total.SpanCount != 4 || total.ErrorCount != 1 || total.PromptTokens != 136 {
+		t.Errorf("unexpected grand totals: %+v", *total.AgentStats)
+	}
+	if math.Abs(total.EstimatedCost-(bot.EstimatedCost+other.EstimatedCost)) > 1e-9 {
+		t.Errorf("expected the total cost to sum the agents, got %f", total.EstimatedCost)
+	}
+}
+
+func TestStatsTable(t *testing.T) {
+	store := newTestDB(t)
+	seedTrace(t, store, "a1", "bot", 1000)
+	seedTrace(t, store, "b1", "other", 3000)
+
+	agent := "bot"
+	var out bytes.Buffer
+	if err := runStats(store, database.TraceFilter{AgentName: &agent}, "table", nil, &out); err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "bot") || !strings.Contains(lines[2], "total") {
+		t.Errorf("expected a header, the bot row and a total row, got:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "other") {
+		t.Errorf("expected --agent to exclude other agents, got:\n%s", out.String())
+	}
+
+	if err := runStats(store, database.TraceFilter{}, "csv", nil, &out); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+}
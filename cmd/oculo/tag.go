@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Mr-Dark-debug/oculo/internal/config"
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+	"github.com/Mr-Dark-debug/oculo/pkg/timeutil"
+)
+
+// cmdTag adds, removes and lists trace tags:
+//
+//	oculo tag add --trace ID tag...
+//	oculo tag remove --trace ID tag...
+//	oculo tag list --trace ID
+//	oculo tag list --filter tag
+func cmdTag(defaultDB string) {
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, "Usage: oculo tag add|remove|list [flags] [tag...]")
+		os.Exit(1)
+	}
+	action := os.Args[2]
+
+	fs := flag.NewFlagSet("tag "+action, flag.ExitOnError)
+	dbPath := fs.String("db", defaultDB, "Path to SQLite database")
+	traceID := fs.String("trace", "", "Trace ID to tag, untag or list the tags of")
+	filter := fs.String("filter", "", "With list: show the traces carrying this tag")
+	limit := fs.Int("limit", 100, "With list --filter: maximum traces")
+	colorFlags(fs)
+	if err := config.Parse(fs, os.Args[3:]); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	switch {
+	case action == "list" && (*traceID == "") == (*filter == ""):
+		fmt.Fprintln(os.Stderr, "Error: exactly one of --trace or --filter is required")
+		fs.Usage()
+		os.Exit(1)
+	case action != "list" && *traceID == "":
+		fmt.Fprintln(os.Stderr, "Error: --trace is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	store, err := database.NewDBService(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer store.Close()
+
+	if action == "list" && *filter != "" {
+		err = runTagFilter(store, *filter, *limit, os.Stdout)
+	} else {
+		err = runTag(store, action, *traceID, fs.Args(), os.Stdout)
+	}
+	if err != nil {
+		log.Fatalf("Tag failed: %v", err)
+	}
+}
+
+// runTag adds or removes tags on a trace, or lists its tags, and then
+// prints the trace's tags one per line.
+func runTag(store database.Store, action, traceID string, tags []string, w io.Writer) error {
+	switch action {
+	case "add", "remove":
+		if len(tags) == 0 {
+			return fmt.Errorf("no tags given")
+		}
+		for _, tag := range tags {
+			if tag == "" || strings.ContainsAny(tag, " \t\n") {
+				return fmt.Errorf("invalid tag %q: tags may not be empty or contain whitespace", tag)
+			}
+		}
+		var err error
+		if action == "add" {
+			err = store.AddTraceTags(traceID, tags)
+		} else {
+			err = store.RemoveTraceTags(traceID, tags)
+		}
+		if err != nil {
+			return err
+		}
+	case "list":
+		if len(tags) > 0 {
+			return fmt.Errorf("list takes no tags; use --filter to find traces by tag")
+		}
+	default:
+		return fmt.Errorf("unknown tag action %q (want add, remove or list)", action)
+	}
+
+	current, err := store.GetTraceTags(traceID)
+	if err != nil {
+		return err
+	}
+	for _, tag := range current {
+		fmt.Fprintln(w, tag)
+	}
+	return nil
+}
+
+// runTagFilter prints the traces carrying tag, most recent first.
+func runTagFilter(store database.Store, tag string, limit int, w io.Writer) error {
+	traces, err := store.QueryTraces(database.TraceFilter{Tag: &tag, Limit: limit})
+	if err != nil {
+		return err
+	}
+	for _, t := range traces {
+		fmt.Fprintf(w, "%s  %s  %s  %s\n",
+			t.TraceID, t.AgentName, timeutil.FormatTimestampFull(t.StartTime), t.Status)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTagAddListRemove(t *testing.T) {
+	store := newTestDB(t)
+	seedTrace(t, store, "trace-1", "bot", 1000)
+	seedTrace(t, store, "trace-2", "bot", 2000)
+
+	if err := runTag(store, "add", "trace-1", []string{"nightly", "flaky"}, &bytes.Buffer{}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runTag(store, "list", "trace-1", nil, &out); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if out.String() != "flaky\nnightly\n" {
+		t.Errorf("expected both tags listed, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := runTagFilter(store, "nightly", 10, &out); err != nil {
+		t.Fatalf("list --filter: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "trace-1 ") || strings.Contains(out.String(), "trace-2") {
+		t.Errorf("expected only trace-1 tagged nightly, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := runTag(store, "remove", "trace-1", []string{"nightly", "flaky"}, &out); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no tags left, got %q", out.String())
+	}
+
+	for _, tags := range [][]string{nil, {"two words"}} {
+		if err := runTag(store, "add", "trace-1", tags, &bytes.Buffer{}); err == nil {
+			t.Errorf("expected add %q to fail", tags)
+		}
+	}
+	if err := runTag(store, "add", "missing", []string{"x"}, &bytes.Buffer{}); err == nil {
+		t.Error("expected tagging an unknown trace to fail")
+	}
+}
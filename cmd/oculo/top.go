@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Mr-Dark-debug/oculo/internal/ingestion"
+	"github.com/Mr-Dark-debug/oculo/pkg/timeutil"
+)
+
+// cmdTop shows the daemon's ingestion rates, refreshing until Ctrl+C.
+func cmdTop() {
+	fs := flag.NewFlagSet("top", flag.ExitOnError)
+	metricsAddr := fs.String("metrics", ingestion.DefaultConfig().MetricsAddr, "Daemon metrics HTTP address")
+	interval := fs.Duration("interval", 2*time.Second, "Refresh interval")
+	parseFlags(fs)
+
+	if *interval <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: --interval must be positive")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	url := fmt.Sprintf("http://%s/api/metrics", *metricsAddr)
+	fetch := func() (*ingestion.IngestionMetrics, error) { return fetchMetrics(url) }
+	if err := runTop(ctx, fetch, *interval, os.Stdout); err != nil {
+		log.Fatalf("Top failed: %v", err)
+	}
+}
+
+// fetchMetrics reads the daemon's /api/metrics endpoint.
+func fetchMetrics(url string) (*ingestion.IngestionMetrics, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+
+	var metrics ingestion.IngestionMetrics
+	if err := json.NewDecoder(resp.Body).Decode(&metrics); err != nil {
+		return nil, fmt.Errorf("decoding metrics: %w", err)
+	}
+	return &metrics, nil
+}
+
+// ingestionRates are per-second rates between two metric snapshots.
+type ingestionRates struct {
+	Traces       float64
+	Spans        float64
+	MemoryEvents float64
+	Batches      float64
+	NewErrors    int64
+}
+
+// computeRates derives rates from the counter deltas between prev and
+// cur, taken elapsed apart. Counters that went backwards mean the daemon
+// restarted, so cur's own values are used as the delta.
+func computeRates(prev, cur *ingestion.IngestionMetrics, elapsed time.Duration) ingestionRates {
+	var r ingestionRates
+	if prev == nil || elapsed <= 0 {
+		return r
+	}
+	restarted := cur.Uptime < prev.Uptime
+	delta := func(p, c int64) int64 {
+		if restarted || c < p {
+			return c
+		}
+		return c - p
+	}
+	secs := elapsed.Seconds()
+	r.Traces = float64(delta(prev.TracesIngested, cur.TracesIngested)) / secs
+	r.Spans = float64(delta(prev.SpansIngested, cur.SpansIngested)) / secs
+	r.MemoryEvents = float64(delta(prev.MemoryEvents, cur.MemoryEvents)) / secs
+	r.Batches = float64(delta(prev.BatchesCommitted, cur.BatchesCommitted)) / secs
+	r.NewErrors = delta(prev.ErrorCount, cur.ErrorCount)
+	return r
+}
+
+// runTop redraws the metrics view every interval until ctx is
+// cancelled. A failed fetch is shown in place of the numbers and
+// retried on the next tick.
+func runTop(ctx context.Context, fetch func() (*ingestion.IngestionMetrics, error), interval time.Duration, w io.Writer) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var prev *ingestion.IngestionMetrics
+	var prevAt time.Time
+	for {
+		cur, err := fetch()
+		now := time.Now()
+
+		// Clear the screen and home the cursor before each frame; when
+		// piped, frames are simply appended.
+		if isTerminal(w) {
+			fmt.Fprint(w, "\033[H\033[2J")
+		}
+		if err != nil {
+			fmt.Fprintln(w, paint(w, colorYellow, fmt.Sprintf("⚠ Oculo daemon is not reachable: %v", err)))
+			prev = nil
+		} else {
+			renderTop(w, cur, computeRates(prev, cur, now.Sub(prevAt)), interval)
+			prev, prevAt = cur, now
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// renderTop writes one frame of oculo top.
+func renderTop(w io.Writer, m *ingestion.IngestionMetrics, r ingestionRates, interval time.Duration) {
+	fmt.Fprintf(w, "Oculo daemon — up %s  (every %s, Ctrl+C to quit)\n\n",
+		timeutil.FormatDurationCompact(m.Uptime*1000), interval)
+	fmt.Fprintln(w, paint(w, colorBold, fmt.Sprintf("  %-16s %12s %10s", "", "TOTAL", "PER SEC")))
+	fmt.Fprintf(w, "  %-16s %12d %10.1f\n", "Traces", m.TracesIngested, r.Traces)
+	fmt.Fprintf(w, "  %-16s %12d %10.1f\n", "Spans", m.SpansIngested, r.Spans)
+	fmt.Fprintf(w, "  %-16s %12d %10.1f\n", "Memory events", m.MemoryEvents, r.MemoryEvents)
+	fmt.Fprintf(w, "  %-16s %12d %10.1f\n", "Batches", m.BatchesCommitted, r.Batches)
+	newErrors := fmt.Sprintf("%10s", fmt.Sprintf("+%d", r.NewErrors))
+	if r.NewErrors > 0 {
+		newErrors = paint(w, colorRed, newErrors)
+	}
+	fmt.Fprintf(w, "  %-16s %12d %s\n", "Errors", m.ErrorCount, newErrors)
+}
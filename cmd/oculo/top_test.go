@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mr-Dark-debug/oculo/internal/ingestion"
+)
+
+func TestComputeRates(t *testing.T) {
+	prev := &ingestion.IngestionMetrics{TracesIngested: 10, SpansIngested: 100, ErrorCount: 1, Uptime: 60}
+	cur := &ingestion.IngestionMetrics{TracesIngested: 14, SpansIngested: 150, ErrorCount: 3, Uptime: 62}
+
+	r := computeRates(prev, cur, 2*time.Second)
+	if r.Traces != 2 || r.Spans != 25 || r.NewErrors != 2 {
+		t.Errorf("unexpected rates: %+v", r)
+	}
+
+	if r := computeRates(nil, cur, 2*time.Second); r != (ingestionRates{}) {
+		t.Errorf("expected zero rates without a previous snapshot, got %+v", r)
+	}
+
+	// After a daemon restart the counters start over from zero.
+	restarted := &ingestion.IngestionMetrics{SpansIngested: 8, Uptime: 1}
+	if r := computeRates(cur, restarted, 2*time.Second); r.Spans != 4 {
+		t.Errorf("expected the post-restart count as the delta, got %+v", r)
+	}
+}
+
+func TestRunTopRendersUntilCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	fetch := func() (*ingestion.IngestionMetrics, error) {
+		calls++
+		switch calls {
+		case 1:
+			return nil, errors.New("connection refused")
+		case 3:
+			cancel()
+		}
+		return &ingestion.IngestionMetrics{SpansIngested: int64(calls * 10), Uptime: int64(calls)}, nil
+	}
+
+	var out bytes.Buffer
+	if err := runTop(ctx, fetch, time.Millisecond, &out); err != nil {
+		t.Fatalf("top: %v", err)
+	}
+	if !strings.Contains(out.String(), "not reachable") {
+		t.Errorf("expected the fetch error to be shown, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "Spans") || !strings.Contains(out.String(), "30") {
+		t.Errorf("expected the last snapshot to be rendered, got:\n%s", out.String())
+	}
+}
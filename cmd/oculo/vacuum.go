@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+	"github.com/Mr-Dark-debug/oculo/internal/ingestion"
+)
+
+// cmdVacuum compacts the database file.
+func cmdVacuum(defaultDB string) {
+	fs := flag.NewFlagSet("vacuum", flag.ExitOnError)
+	dbPath := fs.String("db", defaultDB, "Path to SQLite database")
+	metricsAddr := fs.String("metrics", ingestion.DefaultConfig().MetricsAddr, "Daemon metrics HTTP address, used to detect a running daemon")
+	force := fs.Bool("force", false, "Vacuum even if the daemon appears to be running")
+	parseFlags(fs)
+
+	// The daemon keeps the database open and writing; VACUUM would
+	// contend with it for the write lock.
+	url := fmt.Sprintf("http://%s/api/metrics", *metricsAddr)
+	if _, err := fetchMetrics(url); err == nil && !*force {
+		fmt.Fprintf(os.Stderr, "Error: the Oculo daemon appears to be running (%s answered).\n", url)
+		fmt.Fprintln(os.Stderr, "Stop it first, or pass --force if it uses a different database.")
+		os.Exit(1)
+	}
+
+	store, err := database.NewDBService(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer store.Close()
+
+	if err := runVacuum(store, *dbPath, os.Stdout); err != nil {
+		log.Fatalf("Vacuum failed: %v", err)
+	}
+}
+
+// runVacuum runs the store's maintenance and reports how the database
+// size on disk changed.
+func runVacuum(store *database.DBService, path string, w io.Writer) error {
+	before, err := dbSize(path)
+	if err != nil {
+		return err
+	}
+	if err := store.Maintain(); err != nil {
+		return err
+	}
+	after, err := dbSize(path)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Vacuumed %s: %s → %s (%s freed)\n",
+		path, formatBytes(before), formatBytes(after), formatBytes(before-after))
+	return nil
+}
+
+// dbSize is the size of a database file together with its WAL.
+func dbSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	if wal, err := os.Stat(path + "-wal"); err == nil {
+		size += wal.Size()
+	}
+	return size, nil
+}
+
+// formatBytes renders a byte count with a binary unit: "512 B", "1.5 MiB".
+func formatBytes(n int64) string {
+	if n < 0 {
+		return "-" + formatBytes(-n)
+	}
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+)
+
+func TestVacuumShrinksAfterDeletes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oculo.db")
+	store, err := database.NewDBService(path)
+	if err != nil {
+		t.Fatalf("NewDBService: %v", err)
+	}
+	defer store.Close()
+
+	prompt := strings.Repeat("lorem ipsum ", 500)
+	for i := 0; i < 200; i++ {
+		id := fmt.Sprintf("trace-%d", i)
+		store.InsertTrace(&database.Trace{TraceID: id, AgentName: "bot", StartTime: int64(i), Status: "completed"})
+		if err := store.InsertSpan(&database.Span{SpanID: id + "-s", TraceID: id, OperationType: "LLM",
+			StartTime: int64(i), Prompt: &prompt, Status: "ok"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := store.PruneTracesBefore(190, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	before, _ := dbSize(path)
+	var out bytes.Buffer
+	if err := runVacuum(store, path, &out); err != nil {
+		t.Fatalf("vacuum: %v", err)
+	}
+	after, _ := dbSize(path)
+	if after >= before {
+		t.Errorf("expected the database to shrink, went from %d to %d bytes", before, after)
+	}
+	if !strings.Contains(out.String(), "freed") {
+		t.Errorf("expected a size report, got %q", out.String())
+	}
+
+	// The data that was kept is still readable.
+	if spans, _ := store.QueryTimeline("trace-195"); len(spans) != 1 {
+		t.Errorf("expected trace-195 to survive the vacuum, got %d spans", len(spans))
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[int64]string{0: "0 B", 512: "512 B", 1536: "1.5 KiB", 3 << 20: "3.0 MiB", -2048: "-2.0 KiB"} {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+)
+
+// cmdValidate checks the database for corruption, exiting non-zero if
+// it finds any.
+func cmdValidate(defaultDB string) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	dbPath := fs.String("db", defaultDB, "Path to SQLite database")
+	parseFlags(fs)
+
+	store, err := database.NewDBService(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+
+	ok, err := runValidate(store, os.Stdout)
+	store.Close()
+	if err != nil {
+		log.Fatalf("Validate failed: %v", err)
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// runValidate writes an integrity report for store to w and reports
+// whether the database passed.
+func runValidate(store *database.DBService, w io.Writer) (bool, error) {
+	report, err := store.CheckIntegrity()
+	if err != nil {
+		return false, err
+	}
+
+	check := func(name string, passed bool, detail string) {
+		mark := paint(w, colorGreen, "ok")
+		if !passed {
+			mark = paint(w, colorRed, "FAIL")
+		}
+		fmt.Fprintf(w, "  %-22s %s", name, mark)
+		if detail != "" {
+			fmt.Fprintf(w, "  %s", detail)
+		}
+		fmt.Fprintln(w)
+	}
+	orphans := func(name string, n int64) {
+		detail := ""
+		if n > 0 {
+			detail = fmt.Sprintf("%d orphaned", n)
+		}
+		check(name, n == 0, detail)
+	}
+
+	fmt.Fprintln(w, "Database integrity:")
+	detail := ""
+	if len(report.IntegrityErrors) > 0 {
+		detail = fmt.Sprintf("%d problems, first: %s", len(report.IntegrityErrors), report.IntegrityErrors[0])
+	}
+	check("SQLite integrity", len(report.IntegrityErrors) == 0, detail)
+	check("Full-text index", report.FTSError == "", report.FTSError)
+	orphans("Spans", report.OrphanSpans)
+	orphans("Memory events", report.OrphanMemoryEvents)
+	orphans("Tool calls", report.OrphanToolCalls)
+	orphans("Trace tags", report.OrphanTags)
+
+	if report.OK() {
+		fmt.Fprintln(w, "No problems found.")
+	} else {
+		fmt.Fprintln(w, "Problems found.")
+	}
+	return report.OK(), nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+)
+
+func TestValidateReportsOrphans(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oculo.db")
+	store, err := database.NewDBService(path)
+	if err != nil {
+		t.Fatalf("NewDBService: %v", err)
+	}
+	defer store.Close()
+	seedTrace(t, store, "trace-1", "bot", 1000)
+
+	var out bytes.Buffer
+	ok, err := runValidate(store, &out)
+	if err != nil || !ok {
+		t.Fatalf("expected a clean database to pass, got ok=%v err=%v:\n%s", ok, err, out.String())
+	}
+
+	// A second connection without foreign key enforcement can leave a
+	// span pointing at a trace that does not exist.
+	raw, err := sql.Open("sqlite3", path+"?_foreign_keys=OFF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = raw.Exec(`INSERT INTO spans (span_id, trace_id, operation_type, start_time) VALUES ('lost', 'gone', 'LLM', 1)`)
+	raw.Close()
+	if err != nil {
+		t.Fatalf("inserting orphan: %v", err)
+	}
+
+	out.Reset()
+	ok, err = runValidate(store, &out)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if ok {
+		t.Errorf("expected the orphaned span to fail validation:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "1 orphaned") || !strings.Contains(out.String(), "Problems found") {
+		t.Errorf("expected the orphan to be reported, got:\n%s", out.String())
+	}
+}
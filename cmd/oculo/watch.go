@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+	"github.com/Mr-Dark-debug/oculo/pkg/timeutil"
+)
+
+// cmdWatch tails new traces and spans as they are ingested.
+func cmdWatch(defaultDB string) {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	dbPath := fs.String("db", defaultDB, "Path to SQLite database")
+	agentName := fs.String("agent", "", "Only watch traces from this agent")
+	interval := fs.Duration("interval", time.Second, "Polling interval")
+	parseFlags(fs)
+
+	store, err := database.NewDBService(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer store.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	filter := database.TraceFilter{Limit: watchWindow}
+	if *agentName != "" {
+		filter.AgentName = agentName
+	}
+	if err := runWatch(ctx, newWatcher(store, filter, os.Stdout), *interval); err != nil {
+		log.Fatalf("Watch failed: %v", err)
+	}
+}
+
+// watchWindow is how many of the most recent traces each poll looks at.
+const watchWindow = 50
+
+// traceSource is the part of the store oculo watch reads from.
+type traceSource interface {
+	QueryTraces(filter database.TraceFilter) ([]*database.Trace, error)
+	QueryTimeline(traceID string) ([]*database.Span, error)
+}
+
+// watcher prints each trace and span the first time a poll sees it,
+// and a trace again when its status changes.
+type watcher struct {
+	store  traceSource
+	filter database.TraceFilter
+	w      io.Writer
+
+	status map[string]string // trace ID → last printed status
+	spans  map[string]bool   // span IDs already printed
+}
+
+func newWatcher(store traceSource, filter database.TraceFilter, w io.Writer) *watcher {
+	return &watcher{
+		store:  store,
+		filter: filter,
+		w:      w,
+		status: make(map[string]string),
+		spans:  make(map[string]bool),
+	}
+}
+
+// runWatch polls until ctx is cancelled, e.g. by Ctrl+C.
+func runWatch(ctx context.Context, wa *watcher, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := wa.poll(); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll prints whatever has appeared since the last poll, oldest first.
+func (wa *watcher) poll() error {
+	traces, err := wa.store.QueryTraces(wa.filter)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(traces, func(i, j int) bool { return traces[i].StartTime < traces[j].StartTime })
+
+	for _, t := range traces {
+		last, seen := wa.status[t.TraceID]
+		if seen && last == t.Status && t.Status != "running" {
+			continue
+		}
+		if last != t.Status {
+			fmt.Fprintf(wa.w, "%s  trace %s  %s  %s\n",
+				timeutil.FormatTimestamp(t.StartTime), shortTraceID(t.TraceID), t.AgentName,
+				paint(wa.w, statusColor(t.Status), t.Status))
+			wa.status[t.TraceID] = t.Status
+		}
+
+		spans, err := wa.store.QueryTimeline(t.TraceID)
+		if err != nil {
+			return err
+		}
+		for _, s := range spans {
+			if wa.spans[s.SpanID] {
+				continue
+			}
+			wa.spans[s.SpanID] = true
+			fmt.Fprintf(wa.w, "%s    %-9s %s  %s  %s\n",
+				timeutil.FormatTimestamp(s.StartTime), s.OperationType, s.OperationName,
+				timeutil.FormatDuration(s.DurationMs), paint(wa.w, statusColor(s.Status), s.Status))
+		}
+	}
+	return nil
+}
+
+// shortTraceID abbreviates a trace ID for one-line output.
+func shortTraceID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
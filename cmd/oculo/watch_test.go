@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+)
+
+// fakeTraceSource serves traces and spans from memory.
+type fakeTraceSource struct {
+	traces []*database.Trace
+	spans  map[string][]*database.Span
+}
+
+func (f *fakeTraceSource) QueryTraces(filter database.TraceFilter) ([]*database.Trace, error) {
+	var out []*database.Trace
+	for i := len(f.traces) - 1; i >= 0; i-- { // newest first, like the store
+		t := f.traces[i]
+		if filter.AgentName == nil || t.AgentName == *filter.AgentName {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeTraceSource) QueryTimeline(traceID string) ([]*database.Span, error) {
+	return f.spans[traceID], nil
+}
+
+func (f *fakeTraceSource) addSpan(traceID, spanID, name string) {
+	f.spans[traceID] = append(f.spans[traceID], &database.Span{
+		SpanID: spanID, TraceID: traceID, OperationType: "llm", OperationName: name, Status: "completed",
+	})
+}
+
+func TestWatcherPrintsEachTraceAndSpanOnce(t *testing.T) {
+	src := &fakeTraceSource{spans: map[string][]*database.Span{}}
+	src.traces = []*database.Trace{{TraceID: "trace-aaaaaaaa", AgentName: "bot", StartTime: 1, Status: "completed"}}
+	src.addSpan("trace-aaaaaaaa", "s1", "first-call")
+
+	var out bytes.Buffer
+	wa := newWatcher(src, database.TraceFilter{}, &out)
+	if err := wa.poll(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "trace-aa") || !strings.Contains(out.String(), "first-call") {
+		t.Fatalf("expected the trace and its span, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := wa.poll(); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected nothing new on the second poll, got:\n%s", out.String())
+	}
+
+	src.traces = append(src.traces, &database.Trace{TraceID: "trace-bbbbbbbb", AgentName: "bot", StartTime: 2, Status: "running"})
+	src.addSpan("trace-bbbbbbbb", "s2", "second-call")
+	if err := wa.poll(); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	if strings.Contains(got, "trace-aa") || strings.Contains(got, "first-call") {
+		t.Errorf("already-printed trace repeated:\n%s", got)
+	}
+	if !strings.Contains(got, "trace-bb") || !strings.Contains(got, "second-call") {
+		t.Errorf("expected the new trace and span, got:\n%s", got)
+	}
+}
+
+func TestWatcherFollowsRunningTraces(t *testing.T) {
+	running := &database.Trace{TraceID: "trace-run", AgentName: "bot", StartTime: 1, Status: "running"}
+	src := &fakeTraceSource{traces: []*database.Trace{running}, spans: map[string][]*database.Span{}}
+
+	var out bytes.Buffer
+	wa := newWatcher(src, database.TraceFilter{}, &out)
+	wa.poll()
+
+	out.Reset()
+	src.addSpan("trace-run", "late", "late-call")
+	running.Status = "completed"
+	wa.poll()
+
+	got := out.String()
+	if !strings.Contains(got, "late-call") {
+		t.Errorf("expected a span added to a running trace, got:\n%s", got)
+	}
+	if !strings.Contains(got, "completed") || strings.Count(got, "trace trace-ru") != 1 {
+		t.Errorf("expected one status line for the finished trace, got:\n%s", got)
+	}
+}
+
+func TestRunWatchStopsOnCancel(t *testing.T) {
+	src := &fakeTraceSource{spans: map[string][]*database.Span{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- runWatch(ctx, newWatcher(src, database.TraceFilter{}, &bytes.Buffer{}), time.Hour) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected a clean exit, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("runWatch did not return after cancellation")
+	}
+}
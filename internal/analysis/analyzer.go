@@ -3,15 +3,20 @@
 // methods — no LLMs are involved.
 //
 // Key capabilities:
-//   - Token hotspot detection via Z-score analysis
+//   - Token and latency hotspot detection via Z-score analysis
 //   - Memory growth trend analysis via linear regression
 //   - Cost attribution across LLM calls
-//   - Prompt clustering via similarity metrics
+//   - Error cascade detection over the span tree
+//   - Duplicate prompt clustering via token-set Jaccard similarity
 package analysis
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math"
+	"os"
 	"sort"
 	"strings"
 	"time"
@@ -23,6 +28,10 @@ import (
 // Analyzer performs semantic analysis on trace data without LLMs.
 type Analyzer struct {
 	store database.Store
+
+	// PricingTable overrides and extends the built-in model prices used
+	// by AttributeCosts. It may be nil.
+	PricingTable PricingTable
 }
 
 // NewAnalyzer creates a new analysis engine backed by the given store.
@@ -30,6 +39,17 @@ func NewAnalyzer(store database.Store) *Analyzer {
 	return &Analyzer{store: store}
 }
 
+// NewAnalyzerWithPricing creates an analysis engine that prices LLM
+// calls with pricing before falling back to the built-in prices.
+func NewAnalyzerWithPricing(store database.Store, pricing PricingTable) *Analyzer {
+	return &Analyzer{store: store, PricingTable: pricing}
+}
+
+// SetPricing replaces the analyzer's pricing table.
+func (a *Analyzer) SetPricing(pricing PricingTable) {
+	a.PricingTable = pricing
+}
+
 // ============================================================
 // Token Hotspot Detection
 // ============================================================
@@ -73,36 +93,21 @@ func (a *Analyzer) DetectTokenHotspots(traceID string) ([]TokenHotspot, error) {
 
 	// Calculate total tokens per span
 	totals := make([]float64, len(llmSpans))
-	var sum, sumSq float64
 	for i, s := range llmSpans {
-		total := float64(s.PromptTokens + s.CompletionTokens)
-		totals[i] = total
-		sum += total
-		sumSq += total * total
+		totals[i] = float64(s.PromptTokens + s.CompletionTokens)
 	}
 
-	n := float64(len(llmSpans))
-	mean := sum / n
-	variance := (sumSq / n) - (mean * mean)
-	stddev := math.Sqrt(variance)
-
-	if stddev == 0 {
+	zScores := zScoresOf(totals)
+	if zScores == nil {
 		// All spans have the same token count — no hotspots
 		return nil, nil
 	}
 
 	var hotspots []TokenHotspot
 	for i, s := range llmSpans {
-		zScore := (totals[i] - mean) / stddev
-
-		if zScore > 1.5 {
-			severity := "low"
-			if zScore > 3.0 {
-				severity = "high"
-			} else if zScore > 2.0 {
-				severity = "medium"
-			}
+		zScore := zScores[i]
 
+		if severity, ok := hotspotSeverity(zScore); ok {
 			hotspots = append(hotspots, TokenHotspot{
 				SpanID:           s.SpanID,
 				OperationName:    s.OperationName,
@@ -123,6 +128,120 @@ func (a *Analyzer) DetectTokenHotspots(traceID string) ([]TokenHotspot, error) {
 	return hotspots, nil
 }
 
+// zScoresOf returns the Z-score of each value against the population
+// mean and standard deviation, or nil if all values are equal.
+func zScoresOf(values []float64) []float64 {
+	var sum, sumSq float64
+	for _, v := range values {
+		sum += v
+		sumSq += v * v
+	}
+
+	n := float64(len(values))
+	mean := sum / n
+	variance := (sumSq / n) - (mean * mean)
+	stddev := math.Sqrt(variance)
+	if stddev == 0 || math.IsNaN(stddev) {
+		return nil
+	}
+
+	zScores := make([]float64, len(values))
+	for i, v := range values {
+		zScores[i] = (v - mean) / stddev
+	}
+	return zScores
+}
+
+// hotspotSeverity grades a Z-score: above 1.5 is "low", above 2.0
+// "medium" and above 3.0 "high". ok is false below 1.5.
+func hotspotSeverity(zScore float64) (severity string, ok bool) {
+	switch {
+	case zScore > 3.0:
+		return "high", true
+	case zScore > 2.0:
+		return "medium", true
+	case zScore > 1.5:
+		return "low", true
+	}
+	return "", false
+}
+
+// ============================================================
+// Latency Hotspot Detection
+// ============================================================
+
+// LatencyHotspot identifies a span that took abnormally long.
+type LatencyHotspot struct {
+	SpanID        string  `json:"span_id"`
+	OperationType string  `json:"operation_type"`
+	OperationName string  `json:"operation_name"`
+	DurationMs    int64   `json:"duration_ms"`
+	ZScore        float64 `json:"z_score"`
+	Severity      string  `json:"severity"` // "low", "medium", "high"
+}
+
+// DetectLatencyHotspots calculates the Z-score of DurationMs across the
+// leaf spans of a trace, with the same thresholds as
+// DetectTokenHotspots. Spans with children are left out: their duration
+// includes their children's, so they would always look slow.
+//
+// This answers: "Which operations are holding the agent up?"
+func (a *Analyzer) DetectLatencyHotspots(traceID string) ([]LatencyHotspot, error) {
+	spans, err := a.store.QueryTimeline(traceID)
+	if err != nil {
+		return nil, fmt.Errorf("querying timeline for latency analysis: %w", err)
+	}
+
+	hasChildren := make(map[string]bool)
+	for _, s := range spans {
+		if s.ParentSpanID != nil {
+			hasChildren[*s.ParentSpanID] = true
+		}
+	}
+	var leaves []*database.Span
+	for _, s := range spans {
+		if !hasChildren[s.SpanID] {
+			leaves = append(leaves, s)
+		}
+	}
+
+	if len(leaves) < 2 {
+		// Not enough data for meaningful Z-score analysis
+		return nil, nil
+	}
+
+	durations := make([]float64, len(leaves))
+	for i, s := range leaves {
+		durations[i] = float64(s.DurationMs)
+	}
+
+	zScores := zScoresOf(durations)
+	if zScores == nil {
+		// All spans took the same time — no hotspots
+		return nil, nil
+	}
+
+	var hotspots []LatencyHotspot
+	for i, s := range leaves {
+		if severity, ok := hotspotSeverity(zScores[i]); ok {
+			hotspots = append(hotspots, LatencyHotspot{
+				SpanID:        s.SpanID,
+				OperationType: s.OperationType,
+				OperationName: s.OperationName,
+				DurationMs:    s.DurationMs,
+				ZScore:        math.Round(zScores[i]*100) / 100,
+				Severity:      severity,
+			})
+		}
+	}
+
+	sort.Slice(hotspots, func(i, j int) bool {
+		return hotspots[i].ZScore > hotspots[j].ZScore
+	})
+
+	return hotspots, nil
+}
+
 // ============================================================
 // Memory Growth Analysis
 // ============================================================
@@ -297,15 +416,50 @@ type CostEntry struct {
 	CompletionTokens int     `json:"completion_tokens"`
 	EstimatedCost    float64 `json:"estimated_cost_usd"`
 	Percentage       float64 `json:"percentage"`
+	// IsEstimated is set when no price was known for Model and the
+	// default rate was used instead.
+	IsEstimated bool `json:"is_estimated,omitempty"`
+}
+
+// ModelCostSummary rolls up the LLM calls of one model, or of one
+// operation name prefix.
+type ModelCostSummary struct {
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	EstimatedCost    float64 `json:"estimated_cost_usd"`
+}
+
+// add folds one call into the summary.
+func (m *ModelCostSummary) add(e CostEntry, cost float64) {
+	m.Calls++
+	m.PromptTokens += e.PromptTokens
+	m.CompletionTokens += e.CompletionTokens
+	m.EstimatedCost += cost
 }
 
 // CostReport summarizes token costs across a trace.
 type CostReport struct {
-	TraceID               string      `json:"trace_id"`
-	TotalPromptTokens     int         `json:"total_prompt_tokens"`
-	TotalCompletionTokens int         `json:"total_completion_tokens"`
-	TotalEstimatedCost    float64     `json:"total_estimated_cost_usd"`
-	Entries               []CostEntry `json:"entries"`
+	TraceID               string  `json:"trace_id"`
+	TotalPromptTokens     int     `json:"total_prompt_tokens"`
+	TotalCompletionTokens int     `json:"total_completion_tokens"`
+	TotalEstimatedCost    float64 `json:"total_estimated_cost_usd"`
+	// Entries holds one row per LLM call, most expensive first.
+	Entries []CostEntry `json:"entries"`
+	// ByModel and ByOperation roll the entries up per model and per
+	// operation name prefix (see operationPrefix).
+	ByModel     map[string]ModelCostSummary `json:"by_model"`
+	ByOperation map[string]ModelCostSummary `json:"by_operation"`
+}
+
+// operationPrefix is the part of an operation name before its first
+// ".", "/" or ":", so that "planner.step" and "planner.reflect" are
+// costed together. Names without a separator are their own prefix.
+func operationPrefix(name string) string {
+	if i := strings.IndexAny(name, ".:/"); i > 0 {
+		return name[:i]
+	}
+	return name
 }
 
 // Model pricing (approximate, per 1K tokens)
@@ -320,6 +474,64 @@ var modelPricing = map[string][2]float64{
 	"claude-3-haiku":  {0.00025, 0.00125},
 }
 
+// defaultPricing is used for models missing from modelPricing.
+var defaultPricing = [2]float64{0.01, 0.03}
+
+// PricingTable maps a model name to its USD price per 1K prompt and
+// completion tokens.
+type PricingTable map[string][2]float64
+
+// Cost returns the estimated USD cost of an LLM call to model with the
+// given token counts. Prices in p win over the built-in ones; estimated
+// reports that neither knew the model and the default rate was used.
+func (p PricingTable) Cost(model string, promptTokens, completionTokens int) (cost float64, estimated bool) {
+	pricing, ok := p[model]
+	if !ok {
+		pricing, ok = modelPricing[model]
+	}
+	if !ok {
+		pricing = defaultPricing
+	}
+	cost = float64(promptTokens)/1000.0*pricing[0] +
+		float64(completionTokens)/1000.0*pricing[1]
+	return cost, !ok
+}
+
+// LoadPricing reads a pricing table from a JSON file mapping model
+// names to [prompt, completion] USD prices per 1K tokens:
+//
+//	{"my-llama-70b": [0.0008, 0.0008], "gpt-4o": [0.0025, 0.01]}
+func LoadPricing(path string) (PricingTable, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// Decode into slices so that entries with the wrong number of prices
+	// are caught rather than silently padded or truncated.
+	var raw map[string][]float64
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("parsing pricing %s: %w", path, err)
+	}
+	pricing := make(PricingTable, len(raw))
+	for model, price := range raw {
+		if len(price) != 2 {
+			return nil, fmt.Errorf("pricing %s: %s needs [prompt, completion] prices, got %d values", path, model, len(price))
+		}
+		if price[0] < 0 || price[1] < 0 {
+			return nil, fmt.Errorf("pricing %s: negative price for %s", path, model)
+		}
+		pricing[model] = [2]float64{price[0], price[1]}
+	}
+	return pricing, nil
+}
+
+// EstimateCost returns the estimated USD cost of an LLM call to model
+// with the given token counts, using the built-in prices.
+func EstimateCost(model string, promptTokens, completionTokens int) float64 {
+	cost, _ := PricingTable(nil).Cost(model, promptTokens, completionTokens)
+	return cost
+}
+
 // AttributeCosts calculates estimated costs for each LLM call in a trace.
 func (a *Analyzer) AttributeCosts(traceID string) (*CostReport, error) {
 	spans, err := a.store.QueryTimeline(traceID)
@@ -327,7 +539,11 @@ func (a *Analyzer) AttributeCosts(traceID string) (*CostReport, error) {
 		return nil, fmt.Errorf("querying timeline for cost analysis: %w", err)
 	}
 
-	report := &CostReport{TraceID: traceID}
+	report := &CostReport{
+		TraceID:     traceID,
+		ByModel:     make(map[string]ModelCostSummary),
+		ByOperation: make(map[string]ModelCostSummary),
+	}
 
 	for _, s := range spans {
 		if s.OperationType != "LLM" {
@@ -339,28 +555,45 @@ func (a *Analyzer) AttributeCosts(traceID string) (*CostReport, error) {
 			model = *s.Model
 		}
 
-		pricing, ok := modelPricing[model]
-		if !ok {
-			pricing = [2]float64{0.01, 0.03} // Default estimate
-		}
-
-		promptCost := float64(s.PromptTokens) / 1000.0 * pricing[0]
-		completionCost := float64(s.CompletionTokens) / 1000.0 * pricing[1]
-		totalCost := promptCost + completionCost
+		totalCost, estimated := a.PricingTable.Cost(model, s.PromptTokens, s.CompletionTokens)
 
 		report.TotalPromptTokens += s.PromptTokens
 		report.TotalCompletionTokens += s.CompletionTokens
 		report.TotalEstimatedCost += totalCost
 
-		report.Entries = append(report.Entries, CostEntry{
+		entry := CostEntry{
 			SpanID:           s.SpanID,
 			OperationName:    s.OperationName,
 			Model:            model,
 			PromptTokens:     s.PromptTokens,
 			CompletionTokens: s.CompletionTokens,
 			EstimatedCost:    math.Round(totalCost*10000) / 10000,
-		})
+			IsEstimated:      estimated,
+		}
+		report.Entries = append(report.Entries, entry)
+
+		// Roll up the unrounded cost so many small calls still add up.
+		byModel := report.ByModel[model]
+		byModel.add(entry, totalCost)
+		report.ByModel[model] = byModel
+		prefix := operationPrefix(s.OperationName)
+		byOp := report.ByOperation[prefix]
+		byOp.add(entry, totalCost)
+		report.ByOperation[prefix] = byOp
+	}
+
+	roundSummaries := func(m map[string]ModelCostSummary) {
+		for k, v := range m {
+			v.EstimatedCost = math.Round(v.EstimatedCost*10000) / 10000
+			m[k] = v
+		}
 	}
+	roundSummaries(report.ByModel)
+	roundSummaries(report.ByOperation)
+
+	sort.SliceStable(report.Entries, func(i, j int) bool {
+		return report.Entries[i].EstimatedCost > report.Entries[j].EstimatedCost
+	})
 
 	// Calculate percentages
 	for i := range report.Entries {
@@ -373,19 +606,296 @@ func (a *Analyzer) AttributeCosts(traceID string) (*CostReport, error) {
 	return report, nil
 }
 
+// ============================================================
+// Duplicate Prompt Detection
+// ============================================================
+
+// duplicatePromptSimilarity is the token-set Jaccard similarity at which
+// two different prompts count as near-duplicates.
+const duplicatePromptSimilarity = 0.9
+
+// DuplicateGroup is a set of LLM calls within a trace that sent the same
+// prompt, or nearly the same one.
+type DuplicateGroup struct {
+	// PromptHash identifies the normalized prompt of the first call.
+	PromptHash string   `json:"prompt_hash"`
+	SpanIDs    []string `json:"span_ids"`
+	Count      int      `json:"count"`
+	// Similarity is the lowest Jaccard similarity of any member to the
+	// first call's prompt; 1 means every prompt was identical.
+	Similarity float64 `json:"similarity"`
+	// RedundantTokens and RedundantCost cover every call after the
+	// first, which repeated work already done.
+	RedundantTokens int     `json:"redundant_tokens"`
+	RedundantCost   float64 `json:"redundant_cost_usd"`
+}
+
+// DetectDuplicatePrompts groups the LLM spans of a trace whose prompts
+// match after trimming and collapsing whitespace, then merges groups
+// whose prompts share at least duplicatePromptSimilarity of their words.
+// Groups are returned most redundant tokens first.
+//
+// This answers: "Is the agent paying to ask the same question twice?"
+func (a *Analyzer) DetectDuplicatePrompts(traceID string) ([]DuplicateGroup, error) {
+	spans, err := a.store.QueryTimeline(traceID)
+	if err != nil {
+		return nil, fmt.Errorf("querying timeline for duplicate prompt analysis: %w", err)
+	}
+
+	// promptCluster is one group being built: its first prompt's word set
+	// and the spans that matched it.
+	type promptCluster struct {
+		hash       string
+		words      map[string]bool
+		spans      []*database.Span
+		similarity float64
+	}
+	var clusters []*promptCluster
+	byHash := make(map[string]*promptCluster)
+
+	for _, s := range spans {
+		if s.OperationType != "LLM" || s.Prompt == nil {
+			continue
+		}
+		normalized := normalizePrompt(*s.Prompt)
+		if normalized == "" {
+			continue
+		}
+		hash := promptHash(normalized)
+		if c, ok := byHash[hash]; ok {
+			c.spans = append(c.spans, s)
+			continue
+		}
+
+		words := wordSet(normalized)
+		var match *promptCluster
+		best := 0.0
+		for _, c := range clusters {
+			if sim := jaccard(words, c.words); sim >= duplicatePromptSimilarity && sim > best {
+				match, best = c, sim
+			}
+		}
+		if match == nil {
+			match = &promptCluster{hash: hash, words: words, similarity: 1}
+			clusters = append(clusters, match)
+		} else if best < match.similarity {
+			match.similarity = best
+		}
+		match.spans = append(match.spans, s)
+		byHash[hash] = match
+	}
+
+	var groups []DuplicateGroup
+	for _, c := range clusters {
+		if len(c.spans) < 2 {
+			continue
+		}
+		g := DuplicateGroup{
+			PromptHash: c.hash,
+			Count:      len(c.spans),
+			Similarity: math.Round(c.similarity*100) / 100,
+		}
+		var cost float64
+		for i, s := range c.spans {
+			g.SpanIDs = append(g.SpanIDs, s.SpanID)
+			if i == 0 {
+				continue
+			}
+			g.RedundantTokens += s.PromptTokens + s.CompletionTokens
+			model := "unknown"
+			if s.Model != nil {
+				model = *s.Model
+			}
+			spanCost, _ := a.PricingTable.Cost(model, s.PromptTokens, s.CompletionTokens)
+			cost += spanCost
+		}
+		g.RedundantCost = math.Round(cost*10000) / 10000
+		groups = append(groups, g)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].RedundantTokens > groups[j].RedundantTokens
+	})
+	return groups, nil
+}
+
+// normalizePrompt trims a prompt and collapses its runs of whitespace
+// to single spaces.
+func normalizePrompt(prompt string) string {
+	return strings.Join(strings.Fields(prompt), " ")
+}
+
+// promptHash is a short, stable identifier for a normalized prompt.
+func promptHash(normalized string) string {
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:8])
+}
+
+// wordSet is the set of lowercased words in a normalized prompt.
+func wordSet(normalized string) map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range strings.Fields(strings.ToLower(normalized)) {
+		words[w] = true
+	}
+	return words
+}
+
+// jaccard returns |a ∩ b| / |a ∪ b| for two word sets.
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// ============================================================
+// Error Cascade Detection
+// ============================================================
+
+// errorCascadeWindow bounds how long after a failing span a failing
+// descendant still counts as part of its cascade. Failures further out
+// are treated as independent and start a cascade of their own.
+const errorCascadeWindow = 60 * time.Second
+
+// ErrorCascade is a failing span together with the failing spans
+// beneath it in the span tree that followed it within errorCascadeWindow.
+type ErrorCascade struct {
+	RootSpanID        string   `json:"root_span_id"`
+	RootOperationName string   `json:"root_operation_name"`
+	RootError         string   `json:"root_error,omitempty"`
+	AffectedSpanIDs   []string `json:"affected_span_ids"`
+	// WastedDurationMs is the wall-clock time from the root's start to the
+	// end of the last failing span in the cascade.
+	WastedDurationMs int64 `json:"wasted_duration_ms"`
+	// WastedTokens is the prompt and completion tokens of every failing
+	// span in the cascade, root included.
+	WastedTokens int `json:"wasted_tokens"`
+}
+
+// DetectErrorCascades walks a trace's span tree and groups failing spans
+// (status other than "ok") under the nearest failing ancestor, so that a
+// failed tool call and the retries it caused are reported together.
+// Successful spans in between do not break a cascade. Only failures with
+// at least one failing descendant are returned, ordered by wasted tokens.
+//
+// This answers: "Which single failure cost the most downstream work?"
+func (a *Analyzer) DetectErrorCascades(traceID string) ([]ErrorCascade, error) {
+	spans, err := a.store.QueryTimeline(traceID)
+	if err != nil {
+		return nil, fmt.Errorf("querying timeline for error cascade analysis: %w", err)
+	}
+	return detectErrorCascades(spans), nil
+}
+
+// detectErrorCascades is DetectErrorCascades over an already loaded
+// timeline, ordered by start time.
+func detectErrorCascades(spans []*database.Span) []ErrorCascade {
+	byID := make(map[string]*database.Span, len(spans))
+	for _, s := range spans {
+		byID[s.SpanID] = s
+	}
+	children := make(map[string][]*database.Span)
+	var roots []*database.Span
+	for _, s := range spans {
+		if s.ParentSpanID != nil && byID[*s.ParentSpanID] != nil {
+			children[*s.ParentSpanID] = append(children[*s.ParentSpanID], s)
+		} else {
+			// Spans whose parent is missing from the trace are walked as
+			// roots rather than dropped.
+			roots = append(roots, s)
+		}
+	}
+
+	var cascades []*cascadeBuilder
+	var walk func(s *database.Span, active *cascadeBuilder)
+	walk = func(s *database.Span, active *cascadeBuilder) {
+		if s.Status != "ok" {
+			if active != nil && s.StartTime-active.root.StartTime <= errorCascadeWindow.Nanoseconds() {
+				active.add(s)
+			} else {
+				active = newCascadeBuilder(s)
+				cascades = append(cascades, active)
+			}
+		}
+		for _, c := range children[s.SpanID] {
+			walk(c, active)
+		}
+	}
+	for _, r := range roots {
+		walk(r, nil)
+	}
+
+	var result []ErrorCascade
+	for _, c := range cascades {
+		if len(c.cascade.AffectedSpanIDs) > 0 {
+			c.cascade.WastedDurationMs = (c.lastEnd - c.root.StartTime) / int64(time.Millisecond)
+			result = append(result, c.cascade)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].WastedTokens > result[j].WastedTokens
+	})
+	return result
+}
+
+// cascadeBuilder accumulates an ErrorCascade during the tree walk.
+type cascadeBuilder struct {
+	root    *database.Span
+	lastEnd int64
+	cascade ErrorCascade
+}
+
+func newCascadeBuilder(root *database.Span) *cascadeBuilder {
+	c := &cascadeBuilder{
+		root:    root,
+		lastEnd: spanEnd(root),
+		cascade: ErrorCascade{
+			RootSpanID:        root.SpanID,
+			RootOperationName: root.OperationName,
+			WastedTokens:      root.PromptTokens + root.CompletionTokens,
+		},
+	}
+	if root.ErrorMessage != nil {
+		c.cascade.RootError = *root.ErrorMessage
+	}
+	return c
+}
+
+func (c *cascadeBuilder) add(s *database.Span) {
+	c.cascade.AffectedSpanIDs = append(c.cascade.AffectedSpanIDs, s.SpanID)
+	c.cascade.WastedTokens += s.PromptTokens + s.CompletionTokens
+	if end := spanEnd(s); end > c.lastEnd {
+		c.lastEnd = end
+	}
+}
+
+// spanEnd is the span's end time in Unix nanoseconds.
+func spanEnd(s *database.Span) int64 {
+	return s.StartTime + s.DurationMs*int64(time.Millisecond)
+}
+
 // ============================================================
 // Full Analysis Report
 // ============================================================
 
 // AnalysisReport is the complete output of `oculo analyze`.
 type AnalysisReport struct {
-	TraceID         string               `json:"trace_id"`
-	GeneratedAt     string               `json:"generated_at"`
-	Stats           *database.TraceStats `json:"stats"`
-	TokenHotspots   []TokenHotspot       `json:"token_hotspots"`
-	MemoryGrowth    *MemoryGrowthReport  `json:"memory_growth"`
-	CostAttribution *CostReport          `json:"cost_attribution"`
-	Warnings        []string             `json:"warnings"`
+	TraceID          string               `json:"trace_id"`
+	GeneratedAt      string               `json:"generated_at"`
+	Stats            *database.TraceStats `json:"stats"`
+	TokenHotspots    []TokenHotspot       `json:"token_hotspots"`
+	LatencyHotspots  []LatencyHotspot     `json:"latency_hotspots"`
+	MemoryGrowth     *MemoryGrowthReport  `json:"memory_growth"`
+	CostAttribution  *CostReport          `json:"cost_attribution"`
+	ErrorCascades    []ErrorCascade       `json:"error_cascades"`
+	DuplicatePrompts []DuplicateGroup     `json:"duplicate_prompts"`
+	Warnings         []string             `json:"warnings"`
 }
 
 // FullAnalysis runs all analysis passes and generates a comprehensive report.
@@ -411,6 +921,15 @@ func (a *Analyzer) FullAnalysis(traceID string) (*AnalysisReport, error) {
 		report.TokenHotspots = hotspots
 	}
 
+	// Latency hotspots
+	latencyHotspots, err := a.DetectLatencyHotspots(traceID)
+	if err != nil {
+		report.Warnings = append(report.Warnings,
+			fmt.Sprintf("Latency hotspot analysis failed: %v", err))
+	} else {
+		report.LatencyHotspots = latencyHotspots
+	}
+
 	// Memory growth
 	memGrowth, err := a.AnalyzeMemoryGrowth(traceID)
 	if err != nil {
@@ -429,6 +948,15 @@ func (a *Analyzer) FullAnalysis(traceID string) (*AnalysisReport, error) {
 		report.CostAttribution = costReport
 	}
 
+	// Error cascades
+	cascades, err := a.DetectErrorCascades(traceID)
+	if err != nil {
+		report.Warnings = append(report.Warnings,
+			fmt.Sprintf("Error cascade analysis failed: %v", err))
+	} else {
+		report.ErrorCascades = cascades
+	}
+
 	// Generate warnings based on analysis
 	if memGrowth != nil && memGrowth.IsUnbounded {
 		report.Warnings = append(report.Warnings,
@@ -444,6 +972,33 @@ func (a *Analyzer) FullAnalysis(traceID string) (*AnalysisReport, error) {
 		}
 	}
 
+	for _, h := range latencyHotspots {
+		if h.Severity == "high" {
+			report.Warnings = append(report.Warnings,
+				fmt.Sprintf("⚠ LATENCY HOTSPOT: %s took %s (Z-score: %.2f).",
+					h.OperationName, timeutil.FormatDuration(h.DurationMs), h.ZScore))
+		}
+	}
+
+	// Duplicate prompts
+	duplicates, err := a.DetectDuplicatePrompts(traceID)
+	if err != nil {
+		report.Warnings = append(report.Warnings,
+			fmt.Sprintf("Duplicate prompt analysis failed: %v", err))
+	} else {
+		report.DuplicatePrompts = duplicates
+	}
+
+	totalTokens := stats.TotalPromptTokens + stats.TotalCompletionTokens
+	for _, c := range cascades {
+		if totalTokens > 0 && c.WastedTokens*10 > totalTokens {
+			report.Warnings = append(report.Warnings,
+				fmt.Sprintf("⚠ ERROR CASCADE: failure in %s led to %d more failed spans, wasting %d tokens "+
+					"(%.0f%% of the trace).", c.RootOperationName, len(c.AffectedSpanIDs), c.WastedTokens,
+					float64(c.WastedTokens)/float64(totalTokens)*100))
+		}
+	}
+
 	return report, nil
 }
 
@@ -481,6 +1036,18 @@ func (a *Analyzer) FormatReport(report *AnalysisReport) string {
 		b.WriteString("\n")
 	}
 
+	// Latency Hotspots
+	if len(report.LatencyHotspots) > 0 {
+		b.WriteString("## Latency Hotspots\n\n")
+		b.WriteString("| Operation | Type | Duration | Z-Score | Severity |\n")
+		b.WriteString("|-----------|------|----------|---------|----------|\n")
+		for _, h := range report.LatencyHotspots {
+			b.WriteString(fmt.Sprintf("| %s | %s | %s | %.2f | %s |\n",
+				h.OperationName, h.OperationType, timeutil.FormatDuration(h.DurationMs), h.ZScore, h.Severity))
+		}
+		b.WriteString("\n")
+	}
+
 	// Memory Growth
 	if report.MemoryGrowth != nil {
 		mg := report.MemoryGrowth
@@ -501,19 +1068,54 @@ func (a *Analyzer) FormatReport(report *AnalysisReport) string {
 		ca := report.CostAttribution
 		b.WriteString("## Cost Attribution\n\n")
 		b.WriteString(fmt.Sprintf("**Total Estimated Cost:** $%.4f\n\n", ca.TotalEstimatedCost))
+		writeCostRollup(&b, "Model", ca.ByModel)
+		writeCostRollup(&b, "Operation", ca.ByOperation)
 		if len(ca.Entries) > 0 {
 			b.WriteString("| Operation | Model | Tokens | Cost | % |\n")
 			b.WriteString("|-----------|-------|--------|------|---|\n")
+			anyEstimated := false
 			for _, e := range ca.Entries {
-				b.WriteString(fmt.Sprintf("| %s | %s | %d | $%.4f | %.1f%% |\n",
+				mark := ""
+				if e.IsEstimated {
+					mark, anyEstimated = "*", true
+				}
+				b.WriteString(fmt.Sprintf("| %s | %s | %d | $%.4f%s | %.1f%% |\n",
 					e.OperationName, e.Model,
 					e.PromptTokens+e.CompletionTokens,
-					e.EstimatedCost, e.Percentage))
+					e.EstimatedCost, mark, e.Percentage))
+			}
+			if anyEstimated {
+				b.WriteString("\n\\* No price known for this model; the default rate was used.\n")
 			}
 		}
 		b.WriteString("\n")
 	}
 
+	// Error Cascades
+	if len(report.ErrorCascades) > 0 {
+		b.WriteString("## Error Cascades\n\n")
+		b.WriteString("| Root Operation | Affected Spans | Wasted Tokens | Wasted Time |\n")
+		b.WriteString("|----------------|----------------|---------------|-------------|\n")
+		for _, c := range report.ErrorCascades {
+			b.WriteString(fmt.Sprintf("| %s | %d | %d | %s |\n",
+				c.RootOperationName, len(c.AffectedSpanIDs), c.WastedTokens,
+				timeutil.FormatDuration(c.WastedDurationMs)))
+		}
+		b.WriteString("\n")
+	}
+
+	// Duplicate Prompts
+	if len(report.DuplicatePrompts) > 0 {
+		b.WriteString("## Duplicate Prompts\n\n")
+		b.WriteString("| Prompt | Calls | Similarity | Redundant Tokens | Redundant Cost |\n")
+		b.WriteString("|--------|-------|------------|------------------|----------------|\n")
+		for _, d := range report.DuplicatePrompts {
+			b.WriteString(fmt.Sprintf("| `%s` | %d | %.2f | %d | $%.4f |\n",
+				d.PromptHash, d.Count, d.Similarity, d.RedundantTokens, d.RedundantCost))
+		}
+		b.WriteString("\n")
+	}
+
 	// Warnings
 	if len(report.Warnings) > 0 {
 		b.WriteString("## Warnings\n\n")
@@ -524,3 +1126,197 @@ func (a *Analyzer) FormatReport(report *AnalysisReport) string {
 
 	return b.String()
 }
+
+// writeCostRollup writes a cost rollup as a markdown table, most
+// expensive group first. Rollups with a single group add nothing to the
+// total and are skipped.
+func writeCostRollup(b *strings.Builder, label string, rollup map[string]ModelCostSummary) {
+	if len(rollup) < 2 {
+		return
+	}
+	keys := make([]string, 0, len(rollup))
+	for k := range rollup {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if rollup[keys[i]].EstimatedCost != rollup[keys[j]].EstimatedCost {
+			return rollup[keys[i]].EstimatedCost > rollup[keys[j]].EstimatedCost
+		}
+		return keys[i] < keys[j]
+	})
+
+	b.WriteString(fmt.Sprintf("| %s | Calls | Tokens | Cost |\n", label))
+	b.WriteString(fmt.Sprintf("|%s|-------|--------|------|\n", strings.Repeat("-", len(label)+2)))
+	for _, k := range keys {
+		m := rollup[k]
+		b.WriteString(fmt.Sprintf("| %s | %d | %d | $%.4f |\n",
+			k, m.Calls, m.PromptTokens+m.CompletionTokens, m.EstimatedCost))
+	}
+	b.WriteString("\n")
+}
+
+// ============================================================
+// Trace Comparison
+// ============================================================
+
+// TraceSummary holds the headline numbers of one side of a comparison.
+type TraceSummary struct {
+	TraceID       string  `json:"trace_id"`
+	Spans         int     `json:"spans"`
+	Tokens        int     `json:"tokens"`
+	DurationMs    int64   `json:"duration_ms"` // First span start to last span end
+	EstimatedCost float64 `json:"estimated_cost_usd"`
+}
+
+// OperationDelta counts how many more (or fewer) times an operation
+// ran in the candidate than in the baseline.
+type OperationDelta struct {
+	OperationType string `json:"operation_type"`
+	OperationName string `json:"operation_name"`
+	Count         int    `json:"count"`
+}
+
+// TraceComparison is the output of `oculo diff`: a baseline trace, a
+// candidate, and how the candidate differs.
+type TraceComparison struct {
+	Base              TraceSummary     `json:"base"`
+	Candidate         TraceSummary     `json:"candidate"`
+	SpanDelta         int              `json:"span_delta"`
+	TokenDelta        int              `json:"token_delta"`
+	DurationDeltaMs   int64            `json:"duration_delta_ms"`
+	CostDelta         float64          `json:"cost_delta_usd"`
+	AddedOperations   []OperationDelta `json:"added_operations"`
+	RemovedOperations []OperationDelta `json:"removed_operations"`
+}
+
+// CompareTraces compares a candidate trace against a baseline, e.g. an
+// agent run from a pull request against the same run on main.
+func (a *Analyzer) CompareTraces(baseID, candidateID string) (*TraceComparison, error) {
+	base, err := a.store.QueryTimeline(baseID)
+	if err != nil {
+		return nil, fmt.Errorf("querying baseline trace: %w", err)
+	}
+	candidate, err := a.store.QueryTimeline(candidateID)
+	if err != nil {
+		return nil, fmt.Errorf("querying candidate trace: %w", err)
+	}
+	for id, spans := range map[string][]*database.Span{baseID: base, candidateID: candidate} {
+		if len(spans) == 0 {
+			return nil, fmt.Errorf("trace %s has no spans", id)
+		}
+	}
+	return a.compareTimelines(baseID, base, candidateID, candidate), nil
+}
+
+// compareTimelines does the work of CompareTraces on loaded spans.
+func (a *Analyzer) compareTimelines(baseID string, base []*database.Span, candidateID string, candidate []*database.Span) *TraceComparison {
+	c := &TraceComparison{
+		Base:      a.summarizeTimeline(baseID, base),
+		Candidate: a.summarizeTimeline(candidateID, candidate),
+	}
+	c.SpanDelta = c.Candidate.Spans - c.Base.Spans
+	c.TokenDelta = c.Candidate.Tokens - c.Base.Tokens
+	c.DurationDeltaMs = c.Candidate.DurationMs - c.Base.DurationMs
+	c.CostDelta = math.Round((c.Candidate.EstimatedCost-c.Base.EstimatedCost)*10000) / 10000
+
+	type op struct{ typ, name string }
+	counts := make(map[op]int)
+	for _, s := range candidate {
+		counts[op{s.OperationType, s.OperationName}]++
+	}
+	for _, s := range base {
+		counts[op{s.OperationType, s.OperationName}]--
+	}
+	for o, n := range counts {
+		switch {
+		case n > 0:
+			c.AddedOperations = append(c.AddedOperations, OperationDelta{o.typ, o.name, n})
+		case n < 0:
+			c.RemovedOperations = append(c.RemovedOperations, OperationDelta{o.typ, o.name, -n})
+		}
+	}
+	for _, ops := range [][]OperationDelta{c.AddedOperations, c.RemovedOperations} {
+		sort.Slice(ops, func(i, j int) bool {
+			if ops[i].OperationType != ops[j].OperationType {
+				return ops[i].OperationType < ops[j].OperationType
+			}
+			return ops[i].OperationName < ops[j].OperationName
+		})
+	}
+	return c
+}
+
+// summarizeTimeline totals one trace's spans, pricing LLM calls with
+// the analyzer's pricing table.
+func (a *Analyzer) summarizeTimeline(traceID string, spans []*database.Span) TraceSummary {
+	sum := TraceSummary{TraceID: traceID, Spans: len(spans)}
+	var start, end int64
+	for i, s := range spans {
+		sum.Tokens += s.PromptTokens + s.CompletionTokens
+		if s.OperationType == "LLM" {
+			model := "unknown"
+			if s.Model != nil {
+				model = *s.Model
+			}
+			cost, _ := a.PricingTable.Cost(model, s.PromptTokens, s.CompletionTokens)
+			sum.EstimatedCost += cost
+		}
+		spanEnd := s.StartTime + s.DurationMs*int64(time.Millisecond)
+		if i == 0 || s.StartTime < start {
+			start = s.StartTime
+		}
+		if i == 0 || spanEnd > end {
+			end = spanEnd
+		}
+	}
+	sum.DurationMs = (end - start) / int64(time.Millisecond)
+	sum.EstimatedCost = math.Round(sum.EstimatedCost*10000) / 10000
+	return sum
+}
+
+// FormatComparison renders a trace comparison as markdown.
+func (a *Analyzer) FormatComparison(c *TraceComparison) string {
+	var b strings.Builder
+
+	b.WriteString("# Oculo Trace Comparison\n\n")
+	b.WriteString(fmt.Sprintf("**Base:** `%s`\n", c.Base.TraceID))
+	b.WriteString(fmt.Sprintf("**Candidate:** `%s`\n\n", c.Candidate.TraceID))
+
+	b.WriteString("| Metric | Base | Candidate | Delta |\n")
+	b.WriteString("|--------|------|-----------|-------|\n")
+	b.WriteString(fmt.Sprintf("| Spans | %d | %d | %s |\n",
+		c.Base.Spans, c.Candidate.Spans, signedDelta(float64(c.SpanDelta), float64(c.Base.Spans), "%+.0f")))
+	b.WriteString(fmt.Sprintf("| Tokens | %d | %d | %s |\n",
+		c.Base.Tokens, c.Candidate.Tokens, signedDelta(float64(c.TokenDelta), float64(c.Base.Tokens), "%+.0f")))
+	b.WriteString(fmt.Sprintf("| Duration | %s | %s | %s |\n",
+		timeutil.FormatDuration(c.Base.DurationMs), timeutil.FormatDuration(c.Candidate.DurationMs),
+		signedDelta(float64(c.DurationDeltaMs), float64(c.Base.DurationMs), "%+.0fms")))
+	b.WriteString(fmt.Sprintf("| Estimated Cost | $%.4f | $%.4f | %s |\n\n",
+		c.Base.EstimatedCost, c.Candidate.EstimatedCost, signedDelta(c.CostDelta, c.Base.EstimatedCost, "%+.4f")))
+
+	for _, section := range []struct {
+		title string
+		ops   []OperationDelta
+		sign  string
+	}{{"Added Operations", c.AddedOperations, "+"}, {"Removed Operations", c.RemovedOperations, "-"}} {
+		if len(section.ops) == 0 {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("## %s\n\n", section.title))
+		for _, o := range section.ops {
+			b.WriteString(fmt.Sprintf("- %s%d × %s %s\n", section.sign, o.Count, o.OperationType, o.OperationName))
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// signedDelta formats a change with its percentage of the base value.
+func signedDelta(delta, base float64, format string) string {
+	s := fmt.Sprintf(format, delta)
+	if base != 0 {
+		s += fmt.Sprintf(" (%+.1f%%)", delta/base*100)
+	}
+	return s
+}
@@ -1,8 +1,15 @@
 package analysis
 
 import (
+	"fmt"
 	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
 )
 
 func TestLinearRegression(t *testing.T) {
@@ -69,3 +76,282 @@ func TestLinearRegressionSinglePoint(t *testing.T) {
 		t.Errorf("expected slope=0 for single point, got %.3f", slope)
 	}
 }
+
+func TestDetectErrorCascades(t *testing.T) {
+	parent := func(id string) *string { return &id }
+	sec := int64(time.Second)
+	spans := []*database.Span{
+		{SpanID: "root", OperationName: "agent", StartTime: 0, DurationMs: 5000, Status: "ok"},
+		{SpanID: "tool", ParentSpanID: parent("root"), OperationName: "search", StartTime: 1 * sec, DurationMs: 100, Status: "error"},
+		// An ok span between two failures does not break the cascade.
+		{SpanID: "wrap", ParentSpanID: parent("tool"), OperationName: "retry", StartTime: 2 * sec, DurationMs: 3000, Status: "ok"},
+		{SpanID: "llm1", ParentSpanID: parent("wrap"), OperationName: "chat", StartTime: 2 * sec, DurationMs: 1000,
+			PromptTokens: 100, CompletionTokens: 20, Status: "error"},
+		{SpanID: "llm2", ParentSpanID: parent("wrap"), OperationName: "chat", StartTime: 3 * sec, DurationMs: 2000,
+			PromptTokens: 100, CompletionTokens: 30, Status: "error"},
+		// Too late to belong to the tool's cascade, so it starts its own.
+		{SpanID: "late", ParentSpanID: parent("tool"), OperationName: "late", StartTime: 120 * sec, DurationMs: 10, Status: "error"},
+		// A lone failure is not a cascade.
+		{SpanID: "lone", ParentSpanID: parent("root"), OperationName: "lone", StartTime: 4 * sec, DurationMs: 10, Status: "error"},
+	}
+
+	cascades := detectErrorCascades(spans)
+	if len(cascades) != 1 {
+		t.Fatalf("expected 1 cascade, got %d: %+v", len(cascades), cascades)
+	}
+	c := cascades[0]
+	if c.RootSpanID != "tool" || len(c.AffectedSpanIDs) != 2 ||
+		c.AffectedSpanIDs[0] != "llm1" || c.AffectedSpanIDs[1] != "llm2" {
+		t.Errorf("unexpected cascade: %+v", c)
+	}
+	if c.WastedTokens != 250 {
+		t.Errorf("expected 250 wasted tokens, got %d", c.WastedTokens)
+	}
+	if c.WastedDurationMs != 4000 {
+		t.Errorf("expected 4000ms wasted, got %d", c.WastedDurationMs)
+	}
+}
+
+func TestFullAnalysisWarnsOnErrorCascade(t *testing.T) {
+	store, err := database.NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer store.Close()
+
+	tool := "tool"
+	store.InsertTrace(&database.Trace{TraceID: "t1", AgentName: "a", Status: "failed"})
+	store.InsertSpan(&database.Span{SpanID: "ok", TraceID: "t1", OperationType: "LLM", OperationName: "plan",
+		PromptTokens: 100, Status: "ok"})
+	store.InsertSpan(&database.Span{SpanID: "tool", TraceID: "t1", OperationType: "TOOL", OperationName: "search",
+		StartTime: 1, Status: "error"})
+	store.InsertSpan(&database.Span{SpanID: "retry", TraceID: "t1", ParentSpanID: &tool, OperationType: "LLM",
+		OperationName: "chat", StartTime: 2, PromptTokens: 50, Status: "error"})
+
+	report, err := NewAnalyzer(store).FullAnalysis("t1")
+	if err != nil {
+		t.Fatalf("FullAnalysis failed: %v", err)
+	}
+	if len(report.ErrorCascades) != 1 {
+		t.Fatalf("expected 1 cascade, got %+v", report.ErrorCascades)
+	}
+	found := false
+	for _, w := range report.Warnings {
+		found = found || strings.Contains(w, "ERROR CASCADE")
+	}
+	if !found {
+		t.Errorf("expected an error cascade warning, got %v", report.Warnings)
+	}
+}
+
+func TestPricingTableCost(t *testing.T) {
+	pricing := PricingTable{"my-model": {0.001, 0.002}, "gpt-4o": {0.0025, 0.01}}
+
+	cost, estimated := pricing.Cost("my-model", 1000, 1000)
+	if math.Abs(cost-0.003) > 1e-9 || estimated {
+		t.Errorf("custom model: got %v, estimated=%v", cost, estimated)
+	}
+	// The table overrides a built-in price.
+	if cost, _ := pricing.Cost("gpt-4o", 1000, 0); math.Abs(cost-0.0025) > 1e-9 {
+		t.Errorf("overridden model: got %v", cost)
+	}
+	// Models missing from the table fall back to the built-ins.
+	if cost, estimated := pricing.Cost("gpt-4", 1000, 0); math.Abs(cost-0.03) > 1e-9 || estimated {
+		t.Errorf("built-in model: got %v, estimated=%v", cost, estimated)
+	}
+	if _, estimated := pricing.Cost("mystery", 1000, 0); !estimated {
+		t.Error("expected an unknown model to be estimated")
+	}
+}
+
+func TestLoadPricing(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.json")
+	os.WriteFile(good, []byte(`{"my-model": [0.001, 0.002]}`), 0o644)
+	pricing, err := LoadPricing(good)
+	if err != nil {
+		t.Fatalf("LoadPricing failed: %v", err)
+	}
+	if pricing["my-model"] != [2]float64{0.001, 0.002} {
+		t.Errorf("unexpected pricing: %v", pricing)
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	os.WriteFile(bad, []byte(`{"my-model": [-1, 0]}`), 0o644)
+	if _, err := LoadPricing(bad); err == nil {
+		t.Error("expected an error for a negative price")
+	}
+
+	for _, body := range []string{`{"my-model": [0.001]}`, `{"my-model": [0.001, 0.002, 0.003]}`, `{"my-model": []}`} {
+		os.WriteFile(bad, []byte(body), 0o644)
+		if _, err := LoadPricing(bad); err == nil {
+			t.Errorf("expected an error for %s", body)
+		}
+	}
+}
+
+func TestAttributeCostsRollups(t *testing.T) {
+	store, err := database.NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer store.Close()
+
+	gpt, opus := "gpt-4o", "claude-3-opus"
+	store.InsertTrace(&database.Trace{TraceID: "t1", AgentName: "a", Status: "completed"})
+	for i, sp := range []*database.Span{
+		{OperationName: "planner.step", Model: &gpt, PromptTokens: 1000},
+		{OperationName: "planner.reflect", Model: &gpt, PromptTokens: 2000},
+		{OperationName: "chat", Model: &opus, PromptTokens: 1000, CompletionTokens: 1000},
+	} {
+		sp.SpanID = string(rune('a' + i))
+		sp.TraceID = "t1"
+		sp.OperationType = "LLM"
+		sp.StartTime = int64(i)
+		sp.Status = "ok"
+		store.InsertSpan(sp)
+	}
+
+	report, err := NewAnalyzer(store).AttributeCosts("t1")
+	if err != nil {
+		t.Fatalf("AttributeCosts failed: %v", err)
+	}
+
+	if got := report.ByModel["gpt-4o"]; got.Calls != 2 || got.PromptTokens != 3000 || math.Abs(got.EstimatedCost-0.015) > 1e-9 {
+		t.Errorf("unexpected gpt-4o rollup: %+v", got)
+	}
+	if got := report.ByOperation["planner"]; got.Calls != 2 {
+		t.Errorf("expected planner.* calls grouped, got %+v", report.ByOperation)
+	}
+	if got := report.ByOperation["chat"]; got.Calls != 1 || got.CompletionTokens != 1000 {
+		t.Errorf("unexpected chat rollup: %+v", got)
+	}
+
+	for i := 1; i < len(report.Entries); i++ {
+		if report.Entries[i].EstimatedCost > report.Entries[i-1].EstimatedCost {
+			t.Fatalf("entries not sorted by cost: %+v", report.Entries)
+		}
+	}
+	if report.Entries[0].OperationName != "chat" {
+		t.Errorf("expected the opus call first, got %s", report.Entries[0].OperationName)
+	}
+}
+
+func TestDetectLatencyHotspots(t *testing.T) {
+	store, err := database.NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer store.Close()
+
+	root := "root"
+	store.InsertTrace(&database.Trace{TraceID: "t1", AgentName: "a", Status: "completed"})
+	// The root spans everything, so it must not count as an outlier.
+	store.InsertSpan(&database.Span{SpanID: root, TraceID: "t1", OperationType: "PLANNING",
+		OperationName: "agent", DurationMs: 40000, Status: "ok"})
+	// With one outlier among n values its Z-score is sqrt(n-1), so twelve
+	// spans are needed to clear the "high" threshold of 3.
+	for i := 0; i < 12; i++ {
+		duration := int64(400)
+		if i == 6 {
+			duration = 30000
+		}
+		store.InsertSpan(&database.Span{SpanID: fmt.Sprintf("s%d", i), TraceID: "t1", ParentSpanID: &root,
+			OperationType: "TOOL", OperationName: fmt.Sprintf("op%d", i), StartTime: int64(i + 1),
+			DurationMs: duration, Status: "ok"})
+	}
+
+	analyzer := NewAnalyzer(store)
+	hotspots, err := analyzer.DetectLatencyHotspots("t1")
+	if err != nil {
+		t.Fatalf("DetectLatencyHotspots failed: %v", err)
+	}
+	if len(hotspots) != 1 || hotspots[0].SpanID != "s6" || hotspots[0].Severity != "high" {
+		t.Fatalf("expected s6 as the only, high hotspot, got %+v", hotspots)
+	}
+
+	report, err := analyzer.FullAnalysis("t1")
+	if err != nil {
+		t.Fatalf("FullAnalysis failed: %v", err)
+	}
+	found := false
+	for _, w := range report.Warnings {
+		found = found || strings.Contains(w, "LATENCY HOTSPOT: op6")
+	}
+	if !found {
+		t.Errorf("expected a latency hotspot warning, got %v", report.Warnings)
+	}
+}
+
+func TestDetectLatencyHotspotsUniform(t *testing.T) {
+	store, err := database.NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer store.Close()
+
+	store.InsertTrace(&database.Trace{TraceID: "t1", AgentName: "a", Status: "completed"})
+	for i := 0; i < 5; i++ {
+		store.InsertSpan(&database.Span{SpanID: fmt.Sprintf("s%d", i), TraceID: "t1", OperationType: "TOOL",
+			OperationName: "op", StartTime: int64(i), DurationMs: 100, Status: "ok"})
+	}
+
+	hotspots, err := NewAnalyzer(store).DetectLatencyHotspots("t1")
+	if err != nil || hotspots != nil {
+		t.Errorf("expected no hotspots for equal durations, got %+v, %v", hotspots, err)
+	}
+}
+
+func TestDetectDuplicatePrompts(t *testing.T) {
+	store, err := database.NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer store.Close()
+
+	model := "gpt-4o"
+	base := "Summarize the following support ticket for the on-call engineer and list the affected services and the customer impact"
+	prompts := []string{
+		base,
+		"  " + strings.ReplaceAll(base, " ", "  \n") + "\n", // same after normalizing
+		base + " please", // near-duplicate
+		"Write a haiku about databases",
+		"Translate this sentence into French",
+	}
+	store.InsertTrace(&database.Trace{TraceID: "t1", AgentName: "a", Status: "completed"})
+	for i, p := range prompts {
+		prompt := p
+		store.InsertSpan(&database.Span{SpanID: fmt.Sprintf("s%d", i), TraceID: "t1", OperationType: "LLM",
+			OperationName: "chat", StartTime: int64(i), Prompt: &prompt, Model: &model,
+			PromptTokens: 1000, CompletionTokens: 200, Status: "ok"})
+	}
+
+	groups, err := NewAnalyzer(store).DetectDuplicatePrompts("t1")
+	if err != nil {
+		t.Fatalf("DetectDuplicatePrompts failed: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("expected 1 group, got %+v", groups)
+	}
+	g := groups[0]
+	if g.Count != 3 || strings.Join(g.SpanIDs, ",") != "s0,s1,s2" {
+		t.Errorf("unexpected group members: %+v", g)
+	}
+	if g.Similarity >= 1 || g.Similarity < duplicatePromptSimilarity {
+		t.Errorf("expected a near-duplicate similarity, got %.2f", g.Similarity)
+	}
+	if g.RedundantTokens != 2400 || math.Abs(g.RedundantCost-0.016) > 1e-9 {
+		t.Errorf("expected 2400 redundant tokens costing $0.016, got %d / $%.4f", g.RedundantTokens, g.RedundantCost)
+	}
+}
+
+func TestJaccard(t *testing.T) {
+	a := wordSet("the quick brown fox")
+	b := wordSet("The quick red fox")
+	if got := jaccard(a, b); math.Abs(got-0.6) > 1e-9 {
+		t.Errorf("expected 3/5, got %v", got)
+	}
+	if got := jaccard(a, a); got != 1 {
+		t.Errorf("expected identical sets to score 1, got %v", got)
+	}
+}
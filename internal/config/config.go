@@ -0,0 +1,150 @@
+// Package config loads the optional Oculo config file, which supplies
+// defaults for the flags shared by the oculo, oculo-daemon and
+// oculo-tui commands.
+//
+// The file is JSON and lives at ~/.oculo/config.json unless --config
+// points elsewhere:
+//
+//	{
+//	  "db": "/data/oculo.db",
+//	  "listen": "/tmp/oculo.sock",
+//	  "metrics": "127.0.0.1:9877",
+//	  "batch": 500,
+//	  "flush": "250ms",
+//	  "pricing_file": "~/.oculo/pricing.json",
+//	  "theme": "colorblind"
+//	}
+//
+// A flag given on the command line always wins over the file.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// File is the contents of a config file. Each field fills the flag of
+// the same name (pricing_file fills --pricing) in commands that have it.
+type File struct {
+	DB          string `json:"db,omitempty"`
+	Listen      string `json:"listen,omitempty"`
+	Metrics     string `json:"metrics,omitempty"`
+	Batch       int    `json:"batch,omitempty"`
+	Flush       string `json:"flush,omitempty"` // Go duration, e.g. "500ms"
+	PricingFile string `json:"pricing_file,omitempty"`
+	Theme       string `json:"theme,omitempty"`
+}
+
+// DefaultPath is where the config file is read from without --config.
+func DefaultPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".oculo", "config.json")
+}
+
+// Load reads a config file. Unknown keys are an error so typos do not
+// go unnoticed.
+func Load(path string) (*File, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var f File
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// flagValues maps flag names to the file's values, leaving out those
+// the file does not set.
+func (f *File) flagValues() map[string]string {
+	values := map[string]string{
+		"db":      f.DB,
+		"listen":  f.Listen,
+		"metrics": f.Metrics,
+		"flush":   f.Flush,
+		"pricing": f.PricingFile,
+		"theme":   f.Theme,
+	}
+	if f.Batch != 0 {
+		values["batch"] = strconv.Itoa(f.Batch)
+	}
+	for name, v := range values {
+		if v == "" {
+			delete(values, name)
+		}
+	}
+	return values
+}
+
+// pathFlags are the flags whose file values are paths, in which a
+// leading "~/" is expanded.
+var pathFlags = map[string]bool{"db": true, "pricing": true}
+
+// ExpandHome replaces a leading "~/" in path with the user's home
+// directory. Other paths are returned unchanged.
+func ExpandHome(path string) (string, error) {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, rest), nil
+}
+
+// Apply sets each flag in fs that the file has a value for, unless it
+// was given on the command line. Flags fs does not define are skipped.
+func (f *File) Apply(fs *flag.FlagSet) error {
+	set := make(map[string]bool)
+	fs.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+
+	for name, v := range f.flagValues() {
+		if set[name] || fs.Lookup(name) == nil {
+			continue
+		}
+		if pathFlags[name] {
+			var err error
+			if v, err = ExpandHome(v); err != nil {
+				return fmt.Errorf("config value for %s: %w", name, err)
+			}
+		}
+		if err := fs.Set(name, v); err != nil {
+			return fmt.Errorf("config value for %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Parse adds a --config flag to fs, parses args, and then fills in the
+// flags left unset from the config file. A missing file is only an
+// error when --config names it explicitly.
+func Parse(fs *flag.FlagSet, args []string) error {
+	path := fs.String("config", DefaultPath(), "Path to config file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	explicit := false
+	fs.Visit(func(fl *flag.Flag) { explicit = explicit || fl.Name == "config" })
+
+	f, err := Load(*path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return f.Apply(fs)
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeConfig writes contents to a config file in a temporary directory.
+func writeConfig(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// daemonFlags defines a few flags the way the commands do.
+func daemonFlags() (*flag.FlagSet, *string, *int, *time.Duration) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	db := fs.String("db", "default.db", "")
+	batch := fs.Int("batch", 1000, "")
+	flush := fs.Duration("flush", 500*time.Millisecond, "")
+	return fs, db, batch, flush
+}
+
+func TestParseUsesFileWhenFlagAbsent(t *testing.T) {
+	path := writeConfig(t, `{"db":"/data/oculo.db","batch":50,"flush":"2s","theme":"colorblind"}`)
+	fs, db, batch, flush := daemonFlags()
+
+	if err := Parse(fs, []string{"--config", path}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if *db != "/data/oculo.db" || *batch != 50 || *flush != 2*time.Second {
+		t.Errorf("expected file values, got db=%s batch=%d flush=%v", *db, *batch, *flush)
+	}
+}
+
+func TestParseFlagOverridesFile(t *testing.T) {
+	path := writeConfig(t, `{"db":"/data/oculo.db","batch":50}`)
+	fs, db, batch, _ := daemonFlags()
+
+	if err := Parse(fs, []string{"--config", path, "--db", "cli.db"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if *db != "cli.db" {
+		t.Errorf("expected the flag to win, got db=%s", *db)
+	}
+	if *batch != 50 {
+		t.Errorf("expected batch from the file, got %d", *batch)
+	}
+}
+
+func TestParseExpandsHomeInPaths(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	path := writeConfig(t, `{"db":"~/data/oculo.db","pricing_file":"~/.oculo/pricing.json","listen":"~/oculo.sock"}`)
+	fs, db, _, _ := daemonFlags()
+	pricing := fs.String("pricing", "", "")
+	listen := fs.String("listen", "", "")
+
+	if err := Parse(fs, []string{"--config", path}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if want := filepath.Join(home, "data", "oculo.db"); *db != want {
+		t.Errorf("expected db %s, got %s", want, *db)
+	}
+	if want := filepath.Join(home, ".oculo", "pricing.json"); *pricing != want {
+		t.Errorf("expected pricing %s, got %s", want, *pricing)
+	}
+	if *listen != "~/oculo.sock" {
+		t.Errorf("expected non-path values left alone, got %s", *listen)
+	}
+}
+
+func TestParseMissingFile(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "nope.json")
+
+	fs, _, _, _ := daemonFlags()
+	if err := Parse(fs, []string{"--config", missing}); err == nil {
+		t.Error("expected an error for an explicit --config that does not exist")
+	}
+
+	// Without --config a missing default file is fine
+	t.Setenv("HOME", t.TempDir())
+	fs, db, _, _ := daemonFlags()
+	if err := Parse(fs, nil); err != nil {
+		t.Errorf("expected no error without a config file, got %v", err)
+	}
+	if *db != "default.db" {
+		t.Errorf("expected the flag default, got %s", *db)
+	}
+}
+
+func TestParseInvalidFile(t *testing.T) {
+	for name, contents := range map[string]string{
+		"unknown key": `{"dbpath":"x"}`,
+		"bad value":   `{"flush":"soon"}`,
+		"not json":    `db = "x"`,
+	} {
+		fs, _, _, _ := daemonFlags()
+		if err := Parse(fs, []string{"--config", writeConfig(t, contents)}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
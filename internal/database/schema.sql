@@ -81,6 +81,14 @@ CREATE TABLE IF NOT EXISTS tool_calls (
     created_at     INTEGER NOT NULL DEFAULT (strftime('%s','now') * 1000000000)
 );
 
+-- trace_tags: User-assigned labels on traces, e.g. from CI scripts.
+CREATE TABLE IF NOT EXISTS trace_tags (
+    trace_id     TEXT NOT NULL REFERENCES traces(trace_id) ON DELETE CASCADE,
+    tag          TEXT NOT NULL,
+    created_at   INTEGER NOT NULL DEFAULT (strftime('%s','now') * 1000000000),
+    PRIMARY KEY (trace_id, tag)
+);
+
 -- =============================================================
 -- Indexes: Optimized for the most common query patterns
 -- =============================================================
@@ -103,6 +111,9 @@ CREATE INDEX IF NOT EXISTS idx_traces_status ON traces(status);
 -- Tool call lookup
 CREATE INDEX IF NOT EXISTS idx_tool_calls_span ON tool_calls(span_id);
 
+-- Tag lookup: "Show me all traces tagged 'regression'"
+CREATE INDEX IF NOT EXISTS idx_trace_tags_tag ON trace_tags(tag);
+
 -- =============================================================
 -- Full-Text Search: Semantic search over prompt/completion content
 -- =============================================================
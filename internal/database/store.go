@@ -10,7 +10,10 @@ import (
 	"database/sql"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,6 +23,10 @@ import (
 //go:embed schema.sql
 var schemaFS embed.FS
 
+// ErrNotFound is returned, wrapped, by lookups of a single record that
+// does not exist. Check for it with errors.Is.
+var ErrNotFound = errors.New("not found")
+
 // Store defines the interface for trace data persistence.
 // This abstraction allows for mocking in tests and potential
 // future backends beyond SQLite.
@@ -40,16 +47,49 @@ type Store interface {
 
 	// QueryTraces returns traces matching the given filter, ordered by start_time DESC.
 	QueryTraces(filter TraceFilter) ([]*Trace, error)
+	// GetTrace returns a single trace, or an error wrapping ErrNotFound.
+	GetTrace(traceID string) (*Trace, error)
 	// QueryTimeline returns all spans for a trace, ordered by start_time.
 	QueryTimeline(traceID string) ([]*Span, error)
+	// QueryTimelineFunc streams a trace's spans to fn, ordered by start_time.
+	QueryTimelineFunc(traceID string, fn func(*Span) error) error
+	// GetSpan returns a single span, or an error wrapping ErrNotFound.
+	GetSpan(spanID string) (*Span, error)
 	// GetMemoryDiffs returns all memory events for a span, ordered by timestamp.
 	GetMemoryDiffs(spanID string) ([]*MemoryEvent, error)
+	// GetToolCalls returns the tool calls made by a span, in invocation order.
+	GetToolCalls(spanID string) ([]*ToolCall, error)
 	// GetMemoryTimeline returns the full mutation history for a memory key.
 	GetMemoryTimeline(key string, namespace string) ([]*MemoryEvent, error)
 	// SearchContent performs full-text search over prompt/completion content.
 	SearchContent(query string, limit int) ([]*Span, error)
+	// SearchContentInTrace performs full-text search within a single trace.
+	SearchContentInTrace(traceID, query string, limit int) ([]*Span, error)
+	// SearchContentScored is SearchContent with each match's BM25 score.
+	SearchContentScored(query string, limit int) ([]SearchResult, error)
 	// GetTraceStats returns aggregated statistics for a trace.
 	GetTraceStats(traceID string) (*TraceStats, error)
+	// AggregateAgentStats returns per-agent totals over the traces matching filter.
+	AggregateAgentStats(filter TraceFilter) ([]*AgentStats, error)
+
+	// ExportTrace returns a trace with all its spans, memory events and tool calls.
+	ExportTrace(traceID string) (*TraceExport, error)
+	// ImportTrace inserts an exported trace in a single transaction.
+	ImportTrace(exp *TraceExport) error
+	// PruneTracesBefore deletes traces that started before a cutoff, with their spans.
+	PruneTracesBefore(before int64, agentName string) (int64, error)
+	// DeleteTrace removes a trace and everything recorded under it.
+	DeleteTrace(traceID string) error
+	// DeleteTracesOlderThan removes every trace that started before a
+	// cutoff, optionally only one agent's, and everything under them.
+	DeleteTracesOlderThan(cutoffNano int64, agentName string) (int, error)
+
+	// AddTraceTags labels a trace with the given tags.
+	AddTraceTags(traceID string, tags []string) error
+	// RemoveTraceTags removes the given tags from a trace.
+	RemoveTraceTags(traceID string, tags []string) error
+	// GetTraceTags returns a trace's tags in alphabetical order.
+	GetTraceTags(traceID string) ([]string, error)
 
 	// WritePendingPayload stores a raw payload for crash recovery.
 	WritePendingPayload(payload []byte) (int64, error)
@@ -74,6 +114,10 @@ type Trace struct {
 	EndTime   *int64            `json:"end_time,omitempty"`
 	Status    string            `json:"status"`
 	Metadata  map[string]string `json:"metadata,omitempty"`
+
+	// Aggregates filled in by QueryTraces; not stored on insert.
+	SpanCount   int   `json:"span_count,omitempty"`
+	TotalTokens int64 `json:"total_tokens,omitempty"`
 }
 
 // Span represents a single operation within a trace.
@@ -94,6 +138,9 @@ type Span struct {
 	Metadata         *string `json:"metadata,omitempty"`
 	Status           string  `json:"status"`
 	ErrorMessage     *string `json:"error_message,omitempty"`
+
+	// MemoryEventCount is filled in by QueryTimeline; not stored on insert.
+	MemoryEventCount int `json:"memory_event_count,omitempty"`
 }
 
 // MemoryEvent captures a single mutation to the agent's memory.
@@ -127,6 +174,30 @@ type TraceFilter struct {
 	Until     *int64  `json:"until,omitempty"` // Unix nanoseconds
 	Limit     int     `json:"limit"`
 	Offset    int     `json:"offset"`
+
+	// MetadataMatch keeps traces whose metadata has every key set to
+	// the given value.
+	MetadataMatch map[string]string `json:"metadata_match,omitempty"`
+	// Tag keeps traces carrying this tag.
+	Tag *string `json:"tag,omitempty"`
+
+	// BeforeStartTime pages by keyset instead of Offset: pass the
+	// start_time of the last trace of the previous page to get the
+	// traces after it. Results are ordered by start_time DESC, with
+	// ties broken by trace_id DESC; set BeforeTraceID to that last
+	// trace's ID too so traces sharing its start_time are not skipped.
+	// When BeforeStartTime is set, Offset is ignored.
+	BeforeStartTime *int64  `json:"before_start_time,omitempty"`
+	BeforeTraceID   *string `json:"before_trace_id,omitempty"`
+}
+
+// SearchResult is a full-text search match with its relevance.
+type SearchResult struct {
+	*Span
+	// Score is the raw FTS5 BM25 score. It is negative, and lower means
+	// more relevant: -8.2 is a stronger match than -0.4. Scores compare
+	// only within the results of one query.
+	Score float64 `json:"score"`
 }
 
 // TraceStats holds aggregated statistics for a single trace.
@@ -140,6 +211,52 @@ type TraceStats struct {
 	TotalCompletionTokens int `json:"total_completion_tokens"`
 	TotalDurationMs  int64  `json:"total_duration_ms"`
 	MemoryEventCount int    `json:"memory_event_count"`
+	ErrorSpans       int    `json:"error_spans"`
+}
+
+// AgentStats holds totals across all of one agent's traces.
+type AgentStats struct {
+	AgentName        string       `json:"agent_name"`
+	TraceCount       int          `json:"trace_count"`
+	SpanCount        int          `json:"span_count"`
+	ErrorCount       int          `json:"error_count"`
+	PromptTokens     int          `json:"prompt_tokens"`
+	CompletionTokens int          `json:"completion_tokens"`
+	Models           []ModelUsage `json:"models,omitempty"`
+}
+
+// ModelUsage is the LLM token usage of one model.
+type ModelUsage struct {
+	Model            string `json:"model"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+}
+
+// TraceExport is a self-contained copy of one trace and everything
+// recorded under it, for moving traces between databases.
+type TraceExport struct {
+	Trace        *Trace         `json:"trace"`
+	Spans        []*Span        `json:"spans"`
+	MemoryEvents []*MemoryEvent `json:"memory_events,omitempty"`
+	ToolCalls    []*ToolCall    `json:"tool_calls,omitempty"`
+	Tags         []string       `json:"tags,omitempty"`
+}
+
+// IntegrityReport is the outcome of CheckIntegrity. Orphans are rows
+// whose parent row no longer exists.
+type IntegrityReport struct {
+	IntegrityErrors    []string `json:"integrity_errors,omitempty"`
+	FTSError           string   `json:"fts_error,omitempty"`
+	OrphanSpans        int64    `json:"orphan_spans"`
+	OrphanMemoryEvents int64    `json:"orphan_memory_events"`
+	OrphanToolCalls    int64    `json:"orphan_tool_calls"`
+	OrphanTags         int64    `json:"orphan_tags"`
+}
+
+// OK reports whether the check found no problems.
+func (r *IntegrityReport) OK() bool {
+	return len(r.IntegrityErrors) == 0 && r.FTSError == "" &&
+		r.OrphanSpans == 0 && r.OrphanMemoryEvents == 0 && r.OrphanToolCalls == 0 && r.OrphanTags == 0
 }
 
 // PendingWrite represents an uncommitted ingestion payload.
@@ -430,32 +547,30 @@ func (s *DBService) BatchInsertMemoryEvents(events []*MemoryEvent) error {
 }
 
 // QueryTraces returns traces matching the given filter criteria.
-// Results are ordered by start_time descending (most recent first).
+// Results are ordered by start_time descending (most recent first),
+// then by trace_id descending so pages are stable across equal times.
 func (s *DBService) QueryTraces(filter TraceFilter) ([]*Trace, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	query := `SELECT trace_id, agent_name, start_time, end_time, status, metadata FROM traces WHERE 1=1`
-	args := make([]interface{}, 0)
-
-	if filter.AgentName != nil {
-		query += ` AND agent_name = ?`
-		args = append(args, *filter.AgentName)
-	}
-	if filter.Status != nil {
-		query += ` AND status = ?`
-		args = append(args, *filter.Status)
-	}
-	if filter.Since != nil {
-		query += ` AND start_time >= ?`
-		args = append(args, *filter.Since)
-	}
-	if filter.Until != nil {
-		query += ` AND start_time <= ?`
-		args = append(args, *filter.Until)
+	// The page of traces is materialized first and span counts and
+	// token totals are computed for those rows only, so the cost of a
+	// page does not grow with the spans of every matching trace.
+	query := `WITH page AS MATERIALIZED (
+		SELECT t.trace_id, t.agent_name, t.start_time, t.end_time, t.status, t.metadata
+		FROM traces t WHERE 1=1`
+	where, args := traceFilterClauses(filter)
+	query += where
+	if filter.BeforeStartTime != nil {
+		if filter.BeforeTraceID != nil {
+			query += ` AND (t.start_time < ? OR (t.start_time = ? AND t.trace_id < ?))`
+			args = append(args, *filter.BeforeStartTime, *filter.BeforeStartTime, *filter.BeforeTraceID)
+		} else {
+			query += ` AND t.start_time < ?`
+			args = append(args, *filter.BeforeStartTime)
+		}
 	}
-
-	query += ` ORDER BY start_time DESC`
+	query += ` ORDER BY t.start_time DESC, t.trace_id DESC`
 
 	if filter.Limit > 0 {
 		query += ` LIMIT ?`
@@ -463,10 +578,14 @@ func (s *DBService) QueryTraces(filter TraceFilter) ([]*Trace, error) {
 	} else {
 		query += ` LIMIT 100`
 	}
-	if filter.Offset > 0 {
+	if filter.Offset > 0 && filter.BeforeStartTime == nil {
 		query += ` OFFSET ?`
 		args = append(args, filter.Offset)
 	}
+	query += `)
+		SELECT p.trace_id, p.agent_name, p.start_time, p.end_time, p.status, p.metadata, ` + traceSpanTotals("p") + `
+		FROM page p
+		ORDER BY p.start_time DESC, p.trace_id DESC`
 
 	rows, err := s.db.Query(query, args...)
 	if err != nil {
@@ -478,41 +597,175 @@ func (s *DBService) QueryTraces(filter TraceFilter) ([]*Trace, error) {
 	for rows.Next() {
 		t := &Trace{}
 		var metadataStr *string
-		if err := rows.Scan(&t.TraceID, &t.AgentName, &t.StartTime, &t.EndTime, &t.Status, &metadataStr); err != nil {
+		if err := rows.Scan(&t.TraceID, &t.AgentName, &t.StartTime, &t.EndTime, &t.Status, &metadataStr,
+			&t.SpanCount, &t.TotalTokens); err != nil {
 			return nil, fmt.Errorf("scanning trace row: %w", err)
 		}
-		if metadataStr != nil {
-			t.Metadata = make(map[string]string)
-			if err := json.Unmarshal([]byte(*metadataStr), &t.Metadata); err != nil {
-				// Non-fatal: metadata is supplementary
-				t.Metadata = map[string]string{"_raw": *metadataStr}
-			}
-		}
+		t.Metadata = decodeTraceMetadata(metadataStr)
 		traces = append(traces, t)
 	}
 	return traces, rows.Err()
 }
 
+// GetTrace returns the trace with the given ID, with its metadata and
+// the span count and token total QueryTraces fills in. A missing trace
+// is reported as an error wrapping ErrNotFound.
+func (s *DBService) GetTrace(traceID string) (*Trace, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t := &Trace{}
+	var metadataStr *string
+	err := s.db.QueryRow(`
+		SELECT t.trace_id, t.agent_name, t.start_time, t.end_time, t.status, t.metadata, `+traceSpanTotals("t")+`
+		FROM traces t
+		WHERE t.trace_id = ?
+	`, traceID).Scan(&t.TraceID, &t.AgentName, &t.StartTime, &t.EndTime, &t.Status, &metadataStr,
+		&t.SpanCount, &t.TotalTokens)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("trace %s: %w", traceID, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting trace %s: %w", traceID, err)
+	}
+	t.Metadata = decodeTraceMetadata(metadataStr)
+	return t, nil
+}
+
+// traceSpanTotals returns the select-list columns for a trace's span
+// count and token total, as correlated subqueries against the traces
+// row aliased as alias.
+func traceSpanTotals(alias string) string {
+	return `(SELECT COUNT(*) FROM spans s WHERE s.trace_id = ` + alias + `.trace_id),
+		(SELECT COALESCE(SUM(s.prompt_tokens + s.completion_tokens), 0) FROM spans s WHERE s.trace_id = ` + alias + `.trace_id)`
+}
+
+// decodeTraceMetadata parses a trace's stored metadata JSON. Metadata
+// that is not a string map is kept whole under "_raw" rather than
+// failing the read, since it is supplementary.
+func decodeTraceMetadata(raw *string) map[string]string {
+	if raw == nil {
+		return nil
+	}
+	metadata := make(map[string]string)
+	if err := json.Unmarshal([]byte(*raw), &metadata); err != nil {
+		return map[string]string{"_raw": *raw}
+	}
+	return metadata
+}
+
+// traceFilterClauses turns the trace-level conditions of a filter into
+// " AND ..." clauses over the traces table aliased as t. Limit and
+// Offset are left to the caller.
+func traceFilterClauses(filter TraceFilter) (string, []interface{}) {
+	var where strings.Builder
+	args := make([]interface{}, 0)
+
+	if filter.AgentName != nil {
+		where.WriteString(` AND t.agent_name = ?`)
+		args = append(args, *filter.AgentName)
+	}
+	if filter.Status != nil {
+		where.WriteString(` AND t.status = ?`)
+		args = append(args, *filter.Status)
+	}
+	if filter.Since != nil {
+		where.WriteString(` AND t.start_time >= ?`)
+		args = append(args, *filter.Since)
+	}
+	if filter.Until != nil {
+		where.WriteString(` AND t.start_time <= ?`)
+		args = append(args, *filter.Until)
+	}
+	keys := make([]string, 0, len(filter.MetadataMatch))
+	for k := range filter.MetadataMatch {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		where.WriteString(` AND EXISTS (SELECT 1 FROM json_each(t.metadata) WHERE key = ? AND value = ?)`)
+		args = append(args, k, filter.MetadataMatch[k])
+	}
+	if filter.Tag != nil {
+		where.WriteString(` AND EXISTS (SELECT 1 FROM trace_tags tt WHERE tt.trace_id = t.trace_id AND tt.tag = ?)`)
+		args = append(args, *filter.Tag)
+	}
+	return where.String(), args
+}
+
 // QueryTimeline returns all spans for a given trace, ordered by start_time.
 // This is the primary query for the TUI timeline view.
 func (s *DBService) QueryTimeline(traceID string) ([]*Span, error) {
+	var spans []*Span
+	err := s.QueryTimelineFunc(traceID, func(sp *Span) error {
+		spans = append(spans, sp)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return spans, nil
+}
+
+// QueryTimelineFunc calls fn with each span of a trace in start_time
+// order as it is read, without holding the whole timeline in memory.
+// It stops at the first error fn returns and returns that error as is.
+// The store's connection is busy until it returns, so fn must not call
+// back into the store.
+func (s *DBService) QueryTimelineFunc(traceID string, fn func(*Span) error) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	// Memory event counts come from the span index so the TUI can badge
+	// spans without a query per row.
 	rows, err := s.db.Query(`
 		SELECT span_id, trace_id, parent_span_id, operation_type, operation_name,
 			start_time, duration_ms, prompt, completion, prompt_tokens, completion_tokens,
-			model, temperature, metadata, status, error_message
+			model, temperature, metadata, status, error_message,
+			(SELECT COUNT(*) FROM memory_events me WHERE me.span_id = spans.span_id)
 		FROM spans
 		WHERE trace_id = ?
 		ORDER BY start_time ASC
 	`, traceID)
 	if err != nil {
-		return nil, fmt.Errorf("querying timeline for trace %s: %w", traceID, err)
+		return fmt.Errorf("querying timeline for trace %s: %w", traceID, err)
 	}
 	defer rows.Close()
 
-	return scanSpans(rows)
+	for rows.Next() {
+		sp, err := scanSpanRow(rows, true)
+		if err != nil {
+			return err
+		}
+		if err := fn(sp); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetSpan returns the span with the given ID, with its memory event
+// count. A missing span is reported as an error wrapping ErrNotFound.
+func (s *DBService) GetSpan(spanID string) (*Span, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sp := &Span{}
+	err := s.db.QueryRow(`
+		SELECT span_id, trace_id, parent_span_id, operation_type, operation_name,
+			start_time, duration_ms, prompt, completion, prompt_tokens, completion_tokens,
+			model, temperature, metadata, status, error_message,
+			(SELECT COUNT(*) FROM memory_events me WHERE me.span_id = spans.span_id)
+		FROM spans
+		WHERE span_id = ?
+	`, spanID).Scan(append(spanScanDest(sp), &sp.MemoryEventCount)...)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("span %s: %w", spanID, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting span %s: %w", spanID, err)
+	}
+	return sp, nil
 }
 
 // GetMemoryDiffs returns all memory events for a given span,
@@ -535,6 +788,27 @@ func (s *DBService) GetMemoryDiffs(spanID string) ([]*MemoryEvent, error) {
 	return scanMemoryEvents(rows)
 }
 
+// GetToolCalls returns the tool calls made by a span, ordered by
+// call_id so they read in the order they were invoked. This powers the
+// tool call section of the TUI detail pane.
+func (s *DBService) GetToolCalls(spanID string) ([]*ToolCall, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT call_id, span_id, tool_name, arguments_json, result_json, success, latency_ms
+		FROM tool_calls
+		WHERE span_id = ?
+		ORDER BY call_id ASC
+	`, spanID)
+	if err != nil {
+		return nil, fmt.Errorf("querying tool calls for span %s: %w", spanID, err)
+	}
+	defer rows.Close()
+
+	return scanToolCalls(rows)
+}
+
 // GetMemoryTimeline returns the full mutation history for a specific
 // memory key within a namespace. This lets users answer:
 // "When did the agent start believing X?"
@@ -559,6 +833,24 @@ func (s *DBService) GetMemoryTimeline(key string, namespace string) ([]*MemoryEv
 // SearchContent performs full-text search over prompt and completion content
 // using the FTS5 index. Returns matching spans with BM25 relevance ranking.
 func (s *DBService) SearchContent(query string, limit int) ([]*Span, error) {
+	return spansOf(s.searchContent(query, "", limit))
+}
+
+// SearchContentInTrace is SearchContent limited to the spans of one
+// trace, ranked by BM25 among those spans.
+func (s *DBService) SearchContentInTrace(traceID, query string, limit int) ([]*Span, error) {
+	return spansOf(s.searchContent(query, traceID, limit))
+}
+
+// SearchContentScored is SearchContent with each match's BM25 score,
+// so callers can tell strong matches from weak ones.
+func (s *DBService) SearchContentScored(query string, limit int) ([]SearchResult, error) {
+	return s.searchContent(query, "", limit)
+}
+
+// searchContent runs a full-text search, within one trace when traceID
+// is not empty, best matches first.
+func (s *DBService) searchContent(query, traceID string, limit int) ([]SearchResult, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -566,22 +858,51 @@ func (s *DBService) SearchContent(query string, limit int) ([]*Span, error) {
 		limit = 20
 	}
 
-	rows, err := s.db.Query(`
+	sqlQuery := `
 		SELECT s.span_id, s.trace_id, s.parent_span_id, s.operation_type, s.operation_name,
 			s.start_time, s.duration_ms, s.prompt, s.completion, s.prompt_tokens, s.completion_tokens,
-			s.model, s.temperature, s.metadata, s.status, s.error_message
+			s.model, s.temperature, s.metadata, s.status, s.error_message,
+			bm25(spans_fts)
 		FROM spans s
 		INNER JOIN spans_fts f ON s.span_id = f.span_id
-		WHERE spans_fts MATCH ?
+		WHERE spans_fts MATCH ?`
+	args := []interface{}{query}
+	if traceID != "" {
+		sqlQuery += ` AND s.trace_id = ?`
+		args = append(args, traceID)
+	}
+	sqlQuery += `
 		ORDER BY rank
-		LIMIT ?
-	`, query, limit)
+		LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.Query(sqlQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("searching content for %q: %w", query, err)
 	}
 	defer rows.Close()
 
-	return scanSpans(rows)
+	var results []SearchResult
+	for rows.Next() {
+		r := SearchResult{Span: &Span{}}
+		if err := rows.Scan(append(spanScanDest(r.Span), &r.Score)...); err != nil {
+			return nil, fmt.Errorf("scanning search result row: %w", err)
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// spansOf drops the scores from search results.
+func spansOf(results []SearchResult, err error) ([]*Span, error) {
+	if err != nil {
+		return nil, err
+	}
+	var spans []*Span
+	for _, r := range results {
+		spans = append(spans, r.Span)
+	}
+	return spans, nil
 }
 
 // GetTraceStats returns aggregated statistics for a trace.
@@ -600,12 +921,14 @@ func (s *DBService) GetTraceStats(traceID string) (*TraceStats, error) {
 			COALESCE(SUM(CASE WHEN operation_type = 'MEMORY' THEN 1 ELSE 0 END), 0) as memory_ops,
 			COALESCE(SUM(prompt_tokens), 0) as total_prompt_tokens,
 			COALESCE(SUM(completion_tokens), 0) as total_completion_tokens,
-			COALESCE(SUM(duration_ms), 0) as total_duration_ms
+			COALESCE(SUM(duration_ms), 0) as total_duration_ms,
+			COALESCE(SUM(CASE WHEN status = 'error' THEN 1 ELSE 0 END), 0) as error_spans
 		FROM spans
 		WHERE trace_id = ?
 	`, traceID).Scan(
 		&stats.TotalSpans, &stats.LLMCalls, &stats.ToolCalls, &stats.MemoryOps,
 		&stats.TotalPromptTokens, &stats.TotalCompletionTokens, &stats.TotalDurationMs,
+		&stats.ErrorSpans,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("querying trace stats for %s: %w", traceID, err)
@@ -623,6 +946,71 @@ func (s *DBService) GetTraceStats(traceID string) (*TraceStats, error) {
 	return stats, nil
 }
 
+// AggregateAgentStats totals span counts, errors and tokens per agent
+// over the traces matching filter. Limit and Offset are ignored. Agents
+// come back sorted by name, each with its LLM tokens broken down by
+// model so callers can price them.
+func (s *DBService) AggregateAgentStats(filter TraceFilter) ([]*AgentStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	where, args := traceFilterClauses(filter)
+
+	rows, err := s.db.Query(`
+		SELECT t.agent_name,
+			COUNT(DISTINCT t.trace_id),
+			COUNT(s.span_id),
+			COALESCE(SUM(CASE WHEN s.status = 'error' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(s.prompt_tokens), 0),
+			COALESCE(SUM(s.completion_tokens), 0)
+		FROM traces t LEFT JOIN spans s ON s.trace_id = t.trace_id
+		WHERE 1=1`+where+`
+		GROUP BY t.agent_name ORDER BY t.agent_name`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("aggregating agent stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []*AgentStats
+	byAgent := make(map[string]*AgentStats)
+	for rows.Next() {
+		st := &AgentStats{}
+		if err := rows.Scan(&st.AgentName, &st.TraceCount, &st.SpanCount, &st.ErrorCount,
+			&st.PromptTokens, &st.CompletionTokens); err != nil {
+			return nil, fmt.Errorf("scanning agent stats row: %w", err)
+		}
+		stats = append(stats, st)
+		byAgent[st.AgentName] = st
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query(`
+		SELECT t.agent_name, COALESCE(s.model, 'unknown'),
+			SUM(s.prompt_tokens), SUM(s.completion_tokens)
+		FROM traces t INNER JOIN spans s ON s.trace_id = t.trace_id
+		WHERE s.operation_type = 'LLM'`+where+`
+		GROUP BY t.agent_name, COALESCE(s.model, 'unknown')
+		ORDER BY t.agent_name, COALESCE(s.model, 'unknown')`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("aggregating agent model usage: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var agent string
+		var usage ModelUsage
+		if err := rows.Scan(&agent, &usage.Model, &usage.PromptTokens, &usage.CompletionTokens); err != nil {
+			return nil, fmt.Errorf("scanning model usage row: %w", err)
+		}
+		if st, ok := byAgent[agent]; ok {
+			st.Models = append(st.Models, usage)
+		}
+	}
+	return stats, rows.Err()
+}
+
 // WritePendingPayload stores a raw payload in the pending_writes table
 // for crash recovery. Returns the write ID for later commitment.
 func (s *DBService) WritePendingPayload(payload []byte) (int64, error) {
@@ -695,28 +1083,484 @@ func (s *DBService) Close() error {
 	return s.db.Close()
 }
 
+// ============================================================
+// Export / Import
+// ============================================================
+
+// ExportTrace reads a trace with all of its spans, memory events, tool
+// calls and tags so it can be written out and imported elsewhere.
+func (s *DBService) ExportTrace(traceID string) (*TraceExport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t := &Trace{}
+	var metadataStr *string
+	err := s.db.QueryRow(`
+		SELECT trace_id, agent_name, start_time, end_time, status, metadata
+		FROM traces WHERE trace_id = ?
+	`, traceID).Scan(&t.TraceID, &t.AgentName, &t.StartTime, &t.EndTime, &t.Status, &metadataStr)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("trace %s: %w", traceID, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("exporting trace %s: %w", traceID, err)
+	}
+	t.Metadata = decodeTraceMetadata(metadataStr)
+	exp := &TraceExport{Trace: t}
+
+	rows, err := s.db.Query(`
+		SELECT span_id, trace_id, parent_span_id, operation_type, operation_name,
+			start_time, duration_ms, prompt, completion, prompt_tokens, completion_tokens,
+			model, temperature, metadata, status, error_message
+		FROM spans
+		WHERE trace_id = ?
+		ORDER BY start_time ASC
+	`, traceID)
+	if err != nil {
+		return nil, fmt.Errorf("exporting spans for trace %s: %w", traceID, err)
+	}
+	exp.Spans, err = scanSpans(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query(`
+		SELECT me.event_id, me.span_id, me.timestamp, me.operation, me.key, me.old_value, me.new_value, me.namespace
+		FROM memory_events me
+		INNER JOIN spans s ON me.span_id = s.span_id
+		WHERE s.trace_id = ?
+		ORDER BY me.timestamp ASC
+	`, traceID)
+	if err != nil {
+		return nil, fmt.Errorf("exporting memory events for trace %s: %w", traceID, err)
+	}
+	exp.MemoryEvents, err = scanMemoryEvents(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query(`
+		SELECT tc.call_id, tc.span_id, tc.tool_name, tc.arguments_json, tc.result_json, tc.success, tc.latency_ms
+		FROM tool_calls tc
+		INNER JOIN spans s ON tc.span_id = s.span_id
+		WHERE s.trace_id = ?
+		ORDER BY tc.call_id ASC
+	`, traceID)
+	if err != nil {
+		return nil, fmt.Errorf("exporting tool calls for trace %s: %w", traceID, err)
+	}
+	exp.ToolCalls, err = scanToolCalls(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query(`SELECT tag FROM trace_tags WHERE trace_id = ? ORDER BY tag`, traceID)
+	if err != nil {
+		return nil, fmt.Errorf("exporting tags for trace %s: %w", traceID, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("scanning tag row: %w", err)
+		}
+		exp.Tags = append(exp.Tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return exp, nil
+}
+
+// ImportTrace inserts an exported trace and everything under it in a
+// single transaction. It refuses to overwrite a trace that already
+// exists. Tool calls are given new IDs.
+func (s *DBService) ImportTrace(exp *TraceExport) error {
+	if exp == nil || exp.Trace == nil || exp.Trace.TraceID == "" {
+		return fmt.Errorf("importing trace: export has no trace")
+	}
+	traceID := exp.Trace.TraceID
+	for _, span := range exp.Spans {
+		if span.TraceID != traceID {
+			return fmt.Errorf("importing trace %s: span %s belongs to trace %s", traceID, span.SpanID, span.TraceID)
+		}
+	}
+
+	var metadataJSON *string
+	if exp.Trace.Metadata != nil {
+		b, err := json.Marshal(exp.Trace.Metadata)
+		if err != nil {
+			return fmt.Errorf("marshaling trace metadata: %w", err)
+		}
+		str := string(b)
+		metadataJSON = &str
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning import transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM traces WHERE trace_id = ?`, traceID).Scan(&exists); err != nil {
+		return fmt.Errorf("checking for trace %s: %w", traceID, err)
+	}
+	if exists > 0 {
+		return fmt.Errorf("trace %s already exists", traceID)
+	}
+
+	t := exp.Trace
+	if _, err := tx.Stmt(s.stmtInsertTrace).Exec(
+		t.TraceID, t.AgentName, t.StartTime, t.EndTime, t.Status, metadataJSON,
+	); err != nil {
+		return fmt.Errorf("importing trace %s: %w", traceID, err)
+	}
+
+	stmt := tx.Stmt(s.stmtInsertSpan)
+	for _, span := range exp.Spans {
+		if _, err := stmt.Exec(
+			span.SpanID, span.TraceID, span.ParentSpanID, span.OperationType,
+			span.OperationName, span.StartTime, span.DurationMs,
+			span.Prompt, span.Completion, span.PromptTokens, span.CompletionTokens,
+			span.Model, span.Temperature, span.Metadata,
+			span.Status, span.ErrorMessage,
+		); err != nil {
+			return fmt.Errorf("importing span %s: %w", span.SpanID, err)
+		}
+	}
+
+	stmt = tx.Stmt(s.stmtInsertMemoryEvent)
+	for _, ev := range exp.MemoryEvents {
+		if _, err := stmt.Exec(
+			ev.EventID, ev.SpanID, ev.Timestamp,
+			ev.Operation, ev.Key, ev.OldValue, ev.NewValue,
+			ev.Namespace,
+		); err != nil {
+			return fmt.Errorf("importing memory event %s: %w", ev.EventID, err)
+		}
+	}
+
+	stmt = tx.Stmt(s.stmtInsertToolCall)
+	for _, c := range exp.ToolCalls {
+		if _, err := stmt.Exec(
+			c.SpanID, c.ToolName, c.ArgumentsJSON,
+			c.ResultJSON, c.Success, c.LatencyMs,
+		); err != nil {
+			return fmt.Errorf("importing tool call for span %s: %w", c.SpanID, err)
+		}
+	}
+
+	for _, tag := range exp.Tags {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO trace_tags (trace_id, tag) VALUES (?, ?)`, traceID, tag); err != nil {
+			return fmt.Errorf("importing tag %q: %w", tag, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing import of trace %s: %w", traceID, err)
+	}
+	return nil
+}
+
+// PruneTracesBefore deletes every trace that started before the given
+// Unix nanosecond cutoff, optionally only those from agentName. It is
+// DeleteTracesOlderThan with the count as an int64.
+func (s *DBService) PruneTracesBefore(before int64, agentName string) (int64, error) {
+	n, err := s.DeleteTracesOlderThan(before, agentName)
+	return int64(n), err
+}
+
+// DeleteTrace removes a trace with its spans, memory events, tool
+// calls and tags in a single transaction. Deleting the spans fires the
+// spans_ad trigger, which drops their rows from the full-text index.
+// A trace that does not exist is not an error.
+func (s *DBService) DeleteTrace(traceID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning delete transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Children go first so the delete does not depend on foreign key
+	// enforcement being on for this connection.
+	steps := []string{
+		`DELETE FROM tool_calls WHERE span_id IN (SELECT span_id FROM spans WHERE trace_id = ?)`,
+		`DELETE FROM memory_events WHERE span_id IN (SELECT span_id FROM spans WHERE trace_id = ?)`,
+		`DELETE FROM spans WHERE trace_id = ?`,
+		`DELETE FROM trace_tags WHERE trace_id = ?`,
+		`DELETE FROM traces WHERE trace_id = ?`,
+	}
+	for _, q := range steps {
+		if _, err := tx.Exec(q, traceID); err != nil {
+			return fmt.Errorf("deleting trace %s: %w", traceID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing delete of trace %s: %w", traceID, err)
+	}
+	return nil
+}
+
+// DeleteTracesOlderThan removes every trace that started before the
+// given Unix nanosecond cutoff, optionally only those from agentName,
+// and returns how many traces were removed. Like DeleteTrace it deletes
+// the spans, memory events, tool calls and tags itself in one
+// transaction rather than relying on cascades.
+func (s *DBService) DeleteTracesOlderThan(cutoffNano int64, agentName string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("beginning delete transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	where := `start_time < ?`
+	args := []interface{}{cutoffNano}
+	if agentName != "" {
+		where += ` AND agent_name = ?`
+		args = append(args, agentName)
+	}
+	old := `SELECT trace_id FROM traces WHERE ` + where
+
+	steps := []string{
+		`DELETE FROM tool_calls WHERE span_id IN (SELECT span_id FROM spans WHERE trace_id IN (` + old + `))`,
+		`DELETE FROM memory_events WHERE span_id IN (SELECT span_id FROM spans WHERE trace_id IN (` + old + `))`,
+		`DELETE FROM spans WHERE trace_id IN (` + old + `)`,
+		`DELETE FROM trace_tags WHERE trace_id IN (` + old + `)`,
+	}
+	for _, q := range steps {
+		if _, err := tx.Exec(q, args...); err != nil {
+			return 0, fmt.Errorf("deleting traces older than %d: %w", cutoffNano, err)
+		}
+	}
+
+	result, err := tx.Exec(`DELETE FROM traces WHERE `+where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("deleting traces older than %d: %w", cutoffNano, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("counting deleted traces: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("committing delete of old traces: %w", err)
+	}
+	return int(n), nil
+}
+
+// AddTraceTags labels a trace with the given tags. Tags it already
+// carries are left as they are.
+func (s *DBService) AddTraceTags(traceID string, tags []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning tag transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM traces WHERE trace_id = ?`, traceID).Scan(&exists); err != nil {
+		return fmt.Errorf("looking up trace %s: %w", traceID, err)
+	}
+	if exists == 0 {
+		return fmt.Errorf("trace %s not found", traceID)
+	}
+
+	for _, tag := range tags {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO trace_tags (trace_id, tag) VALUES (?, ?)`, traceID, tag); err != nil {
+			return fmt.Errorf("tagging trace %s with %q: %w", traceID, tag, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// RemoveTraceTags removes the given tags from a trace. Tags the trace
+// does not carry are ignored.
+func (s *DBService) RemoveTraceTags(traceID string, tags []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tag := range tags {
+		if _, err := s.db.Exec(`DELETE FROM trace_tags WHERE trace_id = ? AND tag = ?`, traceID, tag); err != nil {
+			return fmt.Errorf("untagging trace %s: %w", traceID, err)
+		}
+	}
+	return nil
+}
+
+// GetTraceTags returns a trace's tags in alphabetical order.
+func (s *DBService) GetTraceTags(traceID string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`SELECT tag FROM trace_tags WHERE trace_id = ? ORDER BY tag`, traceID)
+	if err != nil {
+		return nil, fmt.Errorf("querying tags for trace %s: %w", traceID, err)
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("scanning tag row: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// Maintain checkpoints the write-ahead log into the main database file
+// and rebuilds the file with VACUUM, returning pages freed by deletions
+// to the filesystem. VACUUM needs the database to itself, so run it
+// while nothing else is writing.
+func (s *DBService) Maintain() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.db.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		return fmt.Errorf("checkpointing WAL: %w", err)
+	}
+	if _, err := s.db.Exec(`VACUUM`); err != nil {
+		return fmt.Errorf("vacuuming database: %w", err)
+	}
+	// In WAL mode VACUUM writes the rebuilt pages to the WAL, so
+	// checkpoint again for the main file to shrink.
+	if _, err := s.db.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		return fmt.Errorf("checkpointing WAL after vacuum: %w", err)
+	}
+	return nil
+}
+
+// CheckIntegrity runs SQLite's integrity check, verifies the full-text
+// index against the spans table, and counts rows orphaned by writes
+// made without foreign key enforcement. Problems are reported in the
+// result; the error is only for checks that could not run.
+func (s *DBService) CheckIntegrity() (*IntegrityReport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	report := &IntegrityReport{}
+
+	rows, err := s.db.Query(`PRAGMA integrity_check`)
+	if err != nil {
+		return nil, fmt.Errorf("running integrity check: %w", err)
+	}
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scanning integrity check row: %w", err)
+		}
+		if msg != "ok" {
+			report.IntegrityErrors = append(report.IntegrityErrors, msg)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// With rank 1, FTS5 also compares the index with the spans it
+	// was built from.
+	if _, err := s.db.Exec(`INSERT INTO spans_fts(spans_fts, rank) VALUES('integrity-check', 1)`); err != nil {
+		report.FTSError = err.Error()
+	}
+
+	orphans := []struct {
+		dest  *int64
+		query string
+	}{
+		{&report.OrphanSpans, `SELECT COUNT(*) FROM spans WHERE trace_id NOT IN (SELECT trace_id FROM traces)`},
+		{&report.OrphanMemoryEvents, `SELECT COUNT(*) FROM memory_events WHERE span_id NOT IN (SELECT span_id FROM spans)`},
+		{&report.OrphanToolCalls, `SELECT COUNT(*) FROM tool_calls WHERE span_id NOT IN (SELECT span_id FROM spans)`},
+		{&report.OrphanTags, `SELECT COUNT(*) FROM trace_tags WHERE trace_id NOT IN (SELECT trace_id FROM traces)`},
+	}
+	for _, o := range orphans {
+		if err := s.db.QueryRow(o.query).Scan(o.dest); err != nil {
+			return nil, fmt.Errorf("counting orphaned rows: %w", err)
+		}
+	}
+
+	return report, nil
+}
+
 // ============================================================
 // Scan Helpers
 // ============================================================
 
 func scanSpans(rows *sql.Rows) ([]*Span, error) {
+	return scanSpanRows(rows, false)
+}
+
+// scanSpanRows scans span rows, optionally followed by a memory event
+// count column.
+func scanSpanRows(rows *sql.Rows, withEventCount bool) ([]*Span, error) {
 	var spans []*Span
 	for rows.Next() {
-		sp := &Span{}
-		if err := rows.Scan(
-			&sp.SpanID, &sp.TraceID, &sp.ParentSpanID, &sp.OperationType,
-			&sp.OperationName, &sp.StartTime, &sp.DurationMs,
-			&sp.Prompt, &sp.Completion, &sp.PromptTokens, &sp.CompletionTokens,
-			&sp.Model, &sp.Temperature, &sp.Metadata,
-			&sp.Status, &sp.ErrorMessage,
-		); err != nil {
-			return nil, fmt.Errorf("scanning span row: %w", err)
+		sp, err := scanSpanRow(rows, withEventCount)
+		if err != nil {
+			return nil, err
 		}
 		spans = append(spans, sp)
 	}
 	return spans, rows.Err()
 }
 
+// scanSpanRow scans the current row into a new span.
+func scanSpanRow(rows *sql.Rows, withEventCount bool) (*Span, error) {
+	sp := &Span{}
+	dest := spanScanDest(sp)
+	if withEventCount {
+		dest = append(dest, &sp.MemoryEventCount)
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, fmt.Errorf("scanning span row: %w", err)
+	}
+	return sp, nil
+}
+
+// spanScanDest returns scan destinations for the span columns, in the
+// order the span queries select them.
+func spanScanDest(sp *Span) []interface{} {
+	return []interface{}{
+		&sp.SpanID, &sp.TraceID, &sp.ParentSpanID, &sp.OperationType,
+		&sp.OperationName, &sp.StartTime, &sp.DurationMs,
+		&sp.Prompt, &sp.Completion, &sp.PromptTokens, &sp.CompletionTokens,
+		&sp.Model, &sp.Temperature, &sp.Metadata,
+		&sp.Status, &sp.ErrorMessage,
+	}
+}
+
+func scanToolCalls(rows *sql.Rows) ([]*ToolCall, error) {
+	var calls []*ToolCall
+	for rows.Next() {
+		c := &ToolCall{}
+		if err := rows.Scan(&c.CallID, &c.SpanID, &c.ToolName, &c.ArgumentsJSON,
+			&c.ResultJSON, &c.Success, &c.LatencyMs); err != nil {
+			return nil, fmt.Errorf("scanning tool call row: %w", err)
+		}
+		calls = append(calls, c)
+	}
+	return calls, rows.Err()
+}
+
 func scanMemoryEvents(rows *sql.Rows) ([]*MemoryEvent, error) {
 	var events []*MemoryEvent
 	for rows.Next() {
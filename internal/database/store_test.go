@@ -1,7 +1,9 @@
 package database
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -323,7 +325,7 @@ func TestGetTraceStats(t *testing.T) {
 	svc.InsertSpan(&Span{
 		SpanID: "ss-003", TraceID: "trace-stats",
 		OperationType: "TOOL", StartTime: now + 2000, DurationMs: 500,
-		Status: "ok",
+		Status: "error",
 	})
 	svc.InsertSpan(&Span{
 		SpanID: "ss-004", TraceID: "trace-stats",
@@ -354,6 +356,9 @@ func TestGetTraceStats(t *testing.T) {
 	if stats.TotalCompletionTokens != 150 {
 		t.Errorf("expected 150 completion tokens, got %d", stats.TotalCompletionTokens)
 	}
+	if stats.ErrorSpans != 1 {
+		t.Errorf("expected 1 error span, got %d", stats.ErrorSpans)
+	}
 }
 
 // TestPendingWrites verifies the crash recovery mechanism.
@@ -453,3 +458,743 @@ func BenchmarkBatchInsert(b *testing.B) {
 		}
 	}
 }
+
+// TestQueryTracesSpanCounts verifies that QueryTraces reports each
+// trace's span count and token total, including traces with no spans.
+func TestQueryTracesSpanCounts(t *testing.T) {
+	svc, err := NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer svc.Close()
+
+	now := time.Now().UnixNano()
+	svc.InsertTrace(&Trace{TraceID: "busy", AgentName: "a", StartTime: now, Status: "completed"})
+	svc.InsertTrace(&Trace{TraceID: "empty", AgentName: "a", StartTime: now - 1000, Status: "running"})
+	for i := 0; i < 3; i++ {
+		svc.InsertSpan(&Span{
+			SpanID: fmt.Sprintf("busy-%d", i), TraceID: "busy",
+			OperationType: "LLM", StartTime: now + int64(i), DurationMs: 10,
+			PromptTokens: 10, CompletionTokens: 5, Status: "ok",
+		})
+	}
+
+	traces, err := svc.QueryTraces(TraceFilter{Limit: 10})
+	if err != nil {
+		t.Fatalf("QueryTraces failed: %v", err)
+	}
+	if len(traces) != 2 {
+		t.Fatalf("expected 2 traces, got %d", len(traces))
+	}
+	if traces[0].TraceID != "busy" || traces[0].SpanCount != 3 || traces[0].TotalTokens != 45 {
+		t.Errorf("expected busy with 3 spans and 45 tokens, got %s %d %d",
+			traces[0].TraceID, traces[0].SpanCount, traces[0].TotalTokens)
+	}
+	if traces[1].SpanCount != 0 || traces[1].TotalTokens != 0 {
+		t.Errorf("expected empty trace with no spans, got %d %d",
+			traces[1].SpanCount, traces[1].TotalTokens)
+	}
+}
+
+// TestQueryTimelineMemoryEventCounts verifies that QueryTimeline reports
+// how many memory events each span has.
+func TestQueryTimelineMemoryEventCounts(t *testing.T) {
+	svc, err := NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer svc.Close()
+
+	now := time.Now().UnixNano()
+	svc.InsertTrace(&Trace{TraceID: "trace-mem", AgentName: "a", StartTime: now, Status: "completed"})
+	svc.InsertSpan(&Span{SpanID: "writer", TraceID: "trace-mem", OperationType: "MEMORY", StartTime: now, Status: "ok"})
+	svc.InsertSpan(&Span{SpanID: "reader", TraceID: "trace-mem", OperationType: "LLM", StartTime: now + 1, Status: "ok"})
+
+	val := "v"
+	for i := 0; i < 3; i++ {
+		if err := svc.InsertMemoryEvent(&MemoryEvent{
+			EventID: fmt.Sprintf("evt-%d", i), SpanID: "writer", Timestamp: now + int64(i),
+			Operation: "ADD", Key: fmt.Sprintf("k%d", i), NewValue: &val, Namespace: "default",
+		}); err != nil {
+			t.Fatalf("InsertMemoryEvent failed: %v", err)
+		}
+	}
+
+	spans, err := svc.QueryTimeline("trace-mem")
+	if err != nil {
+		t.Fatalf("QueryTimeline failed: %v", err)
+	}
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].MemoryEventCount != 3 || spans[1].MemoryEventCount != 0 {
+		t.Errorf("expected counts 3 and 0, got %d and %d",
+			spans[0].MemoryEventCount, spans[1].MemoryEventCount)
+	}
+}
+
+// TestExportImportTrace verifies that an exported trace, tags included,
+// imports into a fresh database unchanged, and that import refuses
+// existing traces.
+func TestExportImportTrace(t *testing.T) {
+	src, err := NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer src.Close()
+
+	val := `{"x":1}`
+	src.InsertTrace(&Trace{TraceID: "t1", AgentName: "a", StartTime: 1, Status: "completed"})
+	src.InsertSpan(&Span{SpanID: "s1", TraceID: "t1", OperationType: "TOOL", StartTime: 2, Status: "ok"})
+	src.InsertMemoryEvent(&MemoryEvent{EventID: "e1", SpanID: "s1", Timestamp: 3, Operation: "ADD", Key: "k", NewValue: &val, Namespace: "default"})
+	src.InsertToolCall(&ToolCall{SpanID: "s1", ToolName: "search", Success: true})
+	src.AddTraceTags("t1", []string{"regression", "ci"})
+
+	exp, err := src.ExportTrace("t1")
+	if err != nil {
+		t.Fatalf("ExportTrace failed: %v", err)
+	}
+	if len(exp.Spans) != 1 || len(exp.MemoryEvents) != 1 || len(exp.ToolCalls) != 1 {
+		t.Fatalf("expected 1 span, event and tool call, got %d %d %d",
+			len(exp.Spans), len(exp.MemoryEvents), len(exp.ToolCalls))
+	}
+	if !reflect.DeepEqual(exp.Tags, []string{"ci", "regression"}) {
+		t.Errorf("expected the trace's tags exported, got %v", exp.Tags)
+	}
+
+	dst, err := NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer dst.Close()
+	if err := dst.ImportTrace(exp); err != nil {
+		t.Fatalf("ImportTrace failed: %v", err)
+	}
+	if stats, _ := dst.GetTraceStats("t1"); stats.TotalSpans != 1 || stats.MemoryEventCount != 1 {
+		t.Errorf("expected the imported trace to have 1 span and 1 event, got %+v", stats)
+	}
+	if tags, _ := dst.GetTraceTags("t1"); !reflect.DeepEqual(tags, exp.Tags) {
+		t.Errorf("expected the imported trace tagged %v, got %v", exp.Tags, tags)
+	}
+	if err := dst.ImportTrace(exp); err == nil {
+		t.Error("expected importing an existing trace to fail")
+	}
+	if _, err := src.ExportTrace("missing"); err == nil {
+		t.Error("expected an error exporting a missing trace")
+	}
+}
+
+// TestPruneTracesBefore verifies that pruning removes only traces older
+// than the cutoff, and their spans with them.
+func TestPruneTracesBefore(t *testing.T) {
+	svc, err := NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer svc.Close()
+
+	for i, agent := range []string{"a", "b", "a"} {
+		id := fmt.Sprintf("t%d", i)
+		svc.InsertTrace(&Trace{TraceID: id, AgentName: agent, StartTime: int64(i * 100), Status: "completed"})
+		svc.InsertSpan(&Span{SpanID: id + "-s", TraceID: id, OperationType: "LLM", StartTime: int64(i * 100), Status: "ok"})
+	}
+
+	// t0 (a) and t1 (b) are older than 150; scoping to "a" leaves t1
+	n, err := svc.PruneTracesBefore(150, "a")
+	if err != nil {
+		t.Fatalf("PruneTracesBefore failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 trace pruned, got %d", n)
+	}
+	if spans, _ := svc.QueryTimeline("t0"); len(spans) != 0 {
+		t.Errorf("expected t0's spans to be pruned too, got %d", len(spans))
+	}
+
+	if n, _ := svc.PruneTracesBefore(150, ""); n != 1 {
+		t.Errorf("expected t1 pruned without an agent scope, got %d", n)
+	}
+	traces, _ := svc.QueryTraces(TraceFilter{})
+	if len(traces) != 1 || traces[0].TraceID != "t2" {
+		t.Errorf("expected only t2 to remain, got %v", traces)
+	}
+}
+
+// TestPruneTracesBeforeWithoutForeignKeys verifies the agent-scoped
+// prune path removes every child row of the traces it deletes, and only
+// theirs, when foreign key enforcement is off.
+func TestPruneTracesBeforeWithoutForeignKeys(t *testing.T) {
+	svc, err := NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer svc.Close()
+
+	if _, err := svc.db.Exec(`PRAGMA foreign_keys = OFF`); err != nil {
+		t.Fatalf("disabling foreign keys failed: %v", err)
+	}
+
+	// "old" matches the cutoff and agent; "other" is as old but from
+	// another agent, and "new" is past the cutoff
+	prompt, value := "transformer architecture", "x"
+	for _, tr := range []Trace{
+		{TraceID: "old", AgentName: "a", StartTime: 0},
+		{TraceID: "other", AgentName: "b", StartTime: 0},
+		{TraceID: "new", AgentName: "a", StartTime: 200},
+	} {
+		id := tr.TraceID
+		tr.Status = "completed"
+		svc.InsertTrace(&tr)
+		svc.InsertSpan(&Span{SpanID: id + "-s", TraceID: id, OperationType: "LLM", Prompt: &prompt, Status: "ok"})
+		svc.InsertMemoryEvent(&MemoryEvent{EventID: id + "-e", SpanID: id + "-s", Operation: "ADD", Key: "k", NewValue: &value, Namespace: "default"})
+		svc.InsertToolCall(&ToolCall{SpanID: id + "-s", ToolName: "search", Success: true})
+		svc.AddTraceTags(id, []string{"ci"})
+	}
+
+	n, err := svc.PruneTracesBefore(100, "a")
+	if err != nil {
+		t.Fatalf("PruneTracesBefore failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 trace pruned, got %d", n)
+	}
+
+	for table, want := range map[string]int{"traces": 2, "spans": 2, "memory_events": 2, "tool_calls": 2, "trace_tags": 2} {
+		var n int
+		svc.db.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&n)
+		if n != want {
+			t.Errorf("expected %d rows left in %s, got %d", want, table, n)
+		}
+	}
+	results, err := svc.SearchContent("transformer", 10)
+	if err != nil {
+		t.Fatalf("SearchContent failed: %v", err)
+	}
+	for _, r := range results {
+		if r.TraceID == "old" {
+			t.Errorf("expected the pruned trace's span gone from search, got %v", r.SpanID)
+		}
+	}
+	if len(results) != 2 {
+		t.Errorf("expected 2 search hits left, got %d", len(results))
+	}
+	report, _ := svc.CheckIntegrity()
+	if !report.OK() {
+		t.Errorf("expected a consistent database after prune, got %+v", report)
+	}
+}
+
+// TestTraceFilterByMetadata verifies that MetadataMatch keeps only
+// traces whose metadata has every requested key and value.
+func TestTraceFilterByMetadata(t *testing.T) {
+	svc, err := NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer svc.Close()
+
+	svc.InsertTrace(&Trace{TraceID: "prod-eu", AgentName: "a", StartTime: 1, Status: "completed",
+		Metadata: map[string]string{"env": "prod", "region": "eu"}})
+	svc.InsertTrace(&Trace{TraceID: "prod-us", AgentName: "a", StartTime: 2, Status: "completed",
+		Metadata: map[string]string{"env": "prod", "region": "us"}})
+	svc.InsertTrace(&Trace{TraceID: "dev", AgentName: "a", StartTime: 3, Status: "completed",
+		Metadata: map[string]string{"env": "dev"}})
+	svc.InsertTrace(&Trace{TraceID: "bare", AgentName: "a", StartTime: 4, Status: "completed"})
+
+	ids := func(match map[string]string) []string {
+		traces, err := svc.QueryTraces(TraceFilter{MetadataMatch: match})
+		if err != nil {
+			t.Fatalf("QueryTraces failed: %v", err)
+		}
+		var out []string
+		for _, tr := range traces {
+			out = append(out, tr.TraceID)
+		}
+		return out
+	}
+
+	if got := ids(map[string]string{"env": "prod"}); len(got) != 2 {
+		t.Errorf("expected both prod traces, got %v", got)
+	}
+	if got := ids(map[string]string{"env": "prod", "region": "us"}); len(got) != 1 || got[0] != "prod-us" {
+		t.Errorf("expected keys to AND together, got %v", got)
+	}
+	if got := ids(map[string]string{"env": "staging"}); len(got) != 0 {
+		t.Errorf("expected no match, got %v", got)
+	}
+}
+
+// TestAggregateAgentStats verifies per-agent totals and the per-model
+// token breakdown, and that the trace filter applies.
+func TestAggregateAgentStats(t *testing.T) {
+	svc, err := NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer svc.Close()
+
+	gpt, claude := "gpt-4o", "claude-3-haiku"
+	svc.InsertTrace(&Trace{TraceID: "t1", AgentName: "b", StartTime: 100, Status: "completed"})
+	svc.InsertTrace(&Trace{TraceID: "t2", AgentName: "a", StartTime: 200, Status: "failed"})
+	svc.InsertSpan(&Span{SpanID: "s1", TraceID: "t1", OperationType: "LLM", Model: &gpt, PromptTokens: 10, CompletionTokens: 5, Status: "ok"})
+	svc.InsertSpan(&Span{SpanID: "s2", TraceID: "t1", OperationType: "LLM", Model: &claude, PromptTokens: 20, CompletionTokens: 1, Status: "error"})
+	svc.InsertSpan(&Span{SpanID: "s3", TraceID: "t1", OperationType: "TOOL", Status: "ok"})
+	svc.InsertSpan(&Span{SpanID: "s4", TraceID: "t2", OperationType: "LLM", PromptTokens: 7, Status: "ok"})
+
+	stats, err := svc.AggregateAgentStats(TraceFilter{})
+	if err != nil {
+		t.Fatalf("AggregateAgentStats failed: %v", err)
+	}
+	if len(stats) != 2 || stats[0].AgentName != "a" || stats[1].AgentName != "b" {
+		t.Fatalf("expected agents a and b in order, got %+v", stats)
+	}
+	b := stats[1]
+	if b.TraceCount != 1 || b.SpanCount != 3 || b.ErrorCount != 1 || b.PromptTokens != 30 || b.CompletionTokens != 6 {
+		t.Errorf("unexpected totals for b: %+v", b)
+	}
+	if len(b.Models) != 2 || b.Models[0].Model != claude || b.Models[1].PromptTokens != 10 {
+		t.Errorf("unexpected model breakdown for b: %+v", b.Models)
+	}
+	if len(stats[0].Models) != 1 || stats[0].Models[0].Model != "unknown" {
+		t.Errorf("expected a's unnamed model to be reported as unknown, got %+v", stats[0].Models)
+	}
+
+	since := int64(150)
+	stats, _ = svc.AggregateAgentStats(TraceFilter{Since: &since})
+	if len(stats) != 1 || stats[0].AgentName != "a" {
+		t.Errorf("expected only a since 150, got %+v", stats)
+	}
+}
+
+// TestTraceTags verifies adding, listing, filtering by and removing
+// trace tags.
+func TestTraceTags(t *testing.T) {
+	svc, err := NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer svc.Close()
+
+	svc.InsertTrace(&Trace{TraceID: "t1", AgentName: "a", StartTime: 100, Status: "completed"})
+	svc.InsertTrace(&Trace{TraceID: "t2", AgentName: "a", StartTime: 200, Status: "completed"})
+
+	if err := svc.AddTraceTags("t1", []string{"nightly", "regression", "nightly"}); err != nil {
+		t.Fatalf("AddTraceTags failed: %v", err)
+	}
+	if err := svc.AddTraceTags("missing", []string{"x"}); err == nil {
+		t.Error("expected tagging an unknown trace to fail")
+	}
+
+	tags, err := svc.GetTraceTags("t1")
+	if err != nil {
+		t.Fatalf("GetTraceTags failed: %v", err)
+	}
+	if len(tags) != 2 || tags[0] != "nightly" || tags[1] != "regression" {
+		t.Errorf("expected [nightly regression], got %v", tags)
+	}
+
+	tag := "regression"
+	traces, _ := svc.QueryTraces(TraceFilter{Tag: &tag})
+	if len(traces) != 1 || traces[0].TraceID != "t1" {
+		t.Errorf("expected only t1 tagged regression, got %v", traces)
+	}
+
+	if err := svc.RemoveTraceTags("t1", []string{"regression"}); err != nil {
+		t.Fatalf("RemoveTraceTags failed: %v", err)
+	}
+	if tags, _ := svc.GetTraceTags("t1"); len(tags) != 1 || tags[0] != "nightly" {
+		t.Errorf("expected only nightly to remain, got %v", tags)
+	}
+}
+
+// TestCheckIntegrity verifies a clean database passes, and that a span
+// written with foreign keys off is counted as an orphan.
+func TestCheckIntegrity(t *testing.T) {
+	svc, err := NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer svc.Close()
+
+	prompt := "hello"
+	svc.InsertTrace(&Trace{TraceID: "t1", AgentName: "a", StartTime: 100, Status: "completed"})
+	svc.InsertSpan(&Span{SpanID: "s1", TraceID: "t1", OperationType: "LLM", Prompt: &prompt, Status: "ok"})
+
+	report, err := svc.CheckIntegrity()
+	if err != nil {
+		t.Fatalf("CheckIntegrity failed: %v", err)
+	}
+	if !report.OK() {
+		t.Errorf("expected a clean database, got %+v", report)
+	}
+
+	svc.db.Exec(`PRAGMA foreign_keys = OFF`)
+	if _, err := svc.db.Exec(`INSERT INTO spans (span_id, trace_id, operation_type, start_time) VALUES ('s2', 'gone', 'LLM', 1)`); err != nil {
+		t.Fatalf("inserting orphan: %v", err)
+	}
+	report, _ = svc.CheckIntegrity()
+	if report.OK() || report.OrphanSpans != 1 {
+		t.Errorf("expected one orphaned span, got %+v", report)
+	}
+}
+
+// TestDeleteTrace verifies a trace is removed with all its children,
+// including its full-text index entries, and that deleting again is a
+// no-op.
+func TestDeleteTrace(t *testing.T) {
+	svc, err := NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer svc.Close()
+
+	prompt, value := "transformer architecture", "x"
+	for _, id := range []string{"t1", "t2"} {
+		svc.InsertTrace(&Trace{TraceID: id, AgentName: "a", StartTime: 100, Status: "completed"})
+		svc.InsertSpan(&Span{SpanID: id + "-s", TraceID: id, OperationType: "LLM", Prompt: &prompt, Status: "ok"})
+		svc.InsertMemoryEvent(&MemoryEvent{EventID: id + "-e", SpanID: id + "-s", Operation: "ADD", Key: "k", NewValue: &value, Namespace: "default"})
+		svc.InsertToolCall(&ToolCall{SpanID: id + "-s", ToolName: "search", Success: true})
+	}
+	svc.AddTraceTags("t1", []string{"ci"})
+
+	if err := svc.DeleteTrace("t1"); err != nil {
+		t.Fatalf("DeleteTrace failed: %v", err)
+	}
+	if err := svc.DeleteTrace("t1"); err != nil {
+		t.Errorf("expected deleting a missing trace to succeed, got %v", err)
+	}
+
+	for table, want := range map[string]int{"traces": 1, "spans": 1, "memory_events": 1, "tool_calls": 1, "trace_tags": 0} {
+		var n int
+		svc.db.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&n)
+		if n != want {
+			t.Errorf("expected %d rows left in %s, got %d", want, table, n)
+		}
+	}
+	results, err := svc.SearchContent("transformer", 10)
+	if err != nil {
+		t.Fatalf("SearchContent failed: %v", err)
+	}
+	if len(results) != 1 || results[0].TraceID != "t2" {
+		t.Errorf("expected search to find only t2's span, got %v", results)
+	}
+	report, _ := svc.CheckIntegrity()
+	if !report.OK() {
+		t.Errorf("expected a consistent database after delete, got %+v", report)
+	}
+}
+
+// TestDeleteTracesOlderThan verifies the retention cutoff and count.
+func TestDeleteTracesOlderThan(t *testing.T) {
+	svc, err := NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer svc.Close()
+
+	for i, agent := range []string{"a", "b", "a"} {
+		svc.InsertTrace(&Trace{TraceID: fmt.Sprintf("t%d", i), AgentName: agent, StartTime: int64(i * 100), Status: "completed"})
+	}
+	n, err := svc.DeleteTracesOlderThan(150, "")
+	if err != nil {
+		t.Fatalf("DeleteTracesOlderThan failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 traces removed, got %d", n)
+	}
+	if traces, _ := svc.QueryTraces(TraceFilter{}); len(traces) != 1 || traces[0].TraceID != "t2" {
+		t.Errorf("expected only t2 to remain, got %v", traces)
+	}
+}
+
+// TestDeleteTracesOlderThanWithoutForeignKeys verifies the retention
+// delete removes every child row itself, so nothing survives when
+// foreign key enforcement is off for the connection.
+func TestDeleteTracesOlderThanWithoutForeignKeys(t *testing.T) {
+	svc, err := NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer svc.Close()
+
+	if _, err := svc.db.Exec(`PRAGMA foreign_keys = OFF`); err != nil {
+		t.Fatalf("disabling foreign keys failed: %v", err)
+	}
+
+	prompt, value := "transformer architecture", "x"
+	for i, id := range []string{"old", "new"} {
+		svc.InsertTrace(&Trace{TraceID: id, AgentName: "a", StartTime: int64(i * 200), Status: "completed"})
+		svc.InsertSpan(&Span{SpanID: id + "-s", TraceID: id, OperationType: "LLM", Prompt: &prompt, Status: "ok"})
+		svc.InsertMemoryEvent(&MemoryEvent{EventID: id + "-e", SpanID: id + "-s", Operation: "ADD", Key: "k", NewValue: &value, Namespace: "default"})
+		svc.InsertToolCall(&ToolCall{SpanID: id + "-s", ToolName: "search", Success: true})
+		svc.AddTraceTags(id, []string{"ci"})
+	}
+
+	n, err := svc.DeleteTracesOlderThan(100, "")
+	if err != nil {
+		t.Fatalf("DeleteTracesOlderThan failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 trace removed, got %d", n)
+	}
+
+	for table, want := range map[string]int{"traces": 1, "spans": 1, "memory_events": 1, "tool_calls": 1, "trace_tags": 1} {
+		var n int
+		svc.db.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&n)
+		if n != want {
+			t.Errorf("expected %d rows left in %s, got %d", want, table, n)
+		}
+	}
+	results, err := svc.SearchContent("transformer", 10)
+	if err != nil {
+		t.Fatalf("SearchContent failed: %v", err)
+	}
+	if len(results) != 1 || results[0].TraceID != "new" {
+		t.Errorf("expected search to find only the new trace's span, got %v", results)
+	}
+	report, _ := svc.CheckIntegrity()
+	if !report.OK() {
+		t.Errorf("expected a consistent database after delete, got %+v", report)
+	}
+}
+
+// TestQueryTracesKeysetPagination verifies paging with BeforeStartTime
+// and BeforeTraceID visits every trace once, even across equal start
+// times, and that it takes precedence over Offset.
+func TestQueryTracesKeysetPagination(t *testing.T) {
+	svc, err := NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer svc.Close()
+
+	starts := map[string]int64{"a": 100, "b": 200, "c": 200, "d": 200, "e": 300}
+	for id, start := range starts {
+		svc.InsertTrace(&Trace{TraceID: id, AgentName: "x", StartTime: start, Status: "completed"})
+	}
+
+	var seen []string
+	filter := TraceFilter{Limit: 2}
+	for page := 0; page < 10; page++ {
+		traces, err := svc.QueryTraces(filter)
+		if err != nil {
+			t.Fatalf("QueryTraces failed: %v", err)
+		}
+		if len(traces) == 0 {
+			break
+		}
+		for _, tr := range traces {
+			seen = append(seen, tr.TraceID)
+		}
+		last := traces[len(traces)-1]
+		filter.BeforeStartTime = &last.StartTime
+		filter.BeforeTraceID = &last.TraceID
+		filter.Offset = 50 // ignored once a cursor is set
+	}
+	if got := fmt.Sprint(seen); got != "[e d c b a]" {
+		t.Errorf("expected [e d c b a] across pages, got %s", got)
+	}
+
+	// Without a trace ID the cursor is strictly before the time.
+	before := int64(300)
+	traces, _ := svc.QueryTraces(TraceFilter{BeforeStartTime: &before})
+	if len(traces) != 4 || traces[0].TraceID != "d" {
+		t.Errorf("expected d,c,b,a before 300, got %v", traces)
+	}
+}
+
+// TestQueryTimelineFunc verifies spans are streamed in order and that
+// an error from the callback stops the iteration and is returned.
+func TestQueryTimelineFunc(t *testing.T) {
+	svc, err := NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer svc.Close()
+
+	svc.InsertTrace(&Trace{TraceID: "t1", AgentName: "a", StartTime: 0, Status: "running"})
+	for i := 3; i >= 1; i-- {
+		svc.InsertSpan(&Span{SpanID: fmt.Sprintf("s%d", i), TraceID: "t1", OperationType: "TOOL", StartTime: int64(i), Status: "ok"})
+	}
+
+	var ids []string
+	if err := svc.QueryTimelineFunc("t1", func(sp *Span) error {
+		ids = append(ids, sp.SpanID)
+		return nil
+	}); err != nil {
+		t.Fatalf("QueryTimelineFunc failed: %v", err)
+	}
+	if fmt.Sprint(ids) != "[s1 s2 s3]" {
+		t.Errorf("expected spans in start order, got %v", ids)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = svc.QueryTimelineFunc("t1", func(sp *Span) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("expected the callback's error after one call, got %v after %d", err, calls)
+	}
+
+	// The store stays usable after an early stop.
+	if spans, err := svc.QueryTimeline("t1"); err != nil || len(spans) != 3 {
+		t.Errorf("expected QueryTimeline to return 3 spans, got %d (%v)", len(spans), err)
+	}
+}
+
+// TestSearchContentInTrace verifies search can be scoped to one trace.
+func TestSearchContentInTrace(t *testing.T) {
+	svc, err := NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer svc.Close()
+
+	strong, weak := "retry retry retry the request", "one retry"
+	for _, id := range []string{"t1", "t2"} {
+		svc.InsertTrace(&Trace{TraceID: id, AgentName: "a", StartTime: 0, Status: "running"})
+		svc.InsertSpan(&Span{SpanID: id + "-weak", TraceID: id, OperationType: "LLM", Prompt: &weak, Status: "ok"})
+		svc.InsertSpan(&Span{SpanID: id + "-strong", TraceID: id, OperationType: "LLM", Prompt: &strong, Status: "ok"})
+	}
+
+	if all, _ := svc.SearchContent("retry", 10); len(all) != 4 {
+		t.Fatalf("expected 4 matches across traces, got %d", len(all))
+	}
+
+	results, err := svc.SearchContentInTrace("t2", "retry", 10)
+	if err != nil {
+		t.Fatalf("SearchContentInTrace failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 matches in t2, got %d", len(results))
+	}
+	if results[0].SpanID != "t2-strong" || results[1].SpanID != "t2-weak" {
+		t.Errorf("expected BM25 order within the trace, got %s, %s", results[0].SpanID, results[1].SpanID)
+	}
+}
+
+// TestSearchContentScored verifies BM25 scores come back with the
+// matches, best (lowest) first.
+func TestSearchContentScored(t *testing.T) {
+	svc, err := NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer svc.Close()
+
+	strong, weak := "retry retry retry", "one retry among many other unrelated words in this prompt"
+	svc.InsertTrace(&Trace{TraceID: "t1", AgentName: "a", StartTime: 0, Status: "running"})
+	svc.InsertSpan(&Span{SpanID: "weak", TraceID: "t1", OperationType: "LLM", Prompt: &weak, Status: "ok"})
+	svc.InsertSpan(&Span{SpanID: "strong", TraceID: "t1", OperationType: "LLM", Prompt: &strong, Status: "ok"})
+
+	results, err := svc.SearchContentScored("retry", 10)
+	if err != nil {
+		t.Fatalf("SearchContentScored failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].SpanID != "strong" || results[1].SpanID != "weak" {
+		t.Errorf("expected strong before weak, got %s, %s", results[0].SpanID, results[1].SpanID)
+	}
+	if !(results[0].Score < results[1].Score && results[1].Score < 0) {
+		t.Errorf("expected negative scores, lower for the stronger match, got %f and %f", results[0].Score, results[1].Score)
+	}
+}
+
+// TestGetSpan verifies a single span can be fetched by ID and that a
+// missing one reports ErrNotFound.
+func TestGetSpan(t *testing.T) {
+	svc, err := NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer svc.Close()
+
+	model, value := "gpt-4o", "v"
+	svc.InsertTrace(&Trace{TraceID: "t1", AgentName: "a", StartTime: 0, Status: "running"})
+	svc.InsertSpan(&Span{SpanID: "s1", TraceID: "t1", OperationType: "LLM", OperationName: "chat",
+		DurationMs: 42, Model: &model, PromptTokens: 7, Status: "ok"})
+	svc.InsertMemoryEvent(&MemoryEvent{EventID: "e1", SpanID: "s1", Operation: "ADD", Key: "k", NewValue: &value, Namespace: "default"})
+
+	sp, err := svc.GetSpan("s1")
+	if err != nil {
+		t.Fatalf("GetSpan failed: %v", err)
+	}
+	if sp.TraceID != "t1" || sp.OperationName != "chat" || sp.DurationMs != 42 || *sp.Model != model ||
+		sp.PromptTokens != 7 || sp.MemoryEventCount != 1 {
+		t.Errorf("unexpected span: %+v", sp)
+	}
+
+	if _, err := svc.GetSpan("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// TestGetTrace verifies that a single trace comes back with its metadata
+// and span totals, and that a missing one yields ErrNotFound.
+func TestGetTrace(t *testing.T) {
+	svc, err := NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer svc.Close()
+
+	svc.InsertTrace(&Trace{TraceID: "t1", AgentName: "a", StartTime: 5, Status: "completed",
+		Metadata: map[string]string{"env": "prod"}})
+	svc.InsertSpan(&Span{SpanID: "s1", TraceID: "t1", OperationType: "LLM", OperationName: "chat",
+		PromptTokens: 10, CompletionTokens: 5, Status: "ok"})
+	svc.InsertSpan(&Span{SpanID: "s2", TraceID: "t1", OperationType: "TOOL", OperationName: "search", Status: "ok"})
+
+	tr, err := svc.GetTrace("t1")
+	if err != nil {
+		t.Fatalf("GetTrace failed: %v", err)
+	}
+	if tr.AgentName != "a" || tr.StartTime != 5 || tr.Status != "completed" ||
+		tr.Metadata["env"] != "prod" || tr.SpanCount != 2 || tr.TotalTokens != 15 {
+		t.Errorf("unexpected trace: %+v", tr)
+	}
+
+	if _, err := svc.GetTrace("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := svc.ExportTrace("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ExportTrace to wrap ErrNotFound, got %v", err)
+	}
+}
+
+// TestGetToolCalls verifies that a span's tool calls come back in
+// invocation order and that other spans' calls are left out.
+func TestGetToolCalls(t *testing.T) {
+	svc, err := NewDBService(":memory:")
+	if err != nil {
+		t.Fatalf("NewDBService failed: %v", err)
+	}
+	defer svc.Close()
+
+	args := `{"q":"weather"}`
+	svc.InsertTrace(&Trace{TraceID: "t1", AgentName: "a", Status: "running"})
+	svc.InsertSpan(&Span{SpanID: "s1", TraceID: "t1", OperationType: "TOOL", OperationName: "tools", Status: "ok"})
+	svc.InsertSpan(&Span{SpanID: "s2", TraceID: "t1", OperationType: "TOOL", OperationName: "other", Status: "ok"})
+	svc.InsertToolCall(&ToolCall{SpanID: "s1", ToolName: "search", ArgumentsJSON: &args, Success: true, LatencyMs: 12})
+	svc.InsertToolCall(&ToolCall{SpanID: "s2", ToolName: "unrelated", Success: true})
+	svc.InsertToolCall(&ToolCall{SpanID: "s1", ToolName: "fetch", Success: false, LatencyMs: 30})
+
+	calls, err := svc.GetToolCalls("s1")
+	if err != nil {
+		t.Fatalf("GetToolCalls failed: %v", err)
+	}
+	if len(calls) != 2 || calls[0].ToolName != "search" || calls[1].ToolName != "fetch" {
+		t.Fatalf("expected search then fetch, got %+v", calls)
+	}
+	if calls[0].CallID >= calls[1].CallID || *calls[0].ArgumentsJSON != args || !calls[0].Success ||
+		calls[0].LatencyMs != 12 || calls[1].Success {
+		t.Errorf("unexpected tool calls: %+v, %+v", calls[0], calls[1])
+	}
+
+	if calls, err := svc.GetToolCalls("missing"); err != nil || len(calls) != 0 {
+		t.Errorf("expected no calls for an unknown span, got %v, %v", calls, err)
+	}
+}
@@ -0,0 +1,227 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Mr-Dark-debug/oculo/internal/analysis"
+	"github.com/Mr-Dark-debug/oculo/pkg/timeutil"
+)
+
+// analysisRow is a single line in the analysis overlay. Rows that refer
+// to a span carry its ID so the user can jump to it in the timeline.
+type analysisRow struct {
+	text   string
+	spanID string
+	style  int
+}
+
+// Row styles for the analysis overlay.
+const (
+	rowPlain = iota
+	rowSection
+	rowWarning
+)
+
+// buildAnalysisRows flattens an analysis report into display rows.
+func buildAnalysisRows(r *analysis.AnalysisReport) []analysisRow {
+	if r == nil {
+		return nil
+	}
+
+	var rows []analysisRow
+	section := func(title string) {
+		if len(rows) > 0 {
+			rows = append(rows, analysisRow{})
+		}
+		rows = append(rows, analysisRow{text: title, style: rowSection})
+	}
+
+	// ── Health ──
+
+	score, penalties := healthScore(r)
+	section(fmt.Sprintf("Health  %d/100", score))
+	if len(penalties) == 0 {
+		rows = append(rows, analysisRow{text: "No problems found."})
+	}
+	for _, p := range penalties {
+		rows = append(rows, analysisRow{text: fmt.Sprintf("-%-3d %s", p.points, p.reason)})
+	}
+
+	// ── Warnings ──
+
+	if len(r.Warnings) > 0 {
+		section("Warnings")
+		for _, w := range r.Warnings {
+			rows = append(rows, analysisRow{text: w, style: rowWarning})
+		}
+	}
+
+	// ── Token hotspots ──
+
+	section("Token Hotspots")
+	if len(r.TokenHotspots) == 0 {
+		rows = append(rows, analysisRow{text: "No hotspots detected."})
+	}
+	for _, h := range r.TokenHotspots {
+		rows = append(rows, analysisRow{
+			text: fmt.Sprintf("%-6s z=%-5.2f %6d tok  %s",
+				h.Severity, h.ZScore, h.TotalTokens, h.OperationName),
+			spanID: h.SpanID,
+		})
+	}
+
+	// ── Latency hotspots ──
+
+	if len(r.LatencyHotspots) > 0 {
+		section("Latency Hotspots")
+		for _, h := range r.LatencyHotspots {
+			rows = append(rows, analysisRow{
+				text: fmt.Sprintf("%-6s z=%-5.2f %8s  %s",
+					h.Severity, h.ZScore, timeutil.FormatDuration(h.DurationMs), h.OperationName),
+				spanID: h.SpanID,
+			})
+		}
+	}
+
+	// ── Memory growth ──
+
+	if mg := r.MemoryGrowth; mg != nil {
+		section("Memory Growth")
+		rows = append(rows, analysisRow{text: fmt.Sprintf(
+			"%d keys  %d events  %.2f keys/s  R²=%.3f",
+			mg.TotalKeys, mg.TotalEvents, mg.GrowthRate, mg.RSquared)})
+		if mg.IsUnbounded {
+			rows = append(rows, analysisRow{
+				text:  fmt.Sprintf("Unbounded growth: ~%d keys in 30 min", mg.Prediction30Min),
+				style: rowWarning,
+			})
+		}
+	}
+
+	// ── Cost ──
+
+	if ca := r.CostAttribution; ca != nil {
+		section(fmt.Sprintf("Cost  $%.4f", ca.TotalEstimatedCost))
+		for _, e := range ca.Entries {
+			rows = append(rows, analysisRow{
+				text: fmt.Sprintf("$%-8.4f %5.1f%%  %-14s %s",
+					e.EstimatedCost, e.Percentage, e.Model, e.OperationName),
+				spanID: e.SpanID,
+			})
+		}
+	}
+
+	return rows
+}
+
+// healthPenalty is one deduction from a trace's health score.
+type healthPenalty struct {
+	points int
+	reason string
+}
+
+// hotspotPenalty is what each token or latency hotspot costs the health
+// score, by severity.
+var hotspotPenalty = map[string]int{"high": 10, "medium": 5, "low": 2}
+
+// healthScore rates a trace from 0 to 100 using the report's findings:
+// up to 50 points for the share of failed spans, a few points per
+// hotspot by severity, and 20 for unbounded memory growth.
+func healthScore(r *analysis.AnalysisReport) (int, []healthPenalty) {
+	var penalties []healthPenalty
+	if s := r.Stats; s != nil && s.TotalSpans > 0 && s.ErrorSpans > 0 {
+		rate := float64(s.ErrorSpans) / float64(s.TotalSpans)
+		penalties = append(penalties, healthPenalty{
+			points: maxInt(1, int(rate*50+0.5)),
+			reason: fmt.Sprintf("%d of %d spans failed (%.0f%%)", s.ErrorSpans, s.TotalSpans, rate*100),
+		})
+	}
+
+	hotspots := func(kind string, severities []string) {
+		counts := make(map[string]int)
+		for _, sev := range severities {
+			counts[sev]++
+		}
+		for _, sev := range []string{"high", "medium", "low"} {
+			if n := counts[sev]; n > 0 {
+				penalties = append(penalties, healthPenalty{
+					points: n * hotspotPenalty[sev],
+					reason: fmt.Sprintf("%d %s %s hotspot(s)", n, sev, kind),
+				})
+			}
+		}
+	}
+	var token, latency []string
+	for _, h := range r.TokenHotspots {
+		token = append(token, h.Severity)
+	}
+	for _, h := range r.LatencyHotspots {
+		latency = append(latency, h.Severity)
+	}
+	hotspots("token", token)
+	hotspots("latency", latency)
+
+	if mg := r.MemoryGrowth; mg != nil && mg.IsUnbounded {
+		penalties = append(penalties, healthPenalty{points: 20, reason: "unbounded memory growth"})
+	}
+
+	score := 100
+	for _, p := range penalties {
+		score -= p.points
+	}
+	return maxInt(score, 0), penalties
+}
+
+// renderAnalysis renders the analysis overlay content.
+func renderAnalysis(m *Model, width, height int) string {
+	title := panelTitleStyle.Render("Analysis")
+
+	if m.analysis == nil {
+		return title + "\n\n" + emptyStateStyle.Render("Running analysis...")
+	}
+
+	title += traceDimStyle.Render(fmt.Sprintf("  %s  generated %s",
+		shortID(m.analysis.TraceID, 10), m.analysis.GeneratedAt))
+	if s := m.analysis.Stats; s != nil {
+		title += traceDimStyle.Render(fmt.Sprintf("  %d spans  %s",
+			s.TotalSpans, timeutil.FormatDuration(s.TotalDurationMs)))
+	}
+
+	rows := buildAnalysisRows(m.analysis)
+	contentHeight := height - 2
+
+	scrollStart := 0
+	if m.analysisCursor >= contentHeight {
+		scrollStart = m.analysisCursor - contentHeight + 1
+	}
+	end := minInt(scrollStart+contentHeight, len(rows))
+
+	lines := []string{title, ""}
+	for i := scrollStart; i < end; i++ {
+		row := rows[i]
+		text := truncate(row.text, width-2)
+
+		switch {
+		case i == m.analysisCursor:
+			text = spanSelectedStyle.Width(width).Render(text)
+		case row.style == rowSection:
+			text = detailLabelStyle.Render(text)
+		case row.style == rowWarning:
+			text = diffModStyle.Render(text)
+		case row.spanID != "":
+			text = detailValueStyle.Render(text)
+		default:
+			text = traceDimStyle.Render(text)
+		}
+		lines = append(lines, text)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// renderAnalysisPanel wraps the analysis overlay in a styled panel.
+func renderAnalysisPanel(m *Model, width, height int) string {
+	content := renderAnalysis(m, width-4, height-2)
+	return panelActiveStyle.Width(width).Height(height).Render(content)
+}
@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Mr-Dark-debug/oculo/internal/analysis"
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+)
+
+// TestAnalysisOverlay verifies that 'a' runs the analyzer for the current
+// trace and that the resulting report renders its warnings.
+func TestAnalysisOverlay(t *testing.T) {
+	// Ten uniform LLM calls and one outlier produce a high-severity hotspot.
+	var spans []*database.Span
+	for i := 0; i < 11; i++ {
+		sp := newTestSpan(fmt.Sprintf("llm-%02d", i), "", "LLM", int64(i*10), 10)
+		sp.PromptTokens = 100
+		if i == 7 {
+			sp.PromptTokens = 10000
+		}
+		spans = append(spans, sp)
+	}
+
+	m := newTestModel(spans...)
+	m.store = &fakeStore{spans: spans}
+	m.currentTrace = &database.Trace{TraceID: "trace-test", AgentName: "agent"}
+
+	m, cmd := press(m, "a")
+	if !m.showAnalysis {
+		t.Fatal("expected a to open the analysis overlay")
+	}
+	if cmd == nil {
+		t.Fatal("expected a to issue the analysis command")
+	}
+
+	msg, ok := cmd().(analysisLoadedMsg)
+	if !ok {
+		t.Fatalf("expected analysisLoadedMsg, got %T", cmd())
+	}
+	updated, _ := m.Update(msg)
+	m = updated.(Model)
+
+	out := renderAnalysis(&m, 100, 40)
+	if !strings.Contains(out, "Warnings") || !strings.Contains(out, "TOKEN HOTSPOT") {
+		t.Errorf("expected rendered warnings, got:\n%s", out)
+	}
+
+	// Jump from the hotspot row back to the timeline
+	rows := buildAnalysisRows(m.analysis)
+	for i, r := range rows {
+		if r.spanID == "llm-07" {
+			m.analysisCursor = i
+			break
+		}
+	}
+	m, _ = press(m, "enter")
+	if m.showAnalysis {
+		t.Error("expected enter on a finding to close the overlay")
+	}
+	if got := m.spanTree[m.selectedSpan].span.SpanID; got != "llm-07" {
+		t.Errorf("expected selection to jump to llm-07, got %s", got)
+	}
+}
+
+// TestHealthScore verifies the score deducts for failed spans, hotspots
+// by severity and unbounded memory growth, and that the overlay shows
+// it with its reasons.
+func TestHealthScore(t *testing.T) {
+	r := &analysis.AnalysisReport{Stats: &database.TraceStats{TotalSpans: 10}}
+	if score, penalties := healthScore(r); score != 100 || len(penalties) != 0 {
+		t.Errorf("expected a clean trace to score 100, got %d %v", score, penalties)
+	}
+
+	r.Stats.ErrorSpans = 2
+	r.TokenHotspots = []analysis.TokenHotspot{{Severity: "high"}, {Severity: "low"}}
+	r.LatencyHotspots = []analysis.LatencyHotspot{{Severity: "medium"}}
+	r.MemoryGrowth = &analysis.MemoryGrowthReport{IsUnbounded: true}
+
+	// 10 for the 20% error rate, 10+2 for token and 5 for latency
+	// hotspots, 20 for memory growth
+	if score, _ := healthScore(r); score != 53 {
+		t.Errorf("expected a score of 53, got %d", score)
+	}
+
+	rows := buildAnalysisRows(r)
+	var texts []string
+	for _, row := range rows {
+		texts = append(texts, row.text)
+	}
+	out := strings.Join(texts, "\n")
+	for _, want := range []string{"Health  53/100", "2 of 10 spans failed (20%)", "1 high token hotspot(s)", "unbounded memory growth"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the overlay, got:\n%s", want, out)
+		}
+	}
+
+	r.Stats.ErrorSpans = 10
+	r.TokenHotspots = make([]analysis.TokenHotspot, 10)
+	for i := range r.TokenHotspots {
+		r.TokenHotspots[i].Severity = "high"
+	}
+	if score, _ := healthScore(r); score != 0 {
+		t.Errorf("expected the score clamped at 0, got %d", score)
+	}
+}
@@ -1,9 +1,14 @@
 package tui
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/Mr-Dark-debug/oculo/internal/analysis"
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+	"github.com/Mr-Dark-debug/oculo/pkg/jsonutil"
 	"github.com/Mr-Dark-debug/oculo/pkg/timeutil"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -21,23 +26,63 @@ func renderDetail(m *Model, width, height int) string {
 			emptyStateStyle.Render("Select a span to view details.")
 	}
 
+	lines := detailLines(m, width)
+
+	// Scroll the content below the title; when it overflows, the last
+	// row shows the position
+	contentHeight := height - 2
+	if len(lines) > contentHeight && contentHeight > 1 {
+		rows := contentHeight - 1
+		start := clamp(m.detailScroll, 0, len(lines)-rows)
+		maxStart := len(lines) - rows
+		indicator := traceDimStyle.Render(fmt.Sprintf(" line %d of %d (%d%%)",
+			start+1, len(lines), start*100/maxStart))
+		lines = append(lines[start:start+rows:start+rows], indicator)
+	} else if len(lines) > contentHeight {
+		lines = lines[:maxInt(contentHeight, 0)]
+	}
+
+	return title + "\n\n" + strings.Join(lines, "\n")
+}
+
+// detailLines renders the selected span's details, one entry per line,
+// before scrolling is applied.
+func detailLines(m *Model, width int) []string {
 	span := m.spanTree[m.selectedSpan].span
 	var lines []string
 
-	lines = append(lines, title)
-	lines = append(lines, "")
-
-	// ── Metadata ──
+	// ── Span fields ──
 
 	lines = append(lines, detailRow("Type", span.OperationType))
 	lines = append(lines, detailRow("Name", span.OperationName))
 	lines = append(lines, detailRow("ID", shortID(span.SpanID, 16)))
+	lines = append(lines, detailRow("Started", m.formatTime(span.StartTime)))
 	lines = append(lines, detailRow("Duration", timeutil.FormatDuration(span.DurationMs)))
 	lines = append(lines, detailRow("Status", span.Status))
 
 	if span.Model != nil {
 		lines = append(lines, detailRow("Model", *span.Model))
 	}
+	if span.Temperature != nil {
+		lines = append(lines, detailRow("Temperature", fmt.Sprintf("%.2f", *span.Temperature)))
+	}
+
+	// ── Metadata ──
+
+	if span.Metadata != nil && *span.Metadata != "" {
+		lines = append(lines, "")
+		lines = append(lines, detailSectionStyle.Render("Metadata"))
+		// Malformed metadata is shown raw
+		if json.Valid([]byte(*span.Metadata)) {
+			for _, line := range highlightJSON(*span.Metadata) {
+				lines = append(lines, hpanStyled(line, m.hScroll, width))
+			}
+		} else {
+			for _, line := range strings.Split(*span.Metadata, "\n") {
+				lines = append(lines, traceDimStyle.Render(hpan(line, m.hScroll, width)))
+			}
+		}
+	}
 
 	// ── Token usage ──
 
@@ -71,6 +116,16 @@ func renderDetail(m *Model, width, height int) string {
 		}
 	}
 
+	// ── Tool calls ──
+
+	if m.diffsSpanID == span.SpanID && len(m.toolCalls) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, detailSectionStyle.Render(fmt.Sprintf("Tool Calls (%d)", len(m.toolCalls))))
+		for _, c := range m.toolCalls {
+			lines = append(lines, m.toolCallLines(c, width)...)
+		}
+	}
+
 	// ── Trace-level summary ──
 
 	if m.stats != nil {
@@ -98,6 +153,12 @@ func renderDetail(m *Model, width, height int) string {
 			lines = append(lines, renderUsageBar("Memory", m.stats.MemoryEventCount,
 				m.stats.LLMCalls+m.stats.ToolCalls+m.stats.MemoryEventCount, barWidth, colorYellow))
 		}
+
+		// Estimated cost by model
+		if costs := modelCosts(m.spans, m.opts.Pricing); len(costs) > 0 {
+			lines = append(lines, "")
+			lines = append(lines, renderCostBar(costs, minInt(width-6, 50))...)
+		}
 	}
 
 	// ── Prompt preview ──
@@ -105,9 +166,8 @@ func renderDetail(m *Model, width, height int) string {
 	if span.Prompt != nil && *span.Prompt != "" {
 		lines = append(lines, "")
 		lines = append(lines, detailSectionStyle.Render("Prompt"))
-		preview := truncate(*span.Prompt, (width-4)*3)
-		for _, line := range strings.Split(preview, "\n") {
-			lines = append(lines, traceDimStyle.Render(line))
+		for _, line := range strings.Split(m.redacted(*span.Prompt), "\n") {
+			lines = append(lines, highlight(hpan(line, m.hScroll, width), m.detailQuery(), traceDimStyle))
 		}
 	}
 
@@ -116,18 +176,12 @@ func renderDetail(m *Model, width, height int) string {
 	if span.Completion != nil && *span.Completion != "" {
 		lines = append(lines, "")
 		lines = append(lines, detailSectionStyle.Render("Completion"))
-		preview := truncate(*span.Completion, (width-4)*3)
-		for _, line := range strings.Split(preview, "\n") {
-			lines = append(lines, detailValueStyle.Render(line))
+		for _, line := range strings.Split(m.redacted(*span.Completion), "\n") {
+			lines = append(lines, highlight(hpan(line, m.hScroll, width), m.detailQuery(), detailValueStyle))
 		}
 	}
 
-	// Truncate to available height
-	if len(lines) > height {
-		lines = lines[:height]
-	}
-
-	return strings.Join(lines, "\n")
+	return lines
 }
 
 // renderDetailPanel wraps detail in a styled panel.
@@ -144,10 +198,168 @@ func renderDetailPanel(m *Model, width, height int) string {
 
 // ── helpers ──
 
+// detailQuery returns the active search query to highlight in the
+// prompt and completion, or "" when the search targets the diff pane.
+func (m *Model) detailQuery() string {
+	if m.diffSearch {
+		return ""
+	}
+	return m.searchQuery
+}
+
+// longestDetailLine returns the rune length of the longest prompt,
+// completion, or metadata line of a span, which bounds horizontal
+// scrolling in the detail pane.
+func longestDetailLine(span *database.Span) int {
+	longest := 0
+	for _, s := range []*string{span.Prompt, span.Completion} {
+		if s != nil {
+			longest = maxInt(longest, longestLine(*s))
+		}
+	}
+	if span.Metadata != nil {
+		longest = maxInt(longest, longestLine(metadataText(*span.Metadata)))
+	}
+	return longest
+}
+
+// metadataText is the plain text the detail pane shows for metadata:
+// pretty-printed JSON, capped the way highlightJSON caps it, or the raw
+// string when it is not JSON.
+func metadataText(s string) string {
+	if !json.Valid([]byte(s)) {
+		return s
+	}
+	if len(s) > maxHighlightBytes {
+		pretty, _ := jsonutil.PrettyJSONLimit(s, maxHighlightBytes)
+		return pretty
+	}
+	return jsonutil.PrettyJSON(s)
+}
+
+// toolCallLines renders one tool call: a ✓/✗ line with its name and
+// latency, then its arguments compacted onto one truncated line. The
+// arguments are masked like prompts while redaction is on.
+func (m *Model) toolCallLines(c *database.ToolCall, width int) []string {
+	mark := traceStatusOk.Render("✓")
+	if !c.Success {
+		mark = traceStatusFail.Render("✗")
+	}
+	lines := []string{mark + " " + detailValueStyle.Render(c.ToolName) + "  " +
+		traceDimStyle.Render(timeutil.FormatDuration(c.LatencyMs))}
+	if c.ArgumentsJSON != nil && *c.ArgumentsJSON != "" {
+		args := m.redacted(strings.Join(strings.Fields(jsonutil.CompactJSON(*c.ArgumentsJSON)), " "))
+		lines = append(lines, traceDimStyle.Render("  args "+hpan(args, 0, width-7)))
+	}
+	return lines
+}
+
 func detailRow(label, value string) string {
 	return detailLabelStyle.Render(label) + "  " + detailValueStyle.Render(value)
 }
 
+// modelCost is one model's share of a trace's estimated LLM cost.
+type modelCost struct {
+	model string
+	cost  float64
+}
+
+// modelCosts totals the estimated cost of LLM spans per model, most
+// expensive first, using pricing over the built-in prices.
+func modelCosts(spans []*database.Span, pricing analysis.PricingTable) []modelCost {
+	byModel := make(map[string]float64)
+	for _, s := range spans {
+		if s.OperationType != "LLM" {
+			continue
+		}
+		model := "unknown"
+		if s.Model != nil {
+			model = *s.Model
+		}
+		cost, _ := pricing.Cost(model, s.PromptTokens, s.CompletionTokens)
+		byModel[model] += cost
+	}
+
+	var costs []modelCost
+	for model, cost := range byModel {
+		if cost > 0 {
+			costs = append(costs, modelCost{model, cost})
+		}
+	}
+	sort.Slice(costs, func(i, j int) bool {
+		if costs[i].cost != costs[j].cost {
+			return costs[i].cost > costs[j].cost
+		}
+		return costs[i].model < costs[j].model
+	})
+	return costs
+}
+
+// costSegments splits a bar of width cells between costs in proportion,
+// handing out leftover cells by largest remainder so widths sum to width.
+func costSegments(costs []modelCost, width int) []int {
+	total := 0.0
+	for _, c := range costs {
+		total += c.cost
+	}
+	segments := make([]int, len(costs))
+	if total <= 0 || width <= 0 {
+		return segments
+	}
+
+	remainders := make([]float64, len(costs))
+	used := 0
+	for i, c := range costs {
+		exact := c.cost / total * float64(width)
+		segments[i] = int(exact)
+		remainders[i] = exact - float64(segments[i])
+		used += segments[i]
+	}
+	for ; used < width; used++ {
+		best := 0
+		for i := range remainders {
+			if remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		segments[best]++
+		remainders[best] = -1
+	}
+	return segments
+}
+
+// costColors colors the cost bar segments, cycling for many models.
+var costColors = []lipgloss.Color{colorPurple, colorBlue, colorCyan, colorGreen, colorYellow, colorRed}
+
+// renderCostBar renders a stacked bar of each model's share of the
+// estimated cost, followed by a legend line per model.
+func renderCostBar(costs []modelCost, barWidth int) []string {
+	total := 0.0
+	for _, c := range costs {
+		total += c.cost
+	}
+
+	lines := []string{detailRow("Est. Cost", fmt.Sprintf("$%.4f", total))}
+	if barWidth < 4 {
+		return lines
+	}
+
+	var bar strings.Builder
+	segments := costSegments(costs, barWidth)
+	for i, w := range segments {
+		color := costColors[i%len(costColors)]
+		bar.WriteString(lipgloss.NewStyle().Foreground(color).Render(strings.Repeat("\u2588", w)))
+	}
+	lines = append(lines, bar.String())
+
+	for i, c := range costs {
+		color := costColors[i%len(costColors)]
+		lines = append(lines, lipgloss.NewStyle().Foreground(color).Render("\u25a0")+
+			traceDimStyle.Render(fmt.Sprintf(" %-16s $%.4f %3.0f%%", c.model, c.cost, c.cost/total*100)))
+	}
+	return lines
+}
+
 func renderUsageBar(label string, count, total, barWidth int, color lipgloss.Color) string {
 	if total == 0 {
 		return ""
@@ -0,0 +1,324 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Mr-Dark-debug/oculo/internal/analysis"
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// TestDetailTemperatureAndMetadata verifies that temperature and
+// pretty-printed metadata appear in the detail pane.
+func TestDetailTemperatureAndMetadata(t *testing.T) {
+	sp := newTestSpan("s0", "", "LLM", 0, 10)
+	temp := 0.7
+	meta := `{"retries":2,"region":"eu-west-1"}`
+	sp.Temperature = &temp
+	sp.Metadata = &meta
+
+	m := newTestModel(sp)
+	out := renderDetail(&m, 80, 60)
+
+	for _, want := range []string{"Temperature", "0.70", "Metadata", `"region": "eu-west-1"`, `"retries": 2`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected detail to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+// TestDetailMalformedMetadata verifies that metadata which is not valid
+// JSON is shown as-is.
+func TestDetailMalformedMetadata(t *testing.T) {
+	sp := newTestSpan("s0", "", "TOOL", 0, 10)
+	meta := `{not json`
+	sp.Metadata = &meta
+
+	m := newTestModel(sp)
+	if out := renderDetail(&m, 80, 60); !strings.Contains(out, "{not json") {
+		t.Errorf("expected raw metadata in detail, got:\n%s", out)
+	}
+}
+
+// TestDetailHorizontalPan verifies that panning right shifts the visible
+// window of a long prompt line and that panning is clamped.
+func TestDetailHorizontalPan(t *testing.T) {
+	sp := newTestSpan("s0", "", "LLM", 0, 10)
+	prompt := strings.Repeat("a", 20) + "MIDDLE" + strings.Repeat("b", 60) + "TAIL"
+	sp.Prompt = &prompt
+
+	m := newTestModel(sp)
+	m.activePane = PaneDetail
+
+	if out := renderDetail(&m, 30, 60); strings.Contains(out, "TAIL") {
+		t.Fatal("expected the end of the prompt to be cut off before panning")
+	}
+
+	m, _ = press(m, "l", "l")
+	out := renderDetail(&m, 30, 60)
+	if !strings.Contains(out, "…aaaaMIDDLE") {
+		t.Errorf("expected panned prompt to start 16 runes in, got:\n%s", out)
+	}
+
+	for i := 0; i < 20; i++ {
+		m, _ = press(m, "l")
+	}
+	if m.hScroll != len(prompt) {
+		t.Errorf("expected pan clamped to %d, got %d", len(prompt), m.hScroll)
+	}
+
+	m, _ = press(m, "h")
+	if m.hScroll != len(prompt)-panStep {
+		t.Errorf("expected h to pan back one step, got %d", m.hScroll)
+	}
+}
+
+// TestLongestDetailLineHugeMetadata verifies the pan limit for
+// oversized metadata follows the capped text the pane renders, not the
+// full document.
+func TestLongestDetailLineHugeMetadata(t *testing.T) {
+	sp := newTestSpan("s0", "", "LLM", 0, 10)
+	meta := `{"blob":"` + strings.Repeat("x", maxHighlightBytes) + `","after":1}`
+	sp.Metadata = &meta
+
+	if got := longestDetailLine(sp); got > maxHighlightBytes {
+		t.Errorf("expected the pan limit within the %d byte cap, got %d", maxHighlightBytes, got)
+	}
+
+	raw := "not json " + strings.Repeat("y", 40)
+	sp.Metadata = &raw
+	if got := longestDetailLine(sp); got != len(raw) {
+		t.Errorf("expected malformed metadata measured as-is, got %d", got)
+	}
+}
+
+// TestDetailScrollIndicator verifies that the position indicator only
+// appears when the detail content overflows and follows the scroll
+// offset to the last screenful.
+func TestDetailScrollIndicator(t *testing.T) {
+	sp := newTestSpan("s0", "", "LLM", 0, 10)
+	m := newTestModel(sp)
+	m.activePane = PaneDetail
+
+	if out := renderDetail(&m, 80, 60); strings.Contains(out, "line ") {
+		t.Fatalf("expected no indicator when content fits, got:\n%s", out)
+	}
+
+	prompt := strings.Repeat("prompt line\n", 60)
+	sp.Prompt = &prompt
+	total := len(detailLines(&m, 80))
+
+	out := renderDetail(&m, 80, 20)
+	if !strings.Contains(out, fmt.Sprintf("line 1 of %d (0%%)", total)) {
+		t.Fatalf("expected indicator at the top, got:\n%s", out)
+	}
+	if got := len(strings.Split(out, "\n")); got != 20 {
+		t.Errorf("expected 20 rendered lines, got %d", got)
+	}
+
+	m, _ = press(m, "j", "j", "j")
+	if out := renderDetail(&m, 80, 20); !strings.Contains(out, fmt.Sprintf("line 4 of %d", total)) {
+		t.Errorf("expected indicator to follow the scroll, got:\n%s", out)
+	}
+
+	for i := 0; i < 200; i++ {
+		m, _ = press(m, "j")
+	}
+	if m.detailScroll != m.maxDetailScroll() {
+		t.Errorf("expected scroll clamped to %d, got %d", m.maxDetailScroll(), m.detailScroll)
+	}
+	_, width, height, _ := m.paneSizes(m.height - 2)
+	if out := renderDetail(&m, width-4, height-2); !strings.Contains(out, "(100%)") {
+		t.Errorf("expected 100%% at the last screenful, got:\n%s", out)
+	}
+}
+
+// TestRedaction verifies that R masks prompt, completion and memory
+// values while token counts stay visible, and that R again restores them.
+func TestRedaction(t *testing.T) {
+	sp := newTestSpan("s0", "", "LLM", 0, 10)
+	prompt, completion := "my secret api key is hunter2", "noted"
+	sp.Prompt, sp.Completion = &prompt, &completion
+	sp.PromptTokens, sp.CompletionTokens = 312, 48
+
+	m := newTestModel(sp)
+	val := "ssn 123-45-6789"
+	m.memoryDiffs = []*database.MemoryEvent{{SpanID: "s0", Operation: "ADD", Key: "user", Namespace: "default", NewValue: &val}}
+
+	m, _ = press(m, "R")
+	detail := renderDetail(&m, 80, 60)
+	if strings.Contains(detail, "hunter2") || strings.Contains(detail, "noted") {
+		t.Errorf("expected prompt and completion hidden, got:\n%s", detail)
+	}
+	if !strings.Contains(detail, fmt.Sprintf("[redacted — %d chars]", len(prompt))) {
+		t.Errorf("expected placeholder with the prompt length, got:\n%s", detail)
+	}
+	for _, want := range []string{"312", "48", "360"} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("expected token count %s to stay visible", want)
+		}
+	}
+	if diff := renderDiffView(&m, 80, 10); strings.Contains(diff, "123-45") || !strings.Contains(diff, "default.user") {
+		t.Errorf("expected memory value hidden but key shown, got:\n%s", diff)
+	}
+
+	m, _ = press(m, "R")
+	if detail := renderDetail(&m, 80, 60); !strings.Contains(detail, "hunter2") {
+		t.Error("expected prompt visible again after a second R")
+	}
+}
+
+// TestDetailSearchHighlight verifies that every case-insensitive match
+// of the search query in the prompt and completion is highlighted.
+func TestDetailSearchHighlight(t *testing.T) {
+	saved := searchMatchStyle
+	searchMatchStyle = lipgloss.NewStyle().Transform(func(s string) string { return "[" + s + "]" })
+	defer func() { searchMatchStyle = saved }()
+
+	sp := newTestSpan("s0", "", "LLM", 0, 10)
+	prompt, completion := "Explain the Transformer. Why transformers?", "A transformer is a model."
+	sp.Prompt, sp.Completion = &prompt, &completion
+	m := newTestModel(sp)
+
+	m, _ = press(m, "/")
+	m, _ = press(m, strings.Split("transformer", "")...)
+	m, _ = press(m, "enter")
+
+	out := renderDetail(&m, 80, 60)
+	for _, want := range []string{"the [Transformer].", "Why [transformer]s?", "A [transformer] is"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in detail, got:\n%s", want, out)
+		}
+	}
+
+	m, _ = press(m, "esc")
+	if out := renderDetail(&m, 80, 60); strings.Contains(out, "[") {
+		t.Errorf("expected no highlight after clearing the search, got:\n%s", out)
+	}
+}
+
+// TestCostBar verifies that the cost bar splits its cells between
+// models in proportion to their estimated cost.
+func TestCostBar(t *testing.T) {
+	llm := func(id, model string, prompt, completion int) *database.Span {
+		s := newTestSpan(id, "", "LLM", 0, 10)
+		s.Model = &model
+		s.PromptTokens, s.CompletionTokens = prompt, completion
+		return s
+	}
+	spans := []*database.Span{
+		llm("a", "gpt-4", 1000, 500),   // $0.03 + $0.03 = $0.06
+		llm("b", "gpt-4o", 3000, 1000), // $0.015 + $0.015 = $0.03
+		newTestSpan("tool", "", "TOOL", 0, 10),
+	}
+
+	costs := modelCosts(spans, nil)
+	if len(costs) != 2 || costs[0].model != "gpt-4" || costs[1].model != "gpt-4o" {
+		t.Fatalf("expected gpt-4 then gpt-4o, got %+v", costs)
+	}
+
+	segments := costSegments(costs, 30)
+	if segments[0] != 20 || segments[1] != 10 {
+		t.Errorf("expected a 2:1 split of 30 cells, got %v", segments)
+	}
+	if got := costSegments(costs, 7); got[0]+got[1] != 7 {
+		t.Errorf("expected segments to fill the bar, got %v", got)
+	}
+
+	m := newTestModel(spans...)
+	m.stats = &database.TraceStats{TotalSpans: 3, LLMCalls: 2}
+	out := renderDetail(&m, 80, 80)
+	for _, want := range []string{"$0.0900", "gpt-4 ", "67%", "gpt-4o", "33%", strings.Repeat("█", 20)} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in detail, got:\n%s", want, out)
+		}
+	}
+
+	// A pricing table from --pricing overrides the built-in prices
+	m.opts.Pricing = analysis.PricingTable{"gpt-4o": {0.02, 0.02}}
+	if costs := modelCosts(spans, m.opts.Pricing); costs[0].model != "gpt-4o" {
+		t.Errorf("expected gpt-4o to cost most with the pricing table, got %+v", costs)
+	}
+	if out := renderDetail(&m, 80, 80); !strings.Contains(out, "$0.1400") {
+		t.Errorf("expected the cost bar to use the pricing table, got:\n%s", out)
+	}
+}
+
+// TestDetailToolCalls verifies that the selected span's tool calls are
+// listed with their outcome, latency and truncated arguments, and that
+// the arguments are masked in redacted mode.
+func TestDetailToolCalls(t *testing.T) {
+	sp := newTestSpan("s0", "", "TOOL", 0, 10)
+	args := `{"query": "` + strings.Repeat("x", 200) + `"}`
+	store := &fakeStore{spans: []*database.Span{sp}, calls: map[string][]*database.ToolCall{
+		"s0": {
+			{CallID: 1, SpanID: "s0", ToolName: "web_search", ArgumentsJSON: &args, Success: true, LatencyMs: 250},
+			{CallID: 2, SpanID: "s0", ToolName: "fetch_page", Success: false, LatencyMs: 1200},
+		},
+	}}
+	m := newTestModel(sp)
+	m.store = store
+
+	updated, _ := m.Update(m.loadMemoryDiffs("s0")())
+	m = updated.(Model)
+
+	out := renderDetail(&m, 60, 60)
+	for _, want := range []string{"Tool Calls (2)", "✓ web_search", "✗ fetch_page", `args {"query":"xxx`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected detail to contain %q, got:\n%s", want, out)
+		}
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, "args") && lipgloss.Width(line) > 60 {
+			t.Errorf("expected arguments truncated to the pane width, got %d cells", lipgloss.Width(line))
+		}
+	}
+
+	m, _ = press(m, "R")
+	out = renderDetail(&m, 60, 60)
+	if strings.Contains(out, "xxx") || !strings.Contains(out, "args [redacted") {
+		t.Errorf("expected arguments masked while redacting, got:\n%s", out)
+	}
+}
+
+// TestDetailPaging verifies that PgDn and PgUp move the detail pane by a
+// screenful, clamped at both ends, and that g and G jump to the ends.
+func TestDetailPaging(t *testing.T) {
+	sp := newTestSpan("s0", "", "LLM", 0, 10)
+	prompt := strings.Repeat("prompt line\n", 200)
+	sp.Prompt = &prompt
+	m := newTestModel(sp)
+	m.activePane = PaneDetail
+
+	page := m.detailRows()
+	if page < 1 || m.maxDetailScroll() <= page {
+		t.Fatalf("expected content longer than a page, got page %d and max %d", page, m.maxDetailScroll())
+	}
+
+	m, _ = press(m, "pgdown")
+	if m.detailScroll != page {
+		t.Errorf("expected PgDn to scroll %d lines, got %d", page, m.detailScroll)
+	}
+	m, _ = press(m, "pgup", "pgup")
+	if m.detailScroll != 0 {
+		t.Errorf("expected PgUp to stop at the top, got %d", m.detailScroll)
+	}
+
+	for i := 0; i < 50; i++ {
+		m, _ = press(m, "pgdown")
+	}
+	if m.detailScroll != m.maxDetailScroll() {
+		t.Errorf("expected PgDn clamped to %d, got %d", m.maxDetailScroll(), m.detailScroll)
+	}
+
+	m, _ = press(m, "g")
+	if m.detailScroll != 0 {
+		t.Errorf("expected g to jump to the top, got %d", m.detailScroll)
+	}
+	m, _ = press(m, "G")
+	if m.detailScroll != m.maxDetailScroll() {
+		t.Errorf("expected G to jump to the bottom, got %d", m.detailScroll)
+	}
+}
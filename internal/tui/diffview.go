@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/Mr-Dark-debug/oculo/pkg/timeutil"
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+	"github.com/Mr-Dark-debug/oculo/pkg/jsonutil"
+	"github.com/charmbracelet/lipgloss"
 )
 
 // renderDiffView renders the memory mutation diff pane (bottom).
@@ -21,58 +23,210 @@ func renderDiffView(m *Model, width, height int) string {
 			diffContextStyle.Render("No memory mutations for this span.")
 	}
 
+	if m.diffNamespace != "" {
+		title += filterBadgeStyle.Render("ns:" + m.diffNamespace)
+	}
+	if q := m.diffQuery(); q != "" {
+		title += filterBadgeStyle.Render("/" + q)
+	}
 	title += traceDimStyle.Render(
-		fmt.Sprintf("  %d events", len(m.memoryDiffs)))
+		fmt.Sprintf("  %d events", len(m.visibleDiffs())))
+
+	lines := diffLines(m, width)
+
+	// Apply scroll offset, never past the last screenful
+	contentHeight := height - 2
+	start := clamp(m.diffScroll, 0, maxInt(len(lines)-contentHeight, 0))
+	lines = lines[start:]
+	if len(lines) > contentHeight {
+		lines = lines[:contentHeight]
+	}
 
+	return title + "\n" + strings.Join(lines, "\n")
+}
+
+// diffLines renders every memory event of the selected span as styled
+// diff lines, before scrolling is applied.
+func diffLines(m *Model, width int) []string {
 	var lines []string
+	q := m.diffQuery()
 
-	for _, ev := range m.memoryDiffs {
-		ts := treeTimestampStyle.Render(timeutil.FormatTimestamp(ev.Timestamp))
+	for _, ev := range m.visibleDiffs() {
+		ts := treeTimestampStyle.Render(m.formatClock(ev.Timestamp))
 
 		switch ev.Operation {
 		case "ADD":
+			key := fmt.Sprintf("%s.%s", ev.Namespace, ev.Key)
+			prefix := "+ " + key + ": "
 			val := ""
 			if ev.NewValue != nil {
-				val = truncate(*ev.NewValue, width-40)
+				val = hpan(m.redacted(*ev.NewValue), m.hScroll, width-lipgloss.Width(ts)-1-len([]rune(prefix)))
 			}
-			key := fmt.Sprintf("%s.%s", ev.Namespace, ev.Key)
 			lines = append(lines,
-				ts+" "+diffAddStyle.Render("+ "+key+": "+val))
+				ts+" "+highlight(prefix+val, q, diffAddStyle))
 
 		case "DELETE":
+			key := fmt.Sprintf("%s.%s", ev.Namespace, ev.Key)
+			prefix := "- " + key + ": "
 			val := ""
 			if ev.OldValue != nil {
-				val = truncate(*ev.OldValue, width-40)
+				val = hpan(m.redacted(*ev.OldValue), m.hScroll, width-lipgloss.Width(ts)-1-len([]rune(prefix)))
 			}
-			key := fmt.Sprintf("%s.%s", ev.Namespace, ev.Key)
 			lines = append(lines,
-				ts+" "+diffDelStyle.Render("- "+key+": "+val))
+				ts+" "+highlight(prefix+val, q, diffDelStyle))
 
 		case "UPDATE":
 			key := fmt.Sprintf("%s.%s", ev.Namespace, ev.Key)
 			lines = append(lines,
-				ts+" "+diffModStyle.Render("~ "+key))
+				ts+" "+highlight("~ "+key, q, diffModStyle))
+			if changes, ok := jsonChanges(ev); ok {
+				lines = append(lines, jsonChangeLines(m, changes, width)...)
+				continue
+			}
 			if ev.OldValue != nil {
 				lines = append(lines,
-					"  "+diffDelStyle.Render("- "+truncate(*ev.OldValue, width-10)))
+					"  "+highlight("- "+hpan(m.redacted(*ev.OldValue), m.hScroll, width-4), q, diffDelStyle))
 			}
 			if ev.NewValue != nil {
 				lines = append(lines,
-					"  "+diffAddStyle.Render("+ "+truncate(*ev.NewValue, width-10)))
+					"  "+highlight("+ "+hpan(m.redacted(*ev.NewValue), m.hScroll, width-4), q, diffAddStyle))
 			}
 		}
 	}
 
-	// Apply scroll offset
-	contentHeight := height - 2
-	if m.diffScroll > 0 && m.diffScroll < len(lines) {
-		lines = lines[m.diffScroll:]
+	return lines
+}
+
+// diffNamespaces returns the namespaces of the given memory events in
+// the order they first appear.
+func diffNamespaces(events []*database.MemoryEvent) []string {
+	var namespaces []string
+	seen := make(map[string]bool)
+	for _, ev := range events {
+		if !seen[ev.Namespace] {
+			seen[ev.Namespace] = true
+			namespaces = append(namespaces, ev.Namespace)
+		}
 	}
-	if len(lines) > contentHeight {
-		lines = lines[:contentHeight]
+	return namespaces
+}
+
+// visibleDiffs returns the memory events shown in the diff pane: those
+// in the selected namespace, if any, whose key or value matches the
+// diff search.
+func (m *Model) visibleDiffs() []*database.MemoryEvent {
+	q := strings.ToLower(m.diffQuery())
+	if m.diffNamespace == "" && q == "" {
+		return m.memoryDiffs
+	}
+	var events []*database.MemoryEvent
+	for _, ev := range m.memoryDiffs {
+		if m.diffNamespace != "" && ev.Namespace != m.diffNamespace {
+			continue
+		}
+		if q != "" && !m.diffMatches(ev, q) {
+			continue
+		}
+		events = append(events, ev)
 	}
+	return events
+}
 
-	return title + "\n" + strings.Join(lines, "\n")
+// diffQuery returns the active search query when the search targets
+// the memory diff pane, or "".
+func (m *Model) diffQuery() string {
+	if !m.diffSearch {
+		return ""
+	}
+	return m.searchQuery
+}
+
+// diffMatches reports whether a memory event's key or values contain
+// the lower-cased query. Redacted values only match their placeholder.
+func (m *Model) diffMatches(ev *database.MemoryEvent, q string) bool {
+	fields := []string{ev.Namespace + "." + ev.Key}
+	for _, v := range []*string{ev.OldValue, ev.NewValue} {
+		if v != nil {
+			fields = append(fields, m.redacted(*v))
+		}
+	}
+	for _, f := range fields {
+		if strings.Contains(strings.ToLower(f), q) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonChanges returns the changed paths of an UPDATE event whose old and
+// new values are both JSON objects or both JSON arrays. ok is false for
+// any other values.
+func jsonChanges(ev *database.MemoryEvent) (changes []jsonutil.JSONDiff, ok bool) {
+	if ev.OldValue == nil || ev.NewValue == nil {
+		return nil, false
+	}
+	oldKind, newKind := jsonContainer(*ev.OldValue), jsonContainer(*ev.NewValue)
+	if oldKind == 0 || oldKind != newKind {
+		return nil, false
+	}
+	changes, err := jsonutil.ComputeJSONDiff(*ev.OldValue, *ev.NewValue)
+	if err != nil {
+		return nil, false
+	}
+	return changes, true
+}
+
+// jsonContainer returns '{' or '[' when s looks like a JSON object or
+// array, and 0 otherwise.
+func jsonContainer(s string) byte {
+	s = strings.TrimSpace(s)
+	if s != "" && (s[0] == '{' || s[0] == '[') {
+		return s[0]
+	}
+	return 0
+}
+
+// jsonChangeLines renders one diff line per changed JSON path.
+func jsonChangeLines(m *Model, changes []jsonutil.JSONDiff, width int) []string {
+	offset := m.hScroll
+	q := m.diffQuery()
+	if len(changes) == 0 {
+		return []string{"  " + diffContextStyle.Render("(no changes)")}
+	}
+
+	var lines []string
+	for _, c := range changes {
+		switch c.Type {
+		case "add":
+			prefix := "+ " + c.Path + ": "
+			lines = append(lines, "  "+highlight(
+				prefix+hpan(m.redacted(c.NewValue), offset, width-4-len([]rune(prefix))), q, diffAddStyle))
+		case "delete":
+			prefix := "- " + c.Path + ": "
+			lines = append(lines, "  "+highlight(
+				prefix+hpan(m.redacted(c.OldValue), offset, width-4-len([]rune(prefix))), q, diffDelStyle))
+		case "update", "type_change":
+			prefix := "~ " + c.Path + ": "
+			lines = append(lines, "  "+highlight(
+				prefix+hpan(m.redacted(c.OldValue)+" \u2192 "+m.redacted(c.NewValue), offset, width-4-len([]rune(prefix))), q, diffModStyle))
+		}
+	}
+	return lines
+}
+
+// longestDiffValue returns the rune length of the longest memory value
+// line in the diff pane, which bounds horizontal scrolling.
+func longestDiffValue(events []*database.MemoryEvent) int {
+	longest := 0
+	for _, ev := range events {
+		if ev.OldValue != nil {
+			longest = maxInt(longest, longestLine(*ev.OldValue))
+		}
+		if ev.NewValue != nil {
+			longest = maxInt(longest, longestLine(*ev.NewValue))
+		}
+	}
+	return longest
 }
 
 // renderDiffPanel wraps the diff view in a styled panel.
@@ -0,0 +1,236 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// addEvents returns n ADD events for keys k00, k01, ... on one span.
+func addEvents(n int) []*database.MemoryEvent {
+	events := make([]*database.MemoryEvent, n)
+	for i := range events {
+		val := fmt.Sprintf("value-%02d", i)
+		events[i] = &database.MemoryEvent{
+			EventID:   fmt.Sprintf("evt-%02d", i),
+			SpanID:    "s0",
+			Operation: "ADD",
+			Key:       fmt.Sprintf("k%02d", i),
+			NewValue:  &val,
+			Namespace: "default",
+		}
+	}
+	return events
+}
+
+// TestDiffScrollStopsAtEnd verifies that scrolling far past the end of
+// the diff keeps the last screenful visible.
+func TestDiffScrollStopsAtEnd(t *testing.T) {
+	m := newTestModel(newTestSpan("s0", "", "MEMORY", 0, 10))
+	m.memoryDiffs = addEvents(30)
+	m.activePane = PaneMemoryDiff
+
+	for i := 0; i < 100; i++ {
+		m, _ = press(m, "j")
+	}
+	if m.diffScroll != m.maxDiffScroll() {
+		t.Errorf("expected diffScroll clamped to %d, got %d", m.maxDiffScroll(), m.diffScroll)
+	}
+
+	_, _, _, bottom := m.paneSizes(m.height - 2)
+	out := renderDiffPanel(&m, m.width, bottom)
+	if !strings.Contains(out, "default.k29") {
+		t.Errorf("expected the last event to stay visible, got:\n%s", out)
+	}
+
+	// An oversized offset set elsewhere is clamped by the renderer too
+	m.diffScroll = 1000
+	if out := renderDiffView(&m, 80, 10); !strings.Contains(out, "default.k29") {
+		t.Errorf("expected renderer to clamp the scroll offset, got:\n%s", out)
+	}
+}
+
+// TestCompactLayoutDiffPane verifies that tabbing to the diff pane on a
+// narrow terminal shows the selected span's memory diffs.
+func TestCompactLayoutDiffPane(t *testing.T) {
+	spans := []*database.Span{
+		newTestSpan("s0", "", "MEMORY", 0, 10),
+		newTestSpan("s1", "", "MEMORY", 10, 10),
+	}
+	v0, v1 := "zero", "one"
+	store := &fakeStore{
+		spans: spans,
+		events: map[string][]*database.MemoryEvent{
+			"s0": {{EventID: "e0", SpanID: "s0", Operation: "ADD", Key: "first", NewValue: &v0, Namespace: "ns"}},
+			"s1": {{EventID: "e1", SpanID: "s1", Operation: "ADD", Key: "second", NewValue: &v1, Namespace: "ns"}},
+		},
+	}
+
+	m := newTestModel(spans...)
+	m.store = store
+	m.width = 50
+
+	// Select s1 but lose the diff load, as a slow or dropped command would
+	m, _ = press(m, "j")
+	if m.selectedSpanID() != "s1" {
+		t.Fatalf("expected s1 selected, got %s", m.selectedSpanID())
+	}
+
+	// A late result for s0 must not overwrite the selection's diffs
+	updated, _ := m.Update(memoryDiffsLoadedMsg{spanID: "s0", events: store.events["s0"]})
+	m = updated.(Model)
+	if len(m.memoryDiffs) != 0 {
+		t.Error("expected stale diffs for s0 to be ignored")
+	}
+
+	m, _ = press(m, "tab")
+	if m.activePane != PaneDetail {
+		t.Fatalf("expected tab to focus the detail pane, got %v", m.activePane)
+	}
+	m, cmd := press(m, "tab")
+	if m.activePane != PaneMemoryDiff {
+		t.Fatalf("expected tab to focus the diff pane, got %v", m.activePane)
+	}
+	if cmd == nil {
+		t.Fatal("expected switching panes to load the selected span's diffs")
+	}
+	updated, _ = m.Update(cmd())
+	m = updated.(Model)
+
+	out := m.renderCompactLayout(m.height - 2)
+	if !strings.Contains(out, "ns.second") || strings.Contains(out, "ns.first") {
+		t.Errorf("expected s1's diff in the compact diff pane, got:\n%s", out)
+	}
+}
+
+// TestJSONUpdateDiff verifies that an UPDATE between two JSON objects
+// renders only the changed field, and that plain values still show both
+// sides in full.
+func TestJSONUpdateDiff(t *testing.T) {
+	oldVal := `{"goal":"research","step":1,"notes":"unchanged"}`
+	newVal := `{"goal":"research","step":2,"notes":"unchanged"}`
+	m := newTestModel(newTestSpan("s0", "", "MEMORY", 0, 10))
+	m.memoryDiffs = []*database.MemoryEvent{{
+		SpanID: "s0", Operation: "UPDATE", Key: "state", Namespace: "agent",
+		OldValue: &oldVal, NewValue: &newVal,
+	}}
+
+	out := strings.Join(diffLines(&m, 100), "\n")
+	if !strings.Contains(out, "~ step: 1 → 2") {
+		t.Errorf("expected the changed field, got:\n%s", out)
+	}
+	if strings.Contains(out, "goal") || strings.Contains(out, "notes") {
+		t.Errorf("expected unchanged fields to be omitted, got:\n%s", out)
+	}
+
+	typeOld, typeNew := `{"plan":{"a":1}}`, `{"plan":["a"]}`
+	m.memoryDiffs[0].OldValue, m.memoryDiffs[0].NewValue = &typeOld, &typeNew
+	if out := strings.Join(diffLines(&m, 100), "\n"); !strings.Contains(out, `~ plan: {"a":1} → ["a"]`) {
+		t.Errorf("expected the type change on one line, got:\n%s", out)
+	}
+
+	plainOld, plainNew := "draft", "final"
+	m.memoryDiffs[0].OldValue = &plainOld
+	m.memoryDiffs[0].NewValue = &plainNew
+	out = strings.Join(diffLines(&m, 100), "\n")
+	if !strings.Contains(out, "- draft") || !strings.Contains(out, "+ final") {
+		t.Errorf("expected plain values in full, got:\n%s", out)
+	}
+}
+
+// TestDiffNamespaceFilter verifies that n cycles through the namespaces
+// of the span's events, showing only the selected one, then all again.
+func TestDiffNamespaceFilter(t *testing.T) {
+	m := newTestModel(newTestSpan("s0", "", "MEMORY", 0, 10))
+	m.activePane = PaneMemoryDiff
+	a, b := "1", "2"
+	m.memoryDiffs = []*database.MemoryEvent{
+		{SpanID: "s0", Operation: "ADD", Key: "goal", Namespace: "agent", NewValue: &a},
+		{SpanID: "s0", Operation: "ADD", Key: "doc", Namespace: "cache", NewValue: &b},
+		{SpanID: "s0", Operation: "DELETE", Key: "tmp", Namespace: "agent", OldValue: &a},
+	}
+
+	m, _ = press(m, "n")
+	out := renderDiffView(&m, 80, 20)
+	if !strings.Contains(out, "ns:agent") || !strings.Contains(out, "2 events") {
+		t.Errorf("expected agent namespace in title, got:\n%s", out)
+	}
+	if !strings.Contains(out, "agent.goal") || !strings.Contains(out, "agent.tmp") || strings.Contains(out, "cache.doc") {
+		t.Errorf("expected only agent events, got:\n%s", out)
+	}
+
+	m, _ = press(m, "n")
+	out = renderDiffView(&m, 80, 20)
+	if !strings.Contains(out, "cache.doc") || strings.Contains(out, "agent.") {
+		t.Errorf("expected only cache events, got:\n%s", out)
+	}
+
+	m, _ = press(m, "n")
+	out = renderDiffView(&m, 80, 20)
+	if strings.Contains(out, "ns:") || !strings.Contains(out, "agent.goal") || !strings.Contains(out, "cache.doc") {
+		t.Errorf("expected all namespaces after cycling, got:\n%s", out)
+	}
+}
+
+// TestDiffSearch verifies that a search started from the diff pane
+// keeps only events whose key or value match, case-insensitively, and
+// that esc clears it.
+func TestDiffSearch(t *testing.T) {
+	m := newTestModel(newTestSpan("s0", "", "MEMORY", 0, 10))
+	m.activePane = PaneMemoryDiff
+	m.memoryDiffs = addEvents(5)
+	query := "Quantum physics notes"
+	m.memoryDiffs[3].NewValue = &query
+
+	m, _ = press(m, "/")
+	m, _ = press(m, strings.Split("quantum", "")...)
+	m, _ = press(m, "enter")
+	if m.searchMode {
+		t.Fatal("expected enter to close the search prompt")
+	}
+
+	lines := diffLines(&m, 100)
+	if len(lines) != 1 || !strings.Contains(lines[0], "default.k03") {
+		t.Fatalf("expected only the matching event, got:\n%s", strings.Join(lines, "\n"))
+	}
+	if out := renderDiffView(&m, 100, 20); !strings.Contains(out, "/quantum") {
+		t.Errorf("expected the query in the title, got:\n%s", out)
+	}
+
+	// Keys match too
+	m, _ = press(m, "/")
+	m, _ = press(m, strings.Split("k01", "")...)
+	if lines := diffLines(&m, 100); len(lines) != 1 || !strings.Contains(lines[0], "default.k01") {
+		t.Errorf("expected only k01 while typing, got:\n%s", strings.Join(lines, "\n"))
+	}
+
+	m, _ = press(m, "enter", "esc")
+	if lines := diffLines(&m, 100); len(lines) != 5 {
+		t.Errorf("expected esc to clear the filter, got %d lines", len(lines))
+	}
+	if m.showTraceList {
+		t.Error("expected esc to clear the filter before leaving the trace")
+	}
+}
+
+// TestHighlight verifies that every case-insensitive match is wrapped
+// in the match style and the rest of the text is kept.
+func TestHighlight(t *testing.T) {
+	base := lipgloss.NewStyle()
+	match := lipgloss.NewStyle().Bold(true)
+	saved := searchMatchStyle
+	searchMatchStyle = match
+	defer func() { searchMatchStyle = saved }()
+
+	got := highlight("Cat and cat", "CAT", base)
+	want := match.Render("Cat") + base.Render(" and ") + match.Render("cat")
+	if got != want {
+		t.Errorf("highlight = %q, want %q", got, want)
+	}
+	if got := highlight("dog", "", base); got != base.Render("dog") {
+		t.Errorf("expected plain render without a query, got %q", got)
+	}
+}
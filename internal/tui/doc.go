@@ -9,9 +9,15 @@
 //	theme.go     — centralized color + style definitions
 //	header.go    — top bar with trace context
 //	timeline.go  — span tree with depth-aware rendering
+//	gantt.go     — proportional time-bar timeline (flamegraph mode)
+//	grouped.go   — timeline bucketed by operation type
 //	detail.go    — span metadata + token usage bars
 //	diffview.go  — unified memory mutation diff viewer
+//	jsonview.go  — JSON syntax highlighting
+//	analysisview.go — analyzer findings overlay
+//	pager.go     — prompt/completion in $PAGER or the built-in viewer
 //	footer.go    — status line + keyboard hints
 //	tracelist.go — trace selector (initial screen)
+//	prefs.go     — view preferences saved between sessions
 //	helpers.go   — span tree building, truncation, etc.
 package tui
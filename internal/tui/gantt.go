@@ -0,0 +1,123 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Mr-Dark-debug/oculo/pkg/timeutil"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ganttLabelWidth is the maximum width of the span name column
+// to the left of the time bars.
+const ganttLabelWidth = 24
+
+// traceBounds returns the earliest start and the latest end (both Unix
+// nanoseconds) across all spans in the tree.
+func traceBounds(nodes []spanNode) (start, end int64) {
+	for i, n := range nodes {
+		s := n.span.StartTime
+		e := s + n.span.DurationMs*1e6
+		if i == 0 || s < start {
+			start = s
+		}
+		if i == 0 || e > end {
+			end = e
+		}
+	}
+	return start, end
+}
+
+// ganttBar maps a span onto a row of cols cells. It returns the column
+// the bar starts at and how many cells it covers. Offsets and widths are
+// proportional to the span's position within [traceStart, traceStart+total];
+// every span gets at least one cell so instantaneous operations stay visible.
+func ganttBar(start, durationMs, traceStart, total int64, cols int) (offset, width int) {
+	if cols <= 0 {
+		return 0, 0
+	}
+	if total <= 0 {
+		return 0, cols
+	}
+
+	offset = int((start - traceStart) * int64(cols) / total)
+	width = int(durationMs * 1e6 * int64(cols) / total)
+
+	offset = clamp(offset, 0, cols-1)
+	if width < 1 {
+		width = 1
+	}
+	if offset+width > cols {
+		width = cols - offset
+	}
+	return offset, width
+}
+
+// renderGantt renders the timeline as proportional time bars, one row per
+// span. Rows follow the span tree order, so parallel spans never collide:
+// each occupies its own row, indented by depth in the label column.
+func renderGantt(m *Model, width, height int) string {
+	titleStyle := panelTitleDimStyle
+	if m.activePane == PaneTimeline {
+		titleStyle = panelTitleStyle
+	}
+
+	traceStart, traceEnd := traceBounds(m.spanTree)
+	total := traceEnd - traceStart
+
+	title := titleStyle.Render("Timeline") +
+		traceDimStyle.Render(fmt.Sprintf("  gantt  %s",
+			timeutil.FormatDurationNanos(total)))
+
+	if len(m.spanTree) == 0 {
+		return title + "\n\n" +
+			emptyStateStyle.Render("No spans in this trace.")
+	}
+
+	labelWidth := minInt(ganttLabelWidth, width/3)
+	barCols := width - labelWidth - 1
+	if barCols < 1 {
+		barCols = 1
+	}
+
+	var lines []string
+	lines = append(lines, title)
+
+	// Time axis: origin on the left, trace duration on the right
+	endLabel := timeutil.FormatDurationNanos(total)
+	axisGap := maxInt(barCols-len("0")-lipgloss.Width(endLabel), 1)
+	lines = append(lines, treeTimestampStyle.Render(
+		strings.Repeat(" ", labelWidth+1)+"0"+strings.Repeat(" ", axisGap)+endLabel))
+
+	contentHeight := height - 2
+
+	// Follow the selection; the stored offset keeps the window steady
+	scrollStart := scrollWindow(m.scrollOffset, m.selectedSpan, contentHeight, len(m.spanTree))
+	end := minInt(scrollStart+contentHeight, len(m.spanTree))
+
+	for i := scrollStart; i < end; i++ {
+		node := m.spanTree[i]
+
+		name := node.span.OperationName
+		if name == "" {
+			name = node.span.OperationType
+		}
+		label := truncate(strings.Repeat(" ", node.depth)+name, labelWidth)
+		label = fmt.Sprintf("%-*s", labelWidth, label)
+
+		offset, barWidth := ganttBar(node.span.StartTime, node.span.DurationMs,
+			traceStart, total, barCols)
+		bar := strings.Repeat(" ", offset) +
+			opStyle(node.span.OperationType).Render(strings.Repeat("█", barWidth))
+
+		if i == m.selectedSpan {
+			label = spanSelectedStyle.Render(label)
+		} else {
+			label = opStyle(node.span.OperationType).Render(label)
+		}
+
+		lines = append(lines, label+" "+bar)
+	}
+
+	return strings.Join(lines, "\n")
+}
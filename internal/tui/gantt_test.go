@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestGanttBarProportional verifies that bar offsets and widths scale
+// with each span's start offset and duration within the trace.
+func TestGanttBarProportional(t *testing.T) {
+	m := newTestModel(
+		newTestSpan("root", "", "PLANNING", 0, 200),
+		newTestSpan("llm", "root", "LLM", 0, 100),
+		newTestSpan("tool", "root", "TOOL", 100, 50),
+		newTestSpan("mem", "root", "MEMORY", 150, 50),
+	)
+
+	traceStart, traceEnd := traceBounds(m.spanTree)
+	total := traceEnd - traceStart
+
+	want := map[string][2]int{
+		"root": {0, 40},
+		"llm":  {0, 20},
+		"tool": {20, 10},
+		"mem":  {30, 10},
+	}
+
+	for _, node := range m.spanTree {
+		off, w := ganttBar(node.span.StartTime, node.span.DurationMs, traceStart, total, 40)
+		exp := want[node.span.SpanID]
+		if off != exp[0] || w != exp[1] {
+			t.Errorf("%s: expected offset=%d width=%d, got offset=%d width=%d",
+				node.span.SpanID, exp[0], exp[1], off, w)
+		}
+	}
+}
+
+// TestGanttBarMinimumWidth verifies that zero-duration spans still
+// occupy a single cell.
+func TestGanttBarMinimumWidth(t *testing.T) {
+	_, w := ganttBar(0, 0, 0, 1e9, 40)
+	if w != 1 {
+		t.Errorf("expected width=1 for an instantaneous span, got %d", w)
+	}
+}
+
+// TestGanttToggle verifies that 'v' switches the timeline rendering mode.
+func TestGanttToggle(t *testing.T) {
+	m := newTestModel(newTestSpan("only", "", "LLM", 0, 10))
+
+	updated, _ := m.handleKey(keyMsg("v"))
+	m = updated.(Model)
+	if !m.ganttMode {
+		t.Fatal("expected gantt mode after pressing v")
+	}
+	if !strings.Contains(renderTimelinePanel(&m, 80, 20), "gantt") {
+		t.Error("expected gantt title in the timeline panel")
+	}
+}
@@ -0,0 +1,144 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+	"github.com/Mr-Dark-debug/oculo/pkg/timeutil"
+)
+
+// groupOrder is the order operation type groups appear in the grouped
+// timeline view.
+var groupOrder = []string{"LLM", "TOOL", "MEMORY", "PLANNING", "RETRIEVAL"}
+
+// groupTitles are the group header labels, keyed by operation type.
+var groupTitles = map[string]string{
+	"LLM":       "LLM",
+	"TOOL":      "Tool",
+	"MEMORY":    "Memory",
+	"PLANNING":  "Planning",
+	"RETRIEVAL": "Retrieval",
+}
+
+// groupRank returns the position of an operation type in groupOrder.
+// Unknown types sort last.
+func groupRank(opType string) int {
+	for i, t := range groupOrder {
+		if t == opType {
+			return i
+		}
+	}
+	return len(groupOrder)
+}
+
+// groupSpans orders spans by operation type group, then by start time
+// within each group. All nodes are flat (depth 0).
+func groupSpans(spans []*database.Span) []spanNode {
+	sorted := make([]*database.Span, len(spans))
+	copy(sorted, spans)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, rj := groupRank(sorted[i].OperationType), groupRank(sorted[j].OperationType)
+		if ri != rj {
+			return ri < rj
+		}
+		return sorted[i].StartTime < sorted[j].StartTime
+	})
+
+	nodes := make([]spanNode, len(sorted))
+	for i, s := range sorted {
+		nodes[i] = spanNode{span: s}
+	}
+	return nodes
+}
+
+// groupRow is one display row of the grouped view: either a group
+// header (node is -1) or the spanTree index of a span.
+type groupRow struct {
+	node   int
+	opType string
+	count  int
+}
+
+// groupRows lays out grouped nodes as display rows, inserting a header
+// before each group.
+func groupRows(nodes []spanNode) []groupRow {
+	counts := make(map[string]int)
+	for _, n := range nodes {
+		counts[n.span.OperationType]++
+	}
+
+	var rows []groupRow
+	for i, n := range nodes {
+		opType := n.span.OperationType
+		if i == 0 || nodes[i-1].span.OperationType != opType {
+			rows = append(rows, groupRow{node: -1, opType: opType, count: counts[opType]})
+		}
+		rows = append(rows, groupRow{node: i, opType: opType})
+	}
+	return rows
+}
+
+// renderGrouped renders the timeline as spans bucketed under operation
+// type headers, each sorted by start time.
+func renderGrouped(m *Model, width, height int) string {
+	titleStyle := panelTitleDimStyle
+	if m.activePane == PaneTimeline {
+		titleStyle = panelTitleStyle
+	}
+
+	title := titleStyle.Render("Timeline") + traceDimStyle.Render("  grouped")
+
+	if len(m.spanTree) == 0 {
+		return title + "\n\n" +
+			emptyStateStyle.Render("No spans in this trace.")
+	}
+
+	rows := groupRows(m.spanTree)
+	selected, _ := m.timelinePos(m.selectedSpan)
+
+	lines := []string{title, ""}
+	contentHeight := height - 2
+	scrollStart := scrollWindow(m.scrollOffset, selected, contentHeight, len(rows))
+	end := minInt(scrollStart+contentHeight, len(rows))
+
+	for r := scrollStart; r < end; r++ {
+		row := rows[r]
+		if row.node < 0 {
+			label := groupTitles[row.opType]
+			if label == "" {
+				label = row.opType
+			}
+			lines = append(lines, detailSectionStyle.Render(
+				fmt.Sprintf("%s (%d)", label, row.count)))
+			continue
+		}
+
+		span := m.spanTree[row.node].span
+		name := span.OperationName
+		if name == "" {
+			name = span.OperationType
+		}
+		name = truncate(name, maxInt(width-20, 10))
+
+		mark := ""
+		if m.bookmarks[span.SpanID] {
+			mark = bookmarkStyle.Render("★") + " "
+		}
+		if spanFailed(span) {
+			mark += traceStatusFail.Render("✗") + " "
+		}
+
+		dur := timeutil.FormatDuration(span.DurationMs)
+		if row.node == m.selectedSpan {
+			lines = append(lines, spanSelectedStyle.Width(width).Render(
+				fmt.Sprintf("  %s%s %s", mark, name, dur)))
+		} else {
+			lines = append(lines, "  "+mark+opStyle(span.OperationType).Render(name)+" "+
+				treeDurationStyle.Render(dur))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
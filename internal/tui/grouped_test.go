@@ -0,0 +1,68 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestGroupedView verifies that the grouped view lists spans under the
+// header for their operation type, in start time order, and keeps the
+// selected span across the toggle.
+func TestGroupedView(t *testing.T) {
+	m := newTestModel(
+		newTestSpan("plan", "", "PLANNING", 0, 100),
+		newTestSpan("llm-late", "plan", "LLM", 50, 10),
+		newTestSpan("search", "plan", "TOOL", 20, 10),
+		newTestSpan("llm-early", "plan", "LLM", 10, 10),
+	)
+	m.selectedSpan = 2 // search
+
+	m, _ = press(m, "o")
+	if got := m.selectedSpanID(); got != "search" {
+		t.Fatalf("expected selection kept on search, got %q", got)
+	}
+
+	var headers []string
+	group := map[string]string{}
+	var order []string
+	current := ""
+	for _, line := range strings.Split(renderGrouped(&m, 60, 30), "\n")[2:] {
+		text := strings.TrimSpace(line)
+		if strings.HasSuffix(text, ")") {
+			current = text
+			headers = append(headers, text)
+			continue
+		}
+		name := strings.Fields(text)[0]
+		group[name] = current
+		order = append(order, name)
+	}
+
+	if got := strings.Join(headers, ","); got != "LLM (2),Tool (1),Planning (1)" {
+		t.Errorf("unexpected headers: %s", got)
+	}
+	for span, want := range map[string]string{
+		"llm-early": "LLM (2)", "llm-late": "LLM (2)", "search": "Tool (1)", "plan": "Planning (1)",
+	} {
+		if group[span] != want {
+			t.Errorf("expected %s under %q, got %q", span, want, group[span])
+		}
+	}
+	if got := strings.Join(order, ","); got != "llm-early,llm-late,search,plan" {
+		t.Errorf("expected start time order within groups, got %s", got)
+	}
+
+	// Navigation skips headers and still loads diffs
+	m, cmd := press(m, "j")
+	if m.selectedSpanID() != "plan" || cmd == nil {
+		t.Errorf("expected j to select plan and load its diffs, got %q", m.selectedSpanID())
+	}
+
+	m, _ = press(m, "o")
+	if got := strings.Join(treeIDs(m.spanTree), ","); got != "plan,llm-late,search,llm-early" {
+		t.Errorf("expected the tree back after a second o, got %s", got)
+	}
+	if m.selectedSpanID() != "plan" {
+		t.Errorf("expected selection kept on plan, got %q", m.selectedSpanID())
+	}
+}
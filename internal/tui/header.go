@@ -4,12 +4,13 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/Mr-Dark-debug/oculo/pkg/timeutil"
 	"github.com/charmbracelet/lipgloss"
 )
 
 // renderHeader produces the top bar:
 //
-//	OCULO  |  Glass Box  |  Trace: a1b2c3  |  Agent: research-bot
+//	OCULO  |  Trace a1b2c3  |  research-bot  |  12 spans  |  2024-05-01 10:00:00.000 · 4.2s
 func renderHeader(m *Model) string {
 	brand := headerBrandStyle.Render("OCULO")
 	sep := headerSepStyle.Render(" \u2502 ")
@@ -29,6 +30,18 @@ func renderHeader(m *Model) string {
 			parts = append(parts, headerMetaStyle.Render(
 				fmt.Sprintf("%d spans", m.stats.TotalSpans)))
 		}
+
+		parts = append(parts, sep)
+		parts = append(parts, headerMetaStyle.Render(traceTiming(m)))
+
+		if m.follow {
+			parts = append(parts, sep)
+			parts = append(parts, headerBrandStyle.Render("FOLLOW"))
+		}
+		if m.redact {
+			parts = append(parts, sep)
+			parts = append(parts, headerBrandStyle.Render("REDACTED"))
+		}
 	} else {
 		parts = append(parts, sep)
 		parts = append(parts, headerMetaStyle.Render("Trace Explorer"))
@@ -36,20 +49,73 @@ func renderHeader(m *Model) string {
 
 	content := strings.Join(parts, "")
 
-	return headerBarStyle.Width(m.width).Render(content)
+	// Keep the header to one row when a narrow terminal wraps it
+	return headerBarStyle.Width(m.width).MaxHeight(1).Render(content)
+}
+
+// traceTiming describes when the open trace started and how long it
+// ran. A trace with no end time is still running; a finished trace
+// that never recorded one is timed to the end of its last span.
+func traceTiming(m *Model) string {
+	t := m.currentTrace
+	start := m.formatTime(t.StartTime)
+
+	end := int64(0)
+	switch {
+	case t.EndTime != nil:
+		end = *t.EndTime
+	case t.Status == "running":
+		return start + " \u00b7 running"
+	default:
+		for _, s := range m.spans {
+			end = max(end, s.StartTime+s.DurationMs*1_000_000)
+		}
+	}
+	if end < t.StartTime {
+		return start
+	}
+	return start + " \u00b7 " + timeutil.FormatDurationNanos(end-t.StartTime)
 }
 
 // renderFooter produces the bottom status bar with keyboard hints.
 func renderFooter(m *Model) string {
 	var left, right string
 
-	if m.searchMode {
+	if m.quitPrompt {
+		left = searchBarStyle.Render("Quit? y/n")
+		right = renderHints([]hint{
+			{"y", "quit"},
+			{"any key", "stay"},
+		})
+	} else if m.jumpMode {
+		cursor := searchCursorStyle.Render(" ")
+		left = searchBarStyle.Render(fmt.Sprintf(": %s%s", m.jumpQuery, cursor))
+		right = renderHints([]hint{
+			{"enter", "jump to span"},
+			{"esc", "cancel"},
+		})
+	} else if m.searchMode {
 		cursor := searchCursorStyle.Render(" ")
 		left = searchBarStyle.Render(fmt.Sprintf("/ %s%s", m.searchQuery, cursor))
 		right = renderHints([]hint{
 			{"enter", "search"},
 			{"esc", "cancel"},
 		})
+	} else if m.showViewer {
+		right = renderHints([]hint{
+			{"\u2191\u2193", "scroll"},
+			{"g/G", "top/bottom"},
+			{"esc", "close"},
+		})
+	} else if m.showAnalysis {
+		if m.statusMsg != "" {
+			left = statusStyle.Render(m.statusMsg)
+		}
+		right = renderHints([]hint{
+			{"\u2191\u2193", "navigate"},
+			{"enter", "go to span"},
+			{"esc", "close"},
+		})
 	} else if m.showTraceList {
 		if m.statusMsg != "" {
 			left = statusStyle.Render(m.statusMsg)
@@ -57,16 +123,23 @@ func renderFooter(m *Model) string {
 		right = renderHints([]hint{
 			{"\u2191\u2193", "navigate"},
 			{"enter", "select"},
+			{"t", "time"},
 			{"/", "search"},
 			{"q", "quit"},
 		})
 	} else {
+		if threshold := minDurations[m.minDuration]; threshold > 0 {
+			left = filterBadgeStyle.Render("filtered: >" + timeutil.FormatDuration(threshold))
+		}
 		if m.statusMsg != "" {
-			left = statusStyle.Render(m.statusMsg)
+			left += statusStyle.Render(m.statusMsg)
 		}
 		right = renderHints([]hint{
 			{"\u2191\u2193", "navigate"},
 			{"tab", "pane"},
+			{"v", "gantt"},
+			{"a", "analyze"},
+			{"F", "follow"},
 			{"d", "diff"},
 			{"/", "search"},
 			{"esc", "back"},
@@ -0,0 +1,44 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+)
+
+// TestHeaderTraceTiming verifies that the header shows the trace's
+// start time and wall-clock duration, or "running" while it has no end.
+func TestHeaderTraceTiming(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local).UnixNano()
+	end := start + int64(4200*time.Millisecond)
+
+	m := newTestModel(newTestSpan("s0", "", "LLM", 0, 10))
+	m.width = 200
+	m.currentTrace = &database.Trace{TraceID: "trace-test", AgentName: "agent", StartTime: start, EndTime: &end, Status: "completed"}
+
+	out := renderHeader(&m)
+	for _, want := range []string{"2024-05-01 10:00:00.000", "4.2s"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in header, got:\n%s", want, out)
+		}
+	}
+
+	m.currentTrace.EndTime, m.currentTrace.Status = nil, "running"
+	if out := renderHeader(&m); !strings.Contains(out, "running") {
+		t.Errorf("expected a running trace to say so, got:\n%s", out)
+	}
+
+	// Without an end time a finished trace is timed to its last span
+	m.currentTrace.Status = "failed"
+	m.spans = []*database.Span{{StartTime: start + int64(time.Second), DurationMs: 1500}}
+	if out := renderHeader(&m); !strings.Contains(out, "2.5s") {
+		t.Errorf("expected the duration to the last span end, got:\n%s", out)
+	}
+
+	m.width = 40
+	if out := renderHeader(&m); strings.Contains(out, "\n") {
+		t.Errorf("expected a one-row header on a narrow terminal, got:\n%s", out)
+	}
+}
@@ -1,7 +1,11 @@
 package tui
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/Mr-Dark-debug/oculo/internal/database"
+	"github.com/Mr-Dark-debug/oculo/pkg/timeutil"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -13,27 +17,42 @@ import (
 type spanNode struct {
 	span  *database.Span
 	depth int
+	cycle bool // parent chain loops back on itself; shown as a root
 }
 
 // buildSpanTree constructs a flat depth-ordered list from parent relationships.
+// Orphans — spans whose parent is not part of the trace, e.g. because of
+// sampling or partial ingestion — are shown as roots. Each span is emitted
+// at most once, so parent cycles from buggy instrumentation cannot hang
+// the walk; the span where a cycle is broken is marked.
 func buildSpanTree(spans []*database.Span) []spanNode {
 	if len(spans) == 0 {
 		return nil
 	}
 
+	present := make(map[string]bool, len(spans))
+	for _, s := range spans {
+		present[s.SpanID] = true
+	}
+
 	childrenOf := make(map[string][]*database.Span)
 	for _, s := range spans {
 		parentID := ""
-		if s.ParentSpanID != nil {
+		if s.ParentSpanID != nil && present[*s.ParentSpanID] {
 			parentID = *s.ParentSpanID
 		}
 		childrenOf[parentID] = append(childrenOf[parentID], s)
 	}
 
 	var result []spanNode
+	visited := make(map[string]bool, len(spans))
 	var walk func(parentID string, depth int)
 	walk = func(parentID string, depth int) {
 		for _, child := range childrenOf[parentID] {
+			if visited[child.SpanID] {
+				continue
+			}
+			visited[child.SpanID] = true
 			result = append(result, spanNode{span: child, depth: depth})
 			walk(child.SpanID, depth+1)
 		}
@@ -41,16 +60,25 @@ func buildSpanTree(spans []*database.Span) []spanNode {
 
 	walk("", 0)
 
-	// Fallback: if no root spans found, use flat list
-	if len(result) == 0 {
-		for _, s := range spans {
-			result = append(result, spanNode{span: s, depth: 0})
+	// Anything left is unreachable from a root, so its parent chain is a
+	// cycle. Break each cycle at its earliest span.
+	for _, s := range spans {
+		if visited[s.SpanID] {
+			continue
 		}
+		visited[s.SpanID] = true
+		result = append(result, spanNode{span: s, depth: 0, cycle: true})
+		walk(s.SpanID, 1)
 	}
 
 	return result
 }
 
+// spanFailed reports whether a span finished with a non-ok status.
+func spanFailed(s *database.Span) bool {
+	return s.Status != "" && s.Status != "ok"
+}
+
 // ────────────────────────────────────────────────────────────
 // Operation type rendering
 // ────────────────────────────────────────────────────────────
@@ -91,6 +119,26 @@ func opStyle(opType string) lipgloss.Style {
 	}
 }
 
+// ────────────────────────────────────────────────────────────
+// Scrolling
+// ────────────────────────────────────────────────────────────
+
+// scrollWindow returns the first visible row of a list of total rows
+// shown rows at a time, such that selected stays visible. Like a list
+// widget, the window only moves when the selection leaves it.
+func scrollWindow(offset, selected, rows, total int) int {
+	if rows <= 0 || total <= rows {
+		return 0
+	}
+	if selected < offset {
+		offset = selected
+	}
+	if selected >= offset+rows {
+		offset = selected - rows + 1
+	}
+	return clamp(offset, 0, total-rows)
+}
+
 // ────────────────────────────────────────────────────────────
 // String helpers
 // ────────────────────────────────────────────────────────────
@@ -110,6 +158,98 @@ func truncate(s string, maxLen int) string {
 	return string(runes[:maxLen-3]) + "..."
 }
 
+// hpan returns the part of s visible through a window of width columns
+// that has been scrolled offset runes to the right. Hidden content on
+// either side is marked with an ellipsis.
+func hpan(s string, offset, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	runes := []rune(s)
+	offset = clamp(offset, 0, len(runes))
+	runes = runes[offset:]
+
+	prefix := ""
+	if offset > 0 && width > 1 {
+		prefix = "\u2026"
+		width--
+	}
+	if len(runes) > width {
+		return prefix + string(runes[:width-1]) + "\u2026"
+	}
+	return prefix + string(runes)
+}
+
+// highlight renders s in base with every case-insensitive occurrence of
+// query picked out in searchMatchStyle.
+func highlight(s, query string, base lipgloss.Style) string {
+	if query == "" {
+		return base.Render(s)
+	}
+	// Case-folding can change byte lengths, so match on runes
+	runes := []rune(s)
+	lower := []rune(strings.ToLower(s))
+	q := []rune(strings.ToLower(query))
+	if len(lower) != len(runes) {
+		return base.Render(s)
+	}
+
+	var b strings.Builder
+	start := 0
+	for i := 0; i+len(q) <= len(lower); {
+		if string(lower[i:i+len(q)]) != string(q) {
+			i++
+			continue
+		}
+		if i > start {
+			b.WriteString(base.Render(string(runes[start:i])))
+		}
+		b.WriteString(searchMatchStyle.Render(string(runes[i : i+len(q)])))
+		i += len(q)
+		start = i
+	}
+	if start < len(runes) {
+		b.WriteString(base.Render(string(runes[start:])))
+	}
+	return b.String()
+}
+
+// longestLine returns the rune length of the longest line in s.
+func longestLine(s string) int {
+	longest := 0
+	for _, line := range strings.Split(s, "\n") {
+		longest = maxInt(longest, len([]rune(line)))
+	}
+	return longest
+}
+
+// formatTime formats a Unix nanosecond timestamp with its date, using
+// the configured layout if there is one.
+func (m *Model) formatTime(ns int64) string {
+	if m.opts.TimeLayout != "" {
+		return timeutil.FormatTimestampLayout(ns, m.opts.TimeLayout)
+	}
+	return timeutil.FormatTimestampFull(ns)
+}
+
+// formatClock formats a Unix nanosecond timestamp as a time of day,
+// using the configured layout if there is one.
+func (m *Model) formatClock(ns int64) string {
+	if m.opts.TimeLayout != "" {
+		return timeutil.FormatTimestampLayout(ns, m.opts.TimeLayout)
+	}
+	return timeutil.FormatTimestamp(ns)
+}
+
+// redacted returns s, or a placeholder giving only its length while
+// redaction is on.
+func (m *Model) redacted(s string) string {
+	if !m.redact {
+		return s
+	}
+	return fmt.Sprintf("[redacted \u2014 %d chars]", len([]rune(s)))
+}
+
 // shortID returns first n characters of an ID string.
 func shortID(id string, n int) string {
 	if len(id) <= n {
@@ -0,0 +1,177 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newTestSpan builds a span starting startMs milliseconds after a fixed
+// base time. parent may be empty for root spans.
+func newTestSpan(id, parent, opType string, startMs, durationMs int64) *database.Span {
+	sp := &database.Span{
+		SpanID:        id,
+		TraceID:       "trace-test",
+		OperationType: opType,
+		OperationName: id,
+		StartTime:     1_700_000_000_000_000_000 + startMs*1e6,
+		DurationMs:    durationMs,
+		Status:        "ok",
+	}
+	if parent != "" {
+		sp.ParentSpanID = &parent
+	}
+	return sp
+}
+
+// newTestModel returns a sized model showing the given spans in the
+// main three-pane layout.
+func newTestModel(spans ...*database.Span) Model {
+	m := NewModel(nil)
+	m.width = 120
+	m.height = 40
+	m.showTraceList = false
+	m.spans = spans
+	m.spanTree = buildSpanTree(spans)
+	return m
+}
+
+// keyMsg builds a key press message for a printable key or a named key
+// such as "enter" or "esc".
+func keyMsg(key string) tea.KeyMsg {
+	switch key {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case "backspace":
+		return tea.KeyMsg{Type: tea.KeyBackspace}
+	case "ctrl+c":
+		return tea.KeyMsg{Type: tea.KeyCtrlC}
+	case "pgup":
+		return tea.KeyMsg{Type: tea.KeyPgUp}
+	case "pgdown":
+		return tea.KeyMsg{Type: tea.KeyPgDown}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+// fakeStore serves canned spans and memory events. Store methods the
+// TUI does not call are left to the embedded nil interface and panic
+// if reached.
+type fakeStore struct {
+	database.Store
+	traces []*database.Trace
+	spans  []*database.Span
+	events map[string][]*database.MemoryEvent
+	calls  map[string][]*database.ToolCall
+
+	timelineQueries []string // trace IDs passed to QueryTimeline
+}
+
+func (f *fakeStore) QueryTraces(filter database.TraceFilter) ([]*database.Trace, error) {
+	return f.traces, nil
+}
+
+func (f *fakeStore) QueryTimeline(traceID string) ([]*database.Span, error) {
+	f.timelineQueries = append(f.timelineQueries, traceID)
+	return f.spans, nil
+}
+
+func (f *fakeStore) GetMemoryDiffs(spanID string) ([]*database.MemoryEvent, error) {
+	return f.events[spanID], nil
+}
+
+func (f *fakeStore) GetToolCalls(spanID string) ([]*database.ToolCall, error) {
+	return f.calls[spanID], nil
+}
+
+func (f *fakeStore) GetTraceStats(traceID string) (*database.TraceStats, error) {
+	stats := &database.TraceStats{TraceID: traceID, TotalSpans: len(f.spans)}
+	for _, s := range f.spans {
+		switch s.OperationType {
+		case "LLM":
+			stats.LLMCalls++
+		case "TOOL":
+			stats.ToolCalls++
+		case "MEMORY":
+			stats.MemoryOps++
+		}
+		stats.TotalPromptTokens += s.PromptTokens
+		stats.TotalCompletionTokens += s.CompletionTokens
+		stats.TotalDurationMs += s.DurationMs
+		if s.Status == "error" {
+			stats.ErrorSpans++
+		}
+	}
+	for _, evs := range f.events {
+		stats.MemoryEventCount += len(evs)
+	}
+	return stats, nil
+}
+
+// treeIDs returns the span IDs of a tree in display order.
+func treeIDs(tree []spanNode) []string {
+	ids := make([]string, len(tree))
+	for i, n := range tree {
+		ids[i] = n.span.SpanID
+	}
+	return ids
+}
+
+// TestBuildSpanTreeOrphans verifies that a span whose parent is missing
+// from the trace is shown as a root instead of being dropped.
+func TestBuildSpanTreeOrphans(t *testing.T) {
+	tree := buildSpanTree([]*database.Span{
+		newTestSpan("root", "", "PLANNING", 0, 100),
+		newTestSpan("child", "root", "LLM", 10, 10),
+		newTestSpan("orphan", "missing-parent", "TOOL", 20, 10),
+		newTestSpan("orphan-child", "orphan", "MEMORY", 25, 1),
+	})
+
+	got := strings.Join(treeIDs(tree), ",")
+	if got != "root,child,orphan,orphan-child" {
+		t.Fatalf("unexpected tree order: %s", got)
+	}
+	if tree[2].depth != 0 {
+		t.Errorf("expected orphan at depth 0, got %d", tree[2].depth)
+	}
+	if tree[3].depth != 1 {
+		t.Errorf("expected orphan's child at depth 1, got %d", tree[3].depth)
+	}
+}
+
+// TestBuildSpanTreeCycle verifies that a two-span parent cycle returns
+// promptly with each span exactly once.
+func TestBuildSpanTreeCycle(t *testing.T) {
+	tree := buildSpanTree([]*database.Span{
+		newTestSpan("root", "", "PLANNING", 0, 100),
+		newTestSpan("a", "b", "LLM", 10, 10),
+		newTestSpan("b", "a", "TOOL", 20, 10),
+	})
+
+	got := strings.Join(treeIDs(tree), ",")
+	if got != "root,a,b" {
+		t.Fatalf("expected each span once, got %s", got)
+	}
+	if !tree[1].cycle {
+		t.Error("expected the span breaking the cycle to be marked")
+	}
+	if tree[2].cycle || tree[2].depth != 1 {
+		t.Errorf("expected b under a at depth 1, got depth=%d cycle=%v", tree[2].depth, tree[2].cycle)
+	}
+}
+
+// TestBuildSpanTreeSelfParent verifies that a span listing itself as its
+// parent is emitted once.
+func TestBuildSpanTreeSelfParent(t *testing.T) {
+	tree := buildSpanTree([]*database.Span{newTestSpan("self", "self", "LLM", 0, 10)})
+	if len(tree) != 1 || !tree[0].cycle {
+		t.Fatalf("expected one marked span, got %+v", tree)
+	}
+}
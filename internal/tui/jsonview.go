@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/Mr-Dark-debug/oculo/pkg/jsonutil"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// ────────────────────────────────────────────────────────────
+// JSON syntax highlighting
+// ────────────────────────────────────────────────────────────
+
+// jsonColors maps JSON token kinds to the current theme's styles.
+func jsonColors() jsonutil.ColorFns {
+	render := func(style lipgloss.Style) func(string) string {
+		return func(s string) string { return style.Render(s) }
+	}
+	return jsonutil.ColorFns{
+		Key:     render(jsonKeyStyle),
+		String:  render(jsonStringStyle),
+		Number:  render(jsonNumberStyle),
+		Literal: render(jsonLiteralStyle),
+		Punct:   render(jsonPunctStyle),
+	}
+}
+
+// maxHighlightBytes is the largest document highlightJSON colors in
+// full. Bigger ones are cut short and left plain so the pane stays
+// responsive.
+const maxHighlightBytes = 256 << 10
+
+// highlightJSON pretty-prints a JSON document with syntax colors and
+// splits it into lines. Invalid JSON comes back as-is.
+func highlightJSON(s string) []string {
+	if len(s) > maxHighlightBytes {
+		pretty, _ := jsonutil.PrettyJSONLimit(s, maxHighlightBytes)
+		return strings.Split(pretty, "\n")
+	}
+	return strings.Split(jsonutil.PrettyJSONColored(s, jsonColors()), "\n")
+}
+
+// hpanStyled is hpan for a line that already carries styling: it pans
+// and truncates by visible cells, keeping the escape sequences intact.
+func hpanStyled(line string, offset, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	offset = clamp(offset, 0, ansi.StringWidth(line))
+	line = ansi.TruncateLeft(line, offset, "")
+
+	prefix := ""
+	if offset > 0 && width > 1 {
+		prefix = jsonPunctStyle.Render("…")
+		width--
+	}
+	return prefix + ansi.Truncate(line, width, "…")
+}
@@ -0,0 +1,76 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// tagStyle returns a style that wraps rendered text in tag(...) so tests
+// can see which style was applied without terminal colors.
+func tagStyle(tag string) lipgloss.Style {
+	return lipgloss.NewStyle().Transform(func(s string) string { return tag + "(" + s + ")" })
+}
+
+// TestHighlightJSON verifies that metadata in the detail pane is drawn
+// with distinct styles for keys, strings, numbers, literals and
+// punctuation.
+func TestHighlightJSON(t *testing.T) {
+	saved := []lipgloss.Style{jsonKeyStyle, jsonStringStyle, jsonNumberStyle, jsonLiteralStyle, jsonPunctStyle}
+	jsonKeyStyle, jsonStringStyle = tagStyle("K"), tagStyle("S")
+	jsonNumberStyle, jsonLiteralStyle, jsonPunctStyle = tagStyle("N"), tagStyle("L"), tagStyle("P")
+	defer func() {
+		jsonKeyStyle, jsonStringStyle, jsonNumberStyle, jsonLiteralStyle, jsonPunctStyle =
+			saved[0], saved[1], saved[2], saved[3], saved[4]
+	}()
+
+	sp := newTestSpan("s0", "", "LLM", 0, 10)
+	meta := `{"region":"eu-west-1","retries":-2.5e3,"ok":true}`
+	sp.Metadata = &meta
+	m := newTestModel(sp)
+
+	out := renderDetail(&m, 80, 60)
+	for _, want := range []string{
+		`K("region")P(:) S("eu-west-1")P(,)`,
+		`K("retries")P(:) N(-2.5e3)P(,)`,
+		`K("ok")P(:) L(true)`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in detail, got:\n%s", want, out)
+		}
+	}
+}
+
+// TestHpanStyled verifies that panning a styled line matches hpan on
+// the same text and keeps the styling intact.
+func TestHpanStyled(t *testing.T) {
+	plain := `  "region": "eu-west-1",`
+	styled := "  \x1b[34m\"region\"\x1b[0m: \x1b[32m\"eu-west-1\"\x1b[0m,"
+
+	for _, tc := range []struct{ offset, width int }{{0, 80}, {0, 10}, {5, 80}, {5, 10}, {100, 10}} {
+		want := hpan(plain, tc.offset, tc.width)
+		got := hpanStyled(styled, tc.offset, tc.width)
+		if stripped := ansi.Strip(got); stripped != want {
+			t.Errorf("offset %d width %d: got %q, want %q", tc.offset, tc.width, stripped, want)
+		}
+	}
+	if got := hpanStyled(styled, 0, 80); !strings.Contains(got, "\x1b[32m\"eu-west-1\"") {
+		t.Errorf("expected styling kept, got %q", got)
+	}
+}
+
+// TestHighlightJSONHugeDocument verifies that an oversized payload is
+// cut short rather than expanded in full.
+func TestHighlightJSONHugeDocument(t *testing.T) {
+	huge := `{"blob":"` + strings.Repeat("x", maxHighlightBytes) + `","after":1}`
+
+	lines := highlightJSON(huge)
+	if last := lines[len(lines)-1]; !strings.Contains(last, "truncated") {
+		t.Errorf("expected a truncation marker, got last line %q", last)
+	}
+	if total := len(strings.Join(lines, "\n")); total > maxHighlightBytes+64 {
+		t.Errorf("expected output near the %d byte cap, got %d bytes", maxHighlightBytes, total)
+	}
+}
@@ -2,7 +2,11 @@ package tui
 
 import (
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/Mr-Dark-debug/oculo/internal/analysis"
 	"github.com/Mr-Dark-debug/oculo/internal/database"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -22,6 +26,31 @@ const (
 	PaneMemoryDiff
 )
 
+// ────────────────────────────────────────────────────────────
+// Layout
+// ────────────────────────────────────────────────────────────
+
+// Pane split proportions, in percent. The user can adjust them at
+// runtime within the min/max bounds.
+const (
+	defaultSplitLeft = 45
+	minSplitLeft     = 20
+	maxSplitLeft     = 80
+
+	defaultSplitTop = 65
+	minSplitTop     = 30
+	maxSplitTop     = 85
+
+	splitStep = 5
+)
+
+// minDurations are the timeline filter thresholds, in milliseconds,
+// cycled with the m key. Zero shows every span.
+var minDurations = []int64{0, 1, 10, 100, 1000}
+
+// followInterval is how often the timeline is reloaded in follow mode.
+const followInterval = 2 * time.Second
+
 // ────────────────────────────────────────────────────────────
 // Model
 // ────────────────────────────────────────────────────────────
@@ -38,7 +67,10 @@ type Model struct {
 	spans        []*database.Span
 	spanTree     []spanNode
 	memoryDiffs  []*database.MemoryEvent
+	toolCalls    []*database.ToolCall
+	diffsSpanID  string // span the loaded memoryDiffs and toolCalls belong to
 	stats        *database.TraceStats
+	analysis     *analysis.AnalysisReport
 
 	// UI state
 	activePane    Pane
@@ -46,23 +78,90 @@ type Model struct {
 	selectedTrace int
 	scrollOffset  int
 	diffScroll    int
+	diffNamespace string // memory diff filter; "" shows all namespaces
+	detailScroll  int
+	hScroll       int // horizontal pan of detail and diff content
 	width         int
 	height        int
+	splitLeft     int // timeline width, percent of terminal width
+	splitTop      int // top row height, percent of body height
 	showTraceList bool
+	absoluteTimes bool // trace list shows full timestamps, not "3m ago"
 	searchMode    bool
 	searchQuery   string
+	diffSearch    bool // the search filters the memory diff pane
+	jumpMode      bool
+	jumpQuery     string
+	ganttMode     bool
+	groupMode     bool            // bucket the timeline by operation type
+	redact        bool            // mask prompt, completion and memory values
+	theme         Theme           // palette for status and diff polarity
+	minDuration   int             // index into minDurations
+	follow        bool            // live tail: reload and select the newest span
+	followGen     int             // identifies the current refresh tick chain
+	bookmarks     map[string]bool // span IDs flagged for review
+
+	// Analysis overlay
+	showAnalysis   bool
+	analysisCursor int
+
+	// Built-in viewer, used when no external pager is set
+	showViewer   bool
+	viewerText   string
+	viewerScroll int
 
 	// Status
-	statusMsg string
-	err       error
+	statusMsg  string
+	quitPrompt bool // waiting for y/n after q
+	err        error
+
+	opts Options
+}
+
+// Options configures optional TUI behavior.
+type Options struct {
+	// ConfirmQuit asks "Quit? y/n" before q exits. ctrl+c always
+	// quits immediately.
+	ConfirmQuit bool
+
+	// TimeLayout is a time.Format layout for every timestamp the TUI
+	// shows. Empty keeps the defaults: full date and time in the trace
+	// list and detail pane, time of day in the memory diff.
+	TimeLayout string
+
+	// PrefsPath is where view preferences are loaded from at startup
+	// and saved to when they change. Empty disables persistence.
+	PrefsPath string
+
+	// Theme is the palette to start with. Empty keeps the saved
+	// preference, or the default palette without one.
+	Theme Theme
+
+	// Pricing overrides the built-in model prices in the analysis
+	// overlay and the cost bar, as --pricing does for the CLI.
+	Pricing analysis.PricingTable
 }
 
 // NewModel creates a new TUI model backed by the given store.
 func NewModel(store database.Store) Model {
+	return NewModelWithOptions(store, Options{})
+}
+
+// NewModelWithOptions creates a new TUI model backed by the given store
+// and configured by opts.
+func NewModelWithOptions(store database.Store, opts Options) Model {
+	if opts.Theme != ThemeDefault {
+		applyTheme(opts.Theme)
+	}
 	return Model{
 		store:         store,
+		opts:          opts,
+		bookmarks:     make(map[string]bool),
+		splitLeft:     defaultSplitLeft,
+		splitTop:      defaultSplitTop,
 		showTraceList: true,
 		statusMsg:     "Loading traces...",
+		theme:         opts.Theme,
 	}
 }
 
@@ -72,10 +171,18 @@ func NewModel(store database.Store) Model {
 
 type tracesLoadedMsg []*database.Trace
 type timelineLoadedMsg struct {
-	spans []*database.Span
-	stats *database.TraceStats
+	traceID string
+	spans   []*database.Span
+	stats   *database.TraceStats
+	refresh bool // reload of the open trace; keep the view state
+}
+type memoryDiffsLoadedMsg struct {
+	spanID string
+	events []*database.MemoryEvent
+	calls  []*database.ToolCall
 }
-type memoryDiffsLoadedMsg []*database.MemoryEvent
+type analysisLoadedMsg struct{ report *analysis.AnalysisReport }
+type followTickMsg struct{ gen int }
 type errMsg struct{ err error }
 
 func (e errMsg) Error() string { return e.err.Error() }
@@ -85,6 +192,9 @@ func (e errMsg) Error() string { return e.err.Error() }
 // ────────────────────────────────────────────────────────────
 
 func (m Model) Init() tea.Cmd {
+	if m.opts.PrefsPath != "" {
+		return tea.Batch(m.loadTraces(), m.loadPrefs())
+	}
 	return m.loadTraces()
 }
 
@@ -99,6 +209,15 @@ func (m Model) loadTraces() tea.Cmd {
 }
 
 func (m Model) loadTimeline(traceID string) tea.Cmd {
+	return m.fetchTimeline(traceID, false)
+}
+
+// refreshTimeline reloads the open trace without resetting the view.
+func (m Model) refreshTimeline(traceID string) tea.Cmd {
+	return m.fetchTimeline(traceID, true)
+}
+
+func (m Model) fetchTimeline(traceID string, refresh bool) tea.Cmd {
 	return func() tea.Msg {
 		spans, err := m.store.QueryTimeline(traceID)
 		if err != nil {
@@ -108,17 +227,39 @@ func (m Model) loadTimeline(traceID string) tea.Cmd {
 		if err != nil {
 			return errMsg{err}
 		}
-		return timelineLoadedMsg{spans: spans, stats: stats}
+		return timelineLoadedMsg{traceID: traceID, spans: spans, stats: stats, refresh: refresh}
 	}
 }
 
+// loadMemoryDiffs loads what the diff and detail panes show for a span
+// beyond the span itself: its memory events and its tool calls.
 func (m Model) loadMemoryDiffs(spanID string) tea.Cmd {
 	return func() tea.Msg {
 		diffs, err := m.store.GetMemoryDiffs(spanID)
 		if err != nil {
 			return errMsg{err}
 		}
-		return memoryDiffsLoadedMsg(diffs)
+		calls, err := m.store.GetToolCalls(spanID)
+		if err != nil {
+			return errMsg{err}
+		}
+		return memoryDiffsLoadedMsg{spanID: spanID, events: diffs, calls: calls}
+	}
+}
+
+func followTick(gen int) tea.Cmd {
+	return tea.Tick(followInterval, func(time.Time) tea.Msg {
+		return followTickMsg{gen: gen}
+	})
+}
+
+func (m Model) runAnalysis(traceID string) tea.Cmd {
+	return func() tea.Msg {
+		report, err := analysis.NewAnalyzerWithPricing(m.store, m.opts.Pricing).FullAnalysis(traceID)
+		if err != nil {
+			return errMsg{err}
+		}
+		return analysisLoadedMsg{report: report}
 	}
 }
 
@@ -132,10 +273,36 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		m.scrollToSelection()
 		return m, nil
 
 	case tea.KeyMsg:
-		return m.handleKey(msg)
+		if m.opts.PrefsPath == "" {
+			return m.handleKey(msg)
+		}
+		// Save preferences whenever a key changes them
+		before := m.preferences()
+		updated, cmd := m.handleKey(msg)
+		next := updated.(Model)
+		if after := next.preferences(); after != before {
+			return next, tea.Batch(cmd, next.savePrefs(after))
+		}
+		return next, cmd
+
+	case prefsLoadedMsg:
+		selectedID := m.selectedSpanID()
+		m.applyPreferences(msg.prefs)
+		if len(m.spans) > 0 {
+			m.spanTree = m.buildTimeline()
+			if idx := m.findSpan(selectedID); idx >= 0 {
+				m.selectedSpan = idx
+			}
+			m.scrollToSelection()
+		}
+		return m, nil
+
+	case tea.MouseMsg:
+		return m.handleMouse(msg)
 
 	case tracesLoadedMsg:
 		m.traces = []*database.Trace(msg)
@@ -147,10 +314,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case timelineLoadedMsg:
+		if msg.refresh {
+			return m.applyRefresh(msg)
+		}
 		m.spans = msg.spans
 		m.stats = msg.stats
-		m.spanTree = buildSpanTree(msg.spans)
+		m.spanTree = m.buildTimeline()
 		m.selectedSpan = 0
+		m.scrollOffset = 0
 		m.showTraceList = false
 		m.activePane = PaneTimeline
 		m.statusMsg = fmt.Sprintf("%d spans  %d LLM calls  %d tokens",
@@ -162,8 +333,38 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case memoryDiffsLoadedMsg:
-		m.memoryDiffs = []*database.MemoryEvent(msg)
+		// Drop results for a span that is no longer selected
+		if msg.spanID != m.selectedSpanID() {
+			return m, nil
+		}
+		m.memoryDiffs = msg.events
+		m.toolCalls = msg.calls
+		m.diffsSpanID = msg.spanID
+		if !slices.Contains(diffNamespaces(msg.events), m.diffNamespace) {
+			m.diffNamespace = ""
+		}
 		m.diffScroll = 0
+		m.hScroll = 0
+		return m, nil
+
+	case pagerClosedMsg:
+		if msg.err != nil {
+			m.statusMsg = "Pager: " + msg.err.Error()
+		}
+		return m, nil
+
+	case followTickMsg:
+		// Letting the tick lapse is what stops following; ticks from an
+		// earlier chain are dropped so toggling never doubles the rate
+		if !m.follow || msg.gen != m.followGen || m.currentTrace == nil {
+			return m, nil
+		}
+		return m, tea.Batch(m.refreshTimeline(m.currentTrace.TraceID), followTick(m.followGen))
+
+	case analysisLoadedMsg:
+		m.analysis = msg.report
+		m.analysisCursor = 0
+		m.statusMsg = fmt.Sprintf("Analysis complete  %d warnings", len(msg.report.Warnings))
 		return m, nil
 
 	case errMsg:
@@ -179,30 +380,88 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	key := msg.String()
 
+	// ── Quit confirmation ──
+
+	if m.quitPrompt {
+		switch key {
+		case "y", "Y", "ctrl+c":
+			return m, tea.Quit
+		}
+		m.quitPrompt = false
+		return m, nil
+	}
+
+	// ── Jump-to-span prompt ──
+
+	if m.jumpMode {
+		switch key {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			m.jumpMode = false
+			m.jumpQuery = ""
+		case "enter":
+			m.jumpMode = false
+			return m, m.jumpToSpan(m.jumpQuery)
+		case "backspace":
+			if len(m.jumpQuery) > 0 {
+				m.jumpQuery = m.jumpQuery[:len(m.jumpQuery)-1]
+			}
+		default:
+			if len(key) == 1 {
+				m.jumpQuery += key
+			}
+		}
+		return m, nil
+	}
+
 	// ── Global ──
 
 	switch key {
-	case "q", "ctrl+c":
+	case "ctrl+c":
+		return m, tea.Quit
+
+	case "q":
+		if m.searchMode {
+			break // typed into the query below
+		}
+		if m.opts.ConfirmQuit {
+			m.quitPrompt = true
+			return m, nil
+		}
 		return m, tea.Quit
 
 	case "tab":
 		if !m.showTraceList && !m.searchMode {
 			m.activePane = (m.activePane + 1) % 3
+			return m, m.ensureMemoryDiffs()
 		}
 		return m, nil
 
 	case "shift+tab":
 		if !m.showTraceList && !m.searchMode {
 			m.activePane = (m.activePane + 2) % 3
+			return m, m.ensureMemoryDiffs()
 		}
 		return m, nil
 
 	case "esc":
-		if m.searchMode {
+		if m.searchMode || m.diffSearch || m.searchQuery != "" {
+			// Keep the selected trace when the ID filter goes away
+			if traces := m.visibleTraces(); m.showTraceList && m.selectedTrace < len(traces) {
+				m.selectedTrace = slices.Index(m.traces, traces[m.selectedTrace])
+			}
 			m.searchMode = false
 			m.searchQuery = ""
+			m.diffSearch = false
+			m.diffScroll = 0
+		} else if m.showViewer {
+			m.showViewer = false
+		} else if m.showAnalysis {
+			m.showAnalysis = false
 		} else if !m.showTraceList {
 			m.showTraceList = true
+			m.follow = false
 			m.activePane = PaneTimeline
 		}
 		return m, nil
@@ -211,6 +470,8 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if !m.searchMode {
 			m.searchMode = true
 			m.searchQuery = ""
+			m.diffSearch = !m.showTraceList && !m.showAnalysis && m.activePane == PaneMemoryDiff
+			m.diffScroll = 0
 		}
 		return m, nil
 	}
@@ -226,21 +487,75 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if len(m.searchQuery) > 0 {
 				m.searchQuery = m.searchQuery[:len(m.searchQuery)-1]
 			}
+			m.diffScroll = 0
+			if m.showTraceList {
+				m.selectedTrace = 0
+			}
 			return m, nil
 		default:
 			if len(key) == 1 {
 				m.searchQuery += key
 			}
+			m.diffScroll = 0
+			if m.showTraceList {
+				m.selectedTrace = 0
+			}
 			return m, nil
 		}
 	}
 
+	// ── Built-in viewer ──
+
+	if m.showViewer {
+		switch key {
+		case "j", "down":
+			m.viewerScroll = minInt(m.viewerScroll+1, m.maxViewerScroll())
+		case "k", "up":
+			m.viewerScroll = maxInt(m.viewerScroll-1, 0)
+		case "pgdown", " ":
+			m.viewerScroll = minInt(m.viewerScroll+m.viewerRows(), m.maxViewerScroll())
+		case "pgup":
+			m.viewerScroll = maxInt(m.viewerScroll-m.viewerRows(), 0)
+		case "g", "home":
+			m.viewerScroll = 0
+		case "G", "end":
+			m.viewerScroll = m.maxViewerScroll()
+		case "p":
+			m.showViewer = false
+		}
+		return m, nil
+	}
+
+	// ── Analysis overlay ──
+
+	if m.showAnalysis {
+		rows := buildAnalysisRows(m.analysis)
+		switch key {
+		case "j", "down":
+			if m.analysisCursor < len(rows)-1 {
+				m.analysisCursor++
+			}
+		case "k", "up":
+			if m.analysisCursor > 0 {
+				m.analysisCursor--
+			}
+		case "enter":
+			if m.analysisCursor < len(rows) && rows[m.analysisCursor].spanID != "" {
+				return m, m.jumpToSpan(rows[m.analysisCursor].spanID)
+			}
+		case "a":
+			m.showAnalysis = false
+		}
+		return m, nil
+	}
+
 	// ── Trace list mode ──
 
 	if m.showTraceList {
+		traces := m.visibleTraces()
 		switch key {
 		case "j", "down":
-			if m.selectedTrace < len(m.traces)-1 {
+			if m.selectedTrace < len(traces)-1 {
 				m.selectedTrace++
 			}
 		case "k", "up":
@@ -248,14 +563,75 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.selectedTrace--
 			}
 		case "enter":
-			if m.selectedTrace < len(m.traces) {
+			if m.selectedTrace < len(traces) {
+				// The ID filter has done its job; don't carry it into
+				// the timeline as a search
+				m.selectedTrace = slices.Index(m.traces, traces[m.selectedTrace])
+				m.searchQuery = ""
 				m.currentTrace = m.traces[m.selectedTrace]
 				return m, m.loadTimeline(m.currentTrace.TraceID)
 			}
+		case "t":
+			m.absoluteTimes = !m.absoluteTimes
+		case "T":
+			m.toggleTheme()
 		}
 		return m, nil
 	}
 
+	// ── Main layout ──
+
+	if key == ":" {
+		m.jumpMode = true
+		m.jumpQuery = ""
+		return m, nil
+	}
+
+	switch key {
+	case "<":
+		m.splitLeft = clamp(m.splitLeft-splitStep, minSplitLeft, maxSplitLeft)
+		return m, nil
+	case ">":
+		m.splitLeft = clamp(m.splitLeft+splitStep, minSplitLeft, maxSplitLeft)
+		return m, nil
+	case "-":
+		m.splitTop = clamp(m.splitTop-splitStep, minSplitTop, maxSplitTop)
+		return m, nil
+	case "+", "=":
+		m.splitTop = clamp(m.splitTop+splitStep, minSplitTop, maxSplitTop)
+		return m, nil
+	case "shift+left":
+		m.pan(-1)
+		return m, nil
+	case "shift+right":
+		m.pan(1)
+		return m, nil
+	case "F":
+		return m, m.toggleFollow()
+	case "p":
+		return m, m.openPager()
+	case "r":
+		if m.currentTrace == nil {
+			return m, nil
+		}
+		m.statusMsg = "Reloading..."
+		return m, m.refreshTimeline(m.currentTrace.TraceID)
+	case "R":
+		m.redact = !m.redact
+		return m, nil
+	case "T":
+		m.toggleTheme()
+		return m, nil
+	}
+
+	if key == "a" && m.currentTrace != nil {
+		m.showAnalysis = true
+		m.analysis = nil
+		m.analysisCursor = 0
+		m.statusMsg = "Analyzing..."
+		return m, m.runAnalysis(m.currentTrace.TraceID)
+	}
+
 	// ── Pane-specific ──
 
 	switch m.activePane {
@@ -263,23 +639,79 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		switch key {
 		case "j", "down":
 			if m.selectedSpan < len(m.spanTree)-1 {
-				m.selectedSpan++
-				return m, m.loadMemoryDiffs(m.spanTree[m.selectedSpan].span.SpanID)
+				return m, m.selectSpan(m.selectedSpan + 1)
 			}
 		case "k", "up":
 			if m.selectedSpan > 0 {
-				m.selectedSpan--
-				return m, m.loadMemoryDiffs(m.spanTree[m.selectedSpan].span.SpanID)
+				return m, m.selectSpan(m.selectedSpan - 1)
 			}
+		case "h", "left":
+			return m, m.selectParent()
+		case "l", "right":
+			return m, m.selectFirstChild()
+		case "pgdown":
+			return m, m.selectSpan(minInt(m.selectedSpan+m.timelineRows(), len(m.spanTree)-1))
+		case "pgup":
+			return m, m.selectSpan(maxInt(m.selectedSpan-m.timelineRows(), 0))
+		case "g", "home":
+			return m, m.selectSpan(0)
+		case "G", "end":
+			return m, m.selectSpan(len(m.spanTree) - 1)
+		case "v":
+			m.ganttMode = !m.ganttMode
+			m.scrollToSelection()
+		case "o":
+			return m, m.toggleGroupMode()
+		case "m":
+			return m, m.cycleMinDuration()
+		case "b":
+			m.toggleBookmark()
+		case "]":
+			return m, m.jumpBookmark(1)
+		case "[":
+			return m, m.jumpBookmark(-1)
+		case "e":
+			return m, m.jumpError(1)
+		case "E":
+			return m, m.jumpError(-1)
 		}
 
 	case PaneDetail:
-		// Detail is read-only; scrolling could be added later.
+		switch key {
+		case "h", "left":
+			m.pan(-1)
+		case "l", "right":
+			m.pan(1)
+		case "j", "down":
+			if m.detailScroll < m.maxDetailScroll() {
+				m.detailScroll++
+			}
+		case "k", "up":
+			if m.detailScroll > 0 {
+				m.detailScroll--
+			}
+		case "pgdown":
+			m.detailScroll = minInt(m.detailScroll+maxInt(m.detailRows(), 1), m.maxDetailScroll())
+		case "pgup":
+			m.detailScroll = maxInt(m.detailScroll-maxInt(m.detailRows(), 1), 0)
+		case "g", "home":
+			m.detailScroll = 0
+		case "G", "end":
+			m.detailScroll = m.maxDetailScroll()
+		}
 
 	case PaneMemoryDiff:
 		switch key {
+		case "h", "left":
+			m.pan(-1)
+		case "l", "right":
+			m.pan(1)
+		case "n":
+			m.cycleNamespace()
 		case "j", "down":
-			m.diffScroll++
+			if m.diffScroll < m.maxDiffScroll() {
+				m.diffScroll++
+			}
 		case "k", "up":
 			if m.diffScroll > 0 {
 				m.diffScroll--
@@ -290,6 +722,484 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// applyRefresh swaps in reloaded spans for the open trace. The selection
+// stays on the same span, or moves to the newest one in follow mode. If
+// the selected span is gone the selection keeps its position.
+func (m Model) applyRefresh(msg timelineLoadedMsg) (tea.Model, tea.Cmd) {
+	if m.currentTrace == nil || msg.traceID != m.currentTrace.TraceID {
+		return m, nil
+	}
+
+	selectedID := m.selectedSpanID()
+	m.spans = msg.spans
+	m.stats = msg.stats
+	m.spanTree = m.buildTimeline()
+	if !m.follow {
+		m.statusMsg = "reloaded"
+	}
+
+	idx := m.findSpan(selectedID)
+	if m.follow {
+		idx = newestSpan(m.spanTree)
+	}
+	if idx < 0 {
+		idx = minInt(m.selectedSpan, len(m.spanTree)-1)
+	}
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(m.spanTree) {
+		return m, nil
+	}
+	if m.spanTree[idx].span.SpanID == selectedID {
+		m.selectedSpan = idx
+		m.scrollToSelection()
+		return m, nil
+	}
+	return m, m.selectSpan(idx)
+}
+
+// visibleSpans returns the loaded spans that pass the minimum
+// duration filter.
+func (m *Model) visibleSpans() []*database.Span {
+	threshold := minDurations[m.minDuration]
+	if threshold == 0 {
+		return m.spans
+	}
+	var visible []*database.Span
+	for _, s := range m.spans {
+		if s.DurationMs >= threshold {
+			visible = append(visible, s)
+		}
+	}
+	return visible
+}
+
+// cycleMinDuration steps to the next duration threshold and rebuilds
+// the timeline.
+func (m *Model) cycleMinDuration() tea.Cmd {
+	m.minDuration = (m.minDuration + 1) % len(minDurations)
+	return m.rebuildTimeline()
+}
+
+// toggleGroupMode switches the timeline between the span tree and spans
+// grouped by operation type, keeping the selected span.
+func (m *Model) toggleGroupMode() tea.Cmd {
+	m.groupMode = !m.groupMode
+	return m.rebuildTimeline()
+}
+
+// buildTimeline orders the visible spans for the timeline: as a tree,
+// or grouped by operation type.
+func (m *Model) buildTimeline() []spanNode {
+	if m.groupMode {
+		return groupSpans(m.visibleSpans())
+	}
+	return buildSpanTree(m.visibleSpans())
+}
+
+// rebuildTimeline rebuilds the timeline after a view or filter change.
+// The selection stays on the same span when it is still shown and is
+// clamped into the new set otherwise.
+func (m *Model) rebuildTimeline() tea.Cmd {
+	selectedID := m.selectedSpanID()
+	m.spanTree = m.buildTimeline()
+	if len(m.spanTree) == 0 {
+		m.selectedSpan = 0
+		m.scrollOffset = 0
+		return nil
+	}
+
+	idx := m.findSpan(selectedID)
+	if idx < 0 {
+		idx = clamp(m.selectedSpan, 0, len(m.spanTree)-1)
+	}
+	return m.selectSpan(idx)
+}
+
+// toggleFollow turns live tail mode on or off. Turning it on reloads
+// the trace right away and starts the periodic refresh.
+func (m *Model) toggleFollow() tea.Cmd {
+	if m.currentTrace == nil {
+		return nil
+	}
+	m.follow = !m.follow
+	if !m.follow {
+		m.statusMsg = "Follow off"
+		return nil
+	}
+	m.followGen++
+	m.statusMsg = "Following newest span"
+	return tea.Batch(m.refreshTimeline(m.currentTrace.TraceID), followTick(m.followGen))
+}
+
+// newestSpan returns the spanTree index of the span that started last,
+// or -1 if the tree is empty.
+func newestSpan(tree []spanNode) int {
+	newest := -1
+	for i, node := range tree {
+		if newest < 0 || node.span.StartTime >= tree[newest].span.StartTime {
+			newest = i
+		}
+	}
+	return newest
+}
+
+// Screen rows above the first list row: the header bar, then the panel
+// border, title and blank line in the main layout, or the heading and
+// blank line in the trace list.
+const (
+	headerRows    = 1
+	panelTopRows  = 3
+	traceListRows = 2
+)
+
+// handleMouse selects the trace or span under a left click. In the main
+// layout, clicking a pane also focuses it.
+func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+		return m, nil
+	}
+	if m.searchMode || m.jumpMode || m.quitPrompt || m.showAnalysis || m.showViewer {
+		return m, nil
+	}
+
+	if m.showTraceList {
+		start, end := traceListWindow(&m)
+		idx := start + msg.Y - headerRows - traceListRows
+		if idx >= start && idx < end {
+			m.selectedTrace = idx
+		}
+		return m, nil
+	}
+
+	// Work out which pane was clicked
+	pane := PaneTimeline
+	if m.width >= 60 {
+		leftWidth, _, topHeight, _ := m.paneSizes(m.height - 2)
+		switch {
+		case msg.Y >= headerRows+topHeight:
+			pane = PaneMemoryDiff
+		case msg.X >= leftWidth:
+			pane = PaneDetail
+		}
+	} else {
+		pane = m.activePane
+	}
+	m.activePane = pane
+	if pane != PaneTimeline {
+		return m, m.ensureMemoryDiffs()
+	}
+
+	selected, total := m.timelinePos(m.selectedSpan)
+	first := scrollWindow(m.scrollOffset, selected, m.timelineRows(), total)
+	row := msg.Y - headerRows - panelTopRows
+	if row < 0 || row >= m.timelineRows() || first+row >= total {
+		return m, m.ensureMemoryDiffs()
+	}
+	idx := first + row
+	if m.groupedView() {
+		// Group headers are not selectable
+		if idx = groupRows(m.spanTree)[idx].node; idx < 0 {
+			return m, m.ensureMemoryDiffs()
+		}
+	}
+	m.scrollOffset = first
+	return m, m.selectSpan(idx)
+}
+
+// toggleTheme switches between the default and color-blind-safe
+// palettes.
+func (m *Model) toggleTheme() {
+	if m.theme == ThemeColorBlind {
+		m.theme = ThemeDefault
+	} else {
+		m.theme = ThemeColorBlind
+	}
+	applyTheme(m.theme)
+	m.statusMsg = "theme: " + m.theme.String()
+}
+
+// selectSpan moves the timeline selection to idx and loads the
+// memory diffs for the newly selected span.
+func (m *Model) selectSpan(idx int) tea.Cmd {
+	if idx < 0 || idx >= len(m.spanTree) {
+		return nil
+	}
+	if idx != m.selectedSpan {
+		m.detailScroll = 0
+	}
+	m.selectedSpan = idx
+	m.scrollToSelection()
+	return m.loadMemoryDiffs(m.spanTree[idx].span.SpanID)
+}
+
+// groupedView reports whether the timeline shows group headers. The
+// Gantt view draws grouped spans without them.
+func (m *Model) groupedView() bool {
+	return m.groupMode && !m.ganttMode
+}
+
+// timelinePos returns the display row of spanTree index idx and the
+// number of display rows in the timeline. They differ from the node
+// index and count only when group headers are shown.
+func (m *Model) timelinePos(idx int) (row, total int) {
+	if !m.groupedView() {
+		return idx, len(m.spanTree)
+	}
+	rows := groupRows(m.spanTree)
+	for r, gr := range rows {
+		if gr.node == idx {
+			return r, len(rows)
+		}
+	}
+	return 0, len(rows)
+}
+
+// scrollToSelection moves the timeline scroll window just enough to
+// show the selected span.
+func (m *Model) scrollToSelection() {
+	row, total := m.timelinePos(m.selectedSpan)
+	m.scrollOffset = scrollWindow(m.scrollOffset, row, m.timelineRows(), total)
+}
+
+// selectedSpanID returns the ID of the selected span, or "" if none.
+func (m *Model) selectedSpanID() string {
+	if m.selectedSpan >= len(m.spanTree) {
+		return ""
+	}
+	return m.spanTree[m.selectedSpan].span.SpanID
+}
+
+// ensureMemoryDiffs reloads memory diffs when the loaded ones belong to
+// a different span than the current selection.
+func (m *Model) ensureMemoryDiffs() tea.Cmd {
+	id := m.selectedSpanID()
+	if id == "" || id == m.diffsSpanID {
+		return nil
+	}
+	return m.loadMemoryDiffs(id)
+}
+
+// timelineRows returns how many span rows fit in the timeline pane at
+// the current terminal size.
+func (m *Model) timelineRows() int {
+	bodyHeight := m.height - 2
+	paneHeight := bodyHeight
+	if m.width >= 60 {
+		_, _, paneHeight, _ = m.paneSizes(bodyHeight)
+	}
+	// Panel border and padding, then the title and blank line
+	return maxInt(paneHeight-4, 1)
+}
+
+// findSpan returns the spanTree index of the span whose ID equals id,
+// falling back to the first span whose ID starts with it. Returns -1
+// when nothing matches.
+func (m *Model) findSpan(id string) int {
+	if id == "" {
+		return -1
+	}
+	prefixMatch := -1
+	for i, node := range m.spanTree {
+		if node.span.SpanID == id {
+			return i
+		}
+		if prefixMatch < 0 && strings.HasPrefix(node.span.SpanID, id) {
+			prefixMatch = i
+		}
+	}
+	return prefixMatch
+}
+
+// jumpToSpan closes the analysis overlay and selects the span matching
+// the given ID or ID prefix in the timeline.
+func (m *Model) jumpToSpan(spanID string) tea.Cmd {
+	m.showAnalysis = false
+	m.activePane = PaneTimeline
+	idx := m.findSpan(spanID)
+	if idx < 0 {
+		m.statusMsg = fmt.Sprintf("Span %s not found", spanID)
+		return nil
+	}
+	return m.selectSpan(idx)
+}
+
+// selectParent moves the selection to the selected span's parent.
+func (m *Model) selectParent() tea.Cmd {
+	if m.selectedSpan >= len(m.spanTree) {
+		return nil
+	}
+	parent := m.spanTree[m.selectedSpan].span.ParentSpanID
+	if parent == nil {
+		return nil
+	}
+	for i, node := range m.spanTree {
+		if node.span.SpanID == *parent {
+			return m.selectSpan(i)
+		}
+	}
+	return nil
+}
+
+// selectFirstChild moves the selection to the selected span's first
+// child. spanTree is depth-first, so a child always directly follows
+// its parent.
+func (m *Model) selectFirstChild() tea.Cmd {
+	next := m.selectedSpan + 1
+	if next >= len(m.spanTree) {
+		return nil
+	}
+	parent := m.spanTree[next].span.ParentSpanID
+	if parent == nil || *parent != m.spanTree[m.selectedSpan].span.SpanID {
+		return nil
+	}
+	return m.selectSpan(next)
+}
+
+// diffRows returns how many diff lines fit in the memory diff pane at
+// the current terminal size.
+func (m *Model) diffRows() int {
+	bodyHeight := m.height - 2
+	paneHeight := bodyHeight
+	if m.width >= 60 {
+		_, _, _, paneHeight = m.paneSizes(bodyHeight)
+	}
+	// Panel border and padding, then the title line
+	return maxInt(paneHeight-4, 1)
+}
+
+// maxDiffScroll returns the largest diff scroll offset that still
+// fills the pane: the last screenful of diff lines.
+func (m *Model) maxDiffScroll() int {
+	return maxInt(len(diffLines(m, m.width-4))-m.diffRows(), 0)
+}
+
+// maxDetailScroll returns the largest detail scroll offset that still
+// fills the pane. One row is kept for the position indicator.
+func (m *Model) maxDetailScroll() int {
+	if m.selectedSpan >= len(m.spanTree) {
+		return 0
+	}
+	rows := m.detailRows()
+	lines := detailLines(m, m.detailWidth()-4)
+	if rows < 1 || len(lines) <= rows+1 {
+		return 0
+	}
+	return len(lines) - rows
+}
+
+// detailRows is how many content lines the detail pane shows at once
+// when it scrolls, which is also how far PgUp and PgDn move.
+func (m *Model) detailRows() int {
+	bodyHeight := m.height - 2
+	paneHeight := bodyHeight
+	if m.width >= 60 {
+		_, _, paneHeight, _ = m.paneSizes(bodyHeight)
+	}
+	// Panel border and padding, the title and blank line, the indicator
+	return paneHeight - 5
+}
+
+// detailWidth is the outer width of the detail pane.
+func (m *Model) detailWidth() int {
+	if m.width >= 60 {
+		_, width, _, _ := m.paneSizes(m.height - 2)
+		return width
+	}
+	return m.width
+}
+
+// cycleNamespace steps the memory diff filter through the namespaces of
+// the loaded events, then back to showing all of them.
+func (m *Model) cycleNamespace() {
+	namespaces := diffNamespaces(m.memoryDiffs)
+	next := ""
+	for i, ns := range namespaces {
+		if m.diffNamespace == "" {
+			next = ns
+			break
+		}
+		if ns == m.diffNamespace && i+1 < len(namespaces) {
+			next = namespaces[i+1]
+			break
+		}
+	}
+	m.diffNamespace = next
+	m.diffScroll = 0
+}
+
+// panStep is how many columns one horizontal scroll step moves.
+const panStep = 8
+
+// pan scrolls detail and diff content horizontally by one step in the
+// given direction, clamped to the longest line on screen.
+func (m *Model) pan(dir int) {
+	longest := longestDiffValue(m.memoryDiffs)
+	if m.selectedSpan < len(m.spanTree) {
+		longest = maxInt(longest, longestDetailLine(m.spanTree[m.selectedSpan].span))
+	}
+	m.hScroll = clamp(m.hScroll+dir*panStep, 0, longest)
+}
+
+// toggleBookmark flags or unflags the selected span.
+func (m *Model) toggleBookmark() {
+	if m.selectedSpan >= len(m.spanTree) {
+		return
+	}
+	id := m.spanTree[m.selectedSpan].span.SpanID
+	if m.bookmarks[id] {
+		delete(m.bookmarks, id)
+		m.statusMsg = "Bookmark removed"
+	} else {
+		m.bookmarks[id] = true
+		m.statusMsg = fmt.Sprintf("Bookmarked (%d total)", len(m.bookmarks))
+	}
+}
+
+// jumpBookmark selects the next (dir > 0) or previous (dir < 0)
+// bookmarked span in tree order, wrapping around at either end.
+func (m *Model) jumpBookmark(dir int) tea.Cmd {
+	n := len(m.spanTree)
+	if n == 0 || len(m.bookmarks) == 0 {
+		m.statusMsg = "No bookmarks"
+		return nil
+	}
+	for step := 1; step <= n; step++ {
+		idx := ((m.selectedSpan+dir*step)%n + n) % n
+		if m.bookmarks[m.spanTree[idx].span.SpanID] {
+			return m.selectSpan(idx)
+		}
+	}
+	return nil
+}
+
+// jumpError selects the next (dir > 0) or previous (dir < 0) failed
+// span in timeline order, wrapping around, and reports its position
+// among all failures.
+func (m *Model) jumpError(dir int) tea.Cmd {
+	var failed []int
+	for i, node := range m.spanTree {
+		if spanFailed(node.span) {
+			failed = append(failed, i)
+		}
+	}
+	if len(failed) == 0 {
+		m.statusMsg = "No failed spans"
+		return nil
+	}
+
+	n := len(m.spanTree)
+	for step := 1; step <= n; step++ {
+		idx := ((m.selectedSpan+dir*step)%n + n) % n
+		if pos := slices.Index(failed, idx); pos >= 0 {
+			m.statusMsg = fmt.Sprintf("error %d/%d", pos+1, len(failed))
+			return m.selectSpan(idx)
+		}
+	}
+	return nil
+}
+
 // ────────────────────────────────────────────────────────────
 // View
 // ────────────────────────────────────────────────────────────
@@ -307,6 +1217,10 @@ func (m Model) View() string {
 	var body string
 	if m.showTraceList {
 		body = renderTraceList(&m)
+	} else if m.showViewer {
+		body = renderViewerPanel(&m, m.width, bodyHeight)
+	} else if m.showAnalysis {
+		body = renderAnalysisPanel(&m, m.width, bodyHeight)
 	} else {
 		body = m.renderMainLayout(bodyHeight)
 	}
@@ -321,11 +1235,7 @@ func (m Model) renderMainLayout(totalHeight int) string {
 		return m.renderCompactLayout(totalHeight)
 	}
 
-	// Split proportions
-	leftWidth := m.width * 45 / 100
-	rightWidth := m.width - leftWidth
-	topHeight := totalHeight * 65 / 100
-	bottomHeight := totalHeight - topHeight
+	leftWidth, rightWidth, topHeight, bottomHeight := m.paneSizes(totalHeight)
 
 	// Render panes
 	timeline := renderTimelinePanel(&m, leftWidth, topHeight)
@@ -336,6 +1246,16 @@ func (m Model) renderMainLayout(totalHeight int) string {
 	return lipgloss.JoinVertical(lipgloss.Left, topRow, diff)
 }
 
+// paneSizes splits the terminal width and the given body height
+// according to the current split proportions.
+func (m Model) paneSizes(totalHeight int) (leftWidth, rightWidth, topHeight, bottomHeight int) {
+	leftWidth = m.width * m.splitLeft / 100
+	rightWidth = m.width - leftWidth
+	topHeight = totalHeight * m.splitTop / 100
+	bottomHeight = totalHeight - topHeight
+	return
+}
+
 // renderCompactLayout is used when the terminal is narrow (< 60 cols).
 // Only the focused pane is shown.
 func (m Model) renderCompactLayout(totalHeight int) string {
@@ -0,0 +1,360 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// press feeds a sequence of keys through the model's key handler and
+// returns the final model along with the command from the last key.
+func press(m Model, keys ...string) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	for _, k := range keys {
+		var updated tea.Model
+		updated, cmd = m.handleKey(keyMsg(k))
+		m = updated.(Model)
+	}
+	return m, cmd
+}
+
+// TestBookmarkCycle verifies that ']' cycles between bookmarked spans
+// and skips unbookmarked ones.
+func TestBookmarkCycle(t *testing.T) {
+	m := newTestModel(
+		newTestSpan("s0", "", "LLM", 0, 10),
+		newTestSpan("s1", "", "TOOL", 10, 10),
+		newTestSpan("s2", "", "LLM", 20, 10),
+		newTestSpan("s3", "", "MEMORY", 30, 10),
+	)
+
+	// Bookmark s1 and s3
+	m, _ = press(m, "j", "b", "j", "j", "b")
+	if len(m.bookmarks) != 2 {
+		t.Fatalf("expected 2 bookmarks, got %d", len(m.bookmarks))
+	}
+
+	m, cmd := press(m, "]")
+	if got := m.spanTree[m.selectedSpan].span.SpanID; got != "s1" {
+		t.Errorf("expected ] to wrap to s1, got %s", got)
+	}
+	if cmd == nil {
+		t.Error("expected ] to load memory diffs for the new selection")
+	}
+
+	m, _ = press(m, "]")
+	if got := m.spanTree[m.selectedSpan].span.SpanID; got != "s3" {
+		t.Errorf("expected ] to move to s3, got %s", got)
+	}
+
+	m, _ = press(m, "[")
+	if got := m.spanTree[m.selectedSpan].span.SpanID; got != "s1" {
+		t.Errorf("expected [ to move back to s1, got %s", got)
+	}
+
+	// Toggling again removes the bookmark
+	m, _ = press(m, "b")
+	if m.bookmarks["s1"] {
+		t.Error("expected second b to remove the bookmark on s1")
+	}
+}
+
+// TestJumpToSpan verifies that the ':' prompt selects a span by full ID
+// or prefix and reports unknown IDs.
+func TestJumpToSpan(t *testing.T) {
+	m := newTestModel(
+		newTestSpan("a1b2", "", "LLM", 0, 10),
+		newTestSpan("c3d4", "", "TOOL", 10, 10),
+		newTestSpan("e5f6", "", "LLM", 20, 10),
+	)
+
+	m, cmd := press(m, ":", "e", "5", "f", "6", "enter")
+	if m.jumpMode {
+		t.Error("expected enter to close the jump prompt")
+	}
+	if got := m.spanTree[m.selectedSpan].span.SpanID; got != "e5f6" {
+		t.Errorf("expected selection on e5f6, got %s", got)
+	}
+	if cmd == nil {
+		t.Error("expected jump to load memory diffs")
+	}
+
+	m, _ = press(m, ":", "c", "3", "enter")
+	if got := m.spanTree[m.selectedSpan].span.SpanID; got != "c3d4" {
+		t.Errorf("expected prefix c3 to select c3d4, got %s", got)
+	}
+
+	m, _ = press(m, ":", "z", "z", "enter")
+	if got := m.spanTree[m.selectedSpan].span.SpanID; got != "c3d4" {
+		t.Errorf("expected selection to stay on c3d4, got %s", got)
+	}
+	if !strings.Contains(m.statusMsg, "not found") {
+		t.Errorf("expected not-found status, got %q", m.statusMsg)
+	}
+}
+
+// TestParentChildNavigation verifies that 'h' selects the parent of the
+// current span and 'l' returns to its first child.
+func TestParentChildNavigation(t *testing.T) {
+	m := newTestModel(
+		newTestSpan("root", "", "PLANNING", 0, 100),
+		newTestSpan("child", "root", "LLM", 10, 40),
+		newTestSpan("grandchild", "child", "TOOL", 20, 10),
+		newTestSpan("sibling", "root", "TOOL", 60, 10),
+	)
+
+	m, _ = press(m, "j", "j")
+	if got := m.spanTree[m.selectedSpan].span.SpanID; got != "grandchild" {
+		t.Fatalf("expected grandchild selected, got %s", got)
+	}
+
+	m, _ = press(m, "h")
+	if got := m.spanTree[m.selectedSpan].span.SpanID; got != "child" {
+		t.Errorf("expected h to select child, got %s", got)
+	}
+
+	m, _ = press(m, "h")
+	if got := m.spanTree[m.selectedSpan].span.SpanID; got != "root" {
+		t.Errorf("expected h to select root, got %s", got)
+	}
+
+	m, _ = press(m, "l")
+	if got := m.spanTree[m.selectedSpan].span.SpanID; got != "child" {
+		t.Errorf("expected l to select child, got %s", got)
+	}
+
+	// A leaf has no child; l leaves the selection alone
+	m, _ = press(m, "l", "l")
+	if got := m.spanTree[m.selectedSpan].span.SpanID; got != "grandchild" {
+		t.Errorf("expected l on a leaf to stay on grandchild, got %s", got)
+	}
+}
+
+// TestAdjustPaneSplit verifies that the split keys change the computed
+// pane sizes and stay within bounds.
+func TestAdjustPaneSplit(t *testing.T) {
+	m := newTestModel(newTestSpan("s0", "", "LLM", 0, 10))
+
+	before, _, top, _ := m.paneSizes(40)
+	m, _ = press(m, ">")
+	after, _, _, _ := m.paneSizes(40)
+	if after <= before {
+		t.Errorf("expected > to widen the timeline: before=%d after=%d", before, after)
+	}
+
+	m, _ = press(m, "-")
+	if _, _, newTop, _ := m.paneSizes(40); newTop >= top {
+		t.Errorf("expected - to shrink the top row: before=%d after=%d", top, newTop)
+	}
+
+	for i := 0; i < 50; i++ {
+		m, _ = press(m, "<")
+	}
+	if m.splitLeft != minSplitLeft {
+		t.Errorf("expected split clamped to %d, got %d", minSplitLeft, m.splitLeft)
+	}
+}
+
+// TestFollowSelectsNewestSpan verifies that with follow enabled, a
+// reload that brings in a newer span moves the selection to it, and
+// that without follow the selection stays put.
+func TestFollowSelectsNewestSpan(t *testing.T) {
+	store := &fakeStore{spans: []*database.Span{
+		newTestSpan("root", "", "PLANNING", 0, 100),
+		newTestSpan("first", "root", "LLM", 10, 10),
+	}}
+	m := newTestModel(store.spans...)
+	m.store = store
+	m.currentTrace = &database.Trace{TraceID: "trace-test"}
+
+	m, cmd := press(m, "F")
+	if !m.follow || cmd == nil {
+		t.Fatal("expected F to enable follow and schedule a reload")
+	}
+
+	// The agent writes a new span; the next refresh picks it up
+	store.spans = append(store.spans, newTestSpan("second", "root", "TOOL", 30, 5))
+	updated, cmd := m.Update(m.refreshTimeline("trace-test")())
+	m = updated.(Model)
+	if got := m.selectedSpanID(); got != "second" {
+		t.Fatalf("expected follow to select the newest span, got %q", got)
+	}
+	if cmd == nil {
+		t.Error("expected the newest span's memory diffs to be loaded")
+	}
+
+	// Follow off: new spans no longer move the selection
+	m, _ = press(m, "F")
+	store.spans = append(store.spans, newTestSpan("third", "root", "TOOL", 50, 5))
+	updated, _ = m.Update(m.refreshTimeline("trace-test")())
+	m = updated.(Model)
+	if got := m.selectedSpanID(); got != "second" {
+		t.Errorf("expected selection to stay on second, got %q", got)
+	}
+	if len(m.spanTree) != 4 {
+		t.Errorf("expected the refresh to load 4 spans, got %d", len(m.spanTree))
+	}
+
+	// Stale ticks from the old chain do nothing
+	if _, cmd := m.Update(followTickMsg{gen: m.followGen}); cmd != nil {
+		t.Error("expected no refresh while follow is off")
+	}
+}
+
+// TestConfirmQuit verifies that with confirmation enabled, q only asks,
+// y quits, any other key cancels, and ctrl+c still quits at once.
+func TestConfirmQuit(t *testing.T) {
+	m := NewModelWithOptions(nil, Options{ConfirmQuit: true})
+
+	m, cmd := press(m, "q")
+	if !m.quitPrompt || cmd != nil {
+		t.Fatal("expected q to show the quit prompt without quitting")
+	}
+	if footer := renderFooter(&m); !strings.Contains(footer, "Quit? y/n") {
+		t.Errorf("expected quit prompt in footer, got %q", footer)
+	}
+
+	m, cmd = press(m, "n")
+	if m.quitPrompt || cmd != nil {
+		t.Fatal("expected n to dismiss the prompt without quitting")
+	}
+
+	_, cmd = press(m, "q", "y")
+	if cmd == nil {
+		t.Fatal("expected y to quit")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("expected y to issue tea.Quit")
+	}
+
+	_, cmd = press(m, "ctrl+c")
+	if cmd == nil {
+		t.Fatal("expected ctrl+c to quit without confirmation")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("expected ctrl+c to issue tea.Quit")
+	}
+}
+
+// click builds a left-button press at the given screen cell.
+func click(x, y int) tea.MouseMsg {
+	return tea.MouseMsg{X: x, Y: y, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft}
+}
+
+// TestMouseSelect verifies that clicking a timeline row selects the
+// span rendered there, and clicking a trace list row selects its trace.
+func TestMouseSelect(t *testing.T) {
+	m := newTestModel(manySpans(40)...)
+	m.activePane = PaneDetail
+
+	// Row y=4 is the first span: header, panel border, title, blank
+	updated, cmd := m.Update(click(5, 6))
+	m = updated.(Model)
+	if m.selectedSpanID() != "span-02" || m.activePane != PaneTimeline {
+		t.Fatalf("expected span-02 in the timeline, got %q in pane %d", m.selectedSpanID(), m.activePane)
+	}
+	if cmd == nil {
+		t.Error("expected the clicked span's memory diffs to be loaded")
+	}
+	if !strings.Contains(strings.Split(m.View(), "\n")[6], "span-02") {
+		t.Error("expected the clicked row to show the selected span")
+	}
+
+	// After scrolling, clicks map through the scroll offset
+	m, _ = press(m, strings.Split(":span-35", "")...)
+	m, _ = press(m, "enter")
+	y := -1
+	for i, l := range strings.Split(m.View(), "\n") {
+		if strings.Contains(l, "span-30") {
+			y = i
+		}
+	}
+	updated, _ = m.Update(click(5, y))
+	m = updated.(Model)
+	if m.selectedSpanID() != "span-30" {
+		t.Errorf("expected span-30 after scrolling, got %q", m.selectedSpanID())
+	}
+
+	// Trace list: heading and blank line sit below the header
+	m.showTraceList = true
+	m.traces = []*database.Trace{{TraceID: "t0"}, {TraceID: "t1"}, {TraceID: "t2"}}
+	updated, _ = m.Update(click(5, 5))
+	if got := updated.(Model).selectedTrace; got != 2 {
+		t.Errorf("expected trace 2 selected, got %d", got)
+	}
+}
+
+// TestJumpError verifies that e and E visit only failed spans, wrap
+// around, and report the position among failures.
+func TestJumpError(t *testing.T) {
+	spans := []*database.Span{
+		newTestSpan("ok-0", "", "LLM", 0, 10),
+		newTestSpan("bad-1", "", "TOOL", 10, 10),
+		newTestSpan("ok-2", "", "LLM", 20, 10),
+		newTestSpan("ok-3", "", "LLM", 30, 10),
+		newTestSpan("bad-4", "", "TOOL", 40, 10),
+	}
+	spans[1].Status = "error"
+	spans[4].Status = "error"
+	m := newTestModel(spans...)
+
+	steps := []struct {
+		key, want, status string
+	}{
+		{"e", "bad-1", "error 1/2"},
+		{"e", "bad-4", "error 2/2"},
+		{"e", "bad-1", "error 1/2"}, // wraps
+		{"E", "bad-4", "error 2/2"}, // wraps backwards
+		{"E", "bad-1", "error 1/2"},
+	}
+	for _, s := range steps {
+		var cmd tea.Cmd
+		m, cmd = press(m, s.key)
+		if got := m.selectedSpanID(); got != s.want {
+			t.Fatalf("%s: expected %s, got %s", s.key, s.want, got)
+		}
+		if m.statusMsg != s.status {
+			t.Errorf("%s: expected status %q, got %q", s.key, s.status, m.statusMsg)
+		}
+		if cmd == nil {
+			t.Errorf("%s: expected memory diffs to be loaded", s.key)
+		}
+		if !strings.Contains(renderFooter(&m), s.status) {
+			t.Errorf("%s: expected %q in footer", s.key, s.status)
+		}
+	}
+}
+
+// TestReload verifies that r reloads the open trace and keeps the
+// selected span.
+func TestReload(t *testing.T) {
+	store := &fakeStore{spans: []*database.Span{
+		newTestSpan("root", "", "PLANNING", 0, 100),
+		newTestSpan("first", "root", "LLM", 10, 10),
+	}}
+	m := newTestModel(store.spans...)
+	m.store = store
+	m.currentTrace = &database.Trace{TraceID: "trace-test"}
+	m, _ = press(m, "j")
+
+	m, cmd := press(m, "r")
+	if cmd == nil {
+		t.Fatal("expected r to issue a reload")
+	}
+	store.spans = append([]*database.Span{newTestSpan("early", "", "TOOL", -5, 1)}, store.spans...)
+	msg := cmd()
+	if len(store.timelineQueries) != 1 || store.timelineQueries[0] != "trace-test" {
+		t.Fatalf("expected a timeline query for trace-test, got %v", store.timelineQueries)
+	}
+
+	updated, _ := m.Update(msg)
+	m = updated.(Model)
+	if got := m.selectedSpanID(); got != "first" {
+		t.Errorf("expected the selection to stay on first, got %q", got)
+	}
+	if m.statusMsg != "reloaded" {
+		t.Errorf("expected status %q, got %q", "reloaded", m.statusMsg)
+	}
+}
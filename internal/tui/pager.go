@@ -0,0 +1,122 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// pagerClosedMsg reports that the external pager or editor exited.
+type pagerClosedMsg struct{ err error }
+
+// pagerContent is the text handed to the pager for a span: its prompt
+// and completion under plain headings.
+func (m *Model) pagerContent(span *database.Span) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Span %s  %s %s\n", span.SpanID, span.OperationType, span.OperationName)
+	for _, part := range []struct {
+		title string
+		text  *string
+	}{{"Prompt", span.Prompt}, {"Completion", span.Completion}} {
+		if part.text == nil || *part.text == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "\n── %s ──\n\n%s\n", part.title, m.redacted(*part.text))
+	}
+	return b.String()
+}
+
+// pagerCommand builds the command that shows content outside the TUI.
+// $PAGER reads it on stdin; otherwise $EDITOR opens a temporary file,
+// which the caller removes afterwards. It returns a nil command when
+// neither is set.
+func pagerCommand(content string) (cmd *exec.Cmd, tmpFile string, err error) {
+	if args := strings.Fields(os.Getenv("PAGER")); len(args) > 0 {
+		cmd = exec.Command(args[0], args[1:]...)
+		cmd.Stdin = strings.NewReader(content)
+		return cmd, "", nil
+	}
+
+	args := strings.Fields(os.Getenv("EDITOR"))
+	if len(args) == 0 {
+		return nil, "", nil
+	}
+	f, err := os.CreateTemp("", "oculo-span-*.txt")
+	if err != nil {
+		return nil, "", fmt.Errorf("creating pager file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		os.Remove(f.Name())
+		return nil, "", fmt.Errorf("writing pager file: %w", err)
+	}
+	return exec.Command(args[0], append(args[1:], f.Name())...), f.Name(), nil
+}
+
+// openPager shows the selected span's prompt and completion in the
+// user's pager, suspending the TUI until it exits. Without a pager the
+// built-in viewer is used instead.
+func (m *Model) openPager() tea.Cmd {
+	if m.selectedSpan >= len(m.spanTree) {
+		return nil
+	}
+	content := m.pagerContent(m.spanTree[m.selectedSpan].span)
+
+	cmd, tmpFile, err := pagerCommand(content)
+	if err != nil {
+		m.statusMsg = "Error: " + err.Error()
+		return nil
+	}
+	if cmd == nil {
+		m.viewerText = content
+		m.viewerScroll = 0
+		m.showViewer = true
+		return nil
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if tmpFile != "" {
+			os.Remove(tmpFile)
+		}
+		return pagerClosedMsg{err}
+	})
+}
+
+// ── Built-in viewer ──
+
+// viewerLines wraps the viewer text to width.
+func viewerLines(m *Model, width int) []string {
+	wrapped := lipgloss.NewStyle().Width(maxInt(width, 1)).Render(m.viewerText)
+	return strings.Split(wrapped, "\n")
+}
+
+// viewerRows is the number of text rows the viewer shows at once.
+func (m *Model) viewerRows() int {
+	// Panel border and padding, then the title and blank line
+	return maxInt(m.height-2-4, 1)
+}
+
+// maxViewerScroll is the furthest the viewer can scroll.
+func (m *Model) maxViewerScroll() int {
+	return maxInt(len(viewerLines(m, m.width-4))-m.viewerRows(), 0)
+}
+
+// renderViewerPanel draws the built-in full-screen text viewer.
+func renderViewerPanel(m *Model, width, height int) string {
+	lines := viewerLines(m, width-4)
+	rows := maxInt(height-4, 1)
+	start := clamp(m.viewerScroll, 0, maxInt(len(lines)-rows, 0))
+	end := minInt(start+rows, len(lines))
+
+	title := panelTitleStyle.Render("Viewer") +
+		traceDimStyle.Render(fmt.Sprintf("  line %d of %d  (set $PAGER to use your own)", start+1, len(lines)))
+	out := []string{title, ""}
+	for _, line := range lines[start:end] {
+		out = append(out, detailValueStyle.Render(line))
+	}
+	return panelActiveStyle.Width(width).Height(height).Render(strings.Join(out, "\n"))
+}
@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestPagerCommand verifies that $PAGER is run with the selected span's
+// prompt and completion on stdin, and $EDITOR on a file holding them.
+func TestPagerCommand(t *testing.T) {
+	sp := newTestSpan("s0", "", "LLM", 0, 10)
+	prompt, completion := "What is the capital of France?", "Paris."
+	sp.Prompt, sp.Completion = &prompt, &completion
+	m := newTestModel(newTestSpan("root", "", "PLANNING", 0, 100), sp)
+	m, _ = press(m, "j")
+
+	content := m.pagerContent(m.spanTree[m.selectedSpan].span)
+	for _, want := range []string{"Span s0", prompt, completion} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected %q in pager content, got:\n%s", want, content)
+		}
+	}
+
+	t.Setenv("PAGER", "less -R")
+	cmd, tmpFile, err := pagerCommand(content)
+	if err != nil || cmd == nil || tmpFile != "" {
+		t.Fatalf("expected a pager command, got %v, %q, %v", cmd, tmpFile, err)
+	}
+	if strings.Join(cmd.Args, " ") != "less -R" {
+		t.Errorf("expected args [less -R], got %v", cmd.Args)
+	}
+	if stdin, _ := io.ReadAll(cmd.Stdin); string(stdin) != content {
+		t.Errorf("expected the span content on stdin, got %q", stdin)
+	}
+
+	t.Setenv("PAGER", "")
+	t.Setenv("EDITOR", "vim")
+	cmd, tmpFile, err = pagerCommand(content)
+	if err != nil || cmd == nil || tmpFile == "" {
+		t.Fatalf("expected an editor command, got %v, %q, %v", cmd, tmpFile, err)
+	}
+	defer os.Remove(tmpFile)
+	if cmd.Args[len(cmd.Args)-1] != tmpFile {
+		t.Errorf("expected the editor to open %s, got %v", tmpFile, cmd.Args)
+	}
+	if data, _ := os.ReadFile(tmpFile); string(data) != content {
+		t.Errorf("expected the span content in the file, got %q", data)
+	}
+}
+
+// TestBuiltinViewer verifies that without a pager, p opens the built-in
+// viewer on the selected span and esc closes it.
+func TestBuiltinViewer(t *testing.T) {
+	t.Setenv("PAGER", "")
+	t.Setenv("EDITOR", "")
+
+	sp := newTestSpan("s0", "", "LLM", 0, 10)
+	prompt := strings.Repeat("a long prompt line\n", 100)
+	sp.Prompt = &prompt
+	m := newTestModel(sp)
+
+	m, cmd := press(m, "p")
+	if !m.showViewer || cmd != nil {
+		t.Fatalf("expected the built-in viewer, got showViewer=%v cmd=%v", m.showViewer, cmd)
+	}
+	if out := m.View(); !strings.Contains(out, "a long prompt line") || !strings.Contains(out, "Viewer") {
+		t.Errorf("expected the prompt in the viewer, got:\n%s", out)
+	}
+
+	m, _ = press(m, "G")
+	if m.viewerScroll == 0 || m.viewerScroll != m.maxViewerScroll() {
+		t.Errorf("expected G to scroll to the end, got %d of %d", m.viewerScroll, m.maxViewerScroll())
+	}
+	if m.selectedSpan != 0 {
+		t.Error("expected viewer keys not to move the timeline")
+	}
+
+	m, _ = press(m, "esc")
+	if m.showViewer || m.showTraceList {
+		t.Error("expected esc to close only the viewer")
+	}
+}
@@ -0,0 +1,117 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Preferences are the view settings remembered between sessions.
+type Preferences struct {
+	SplitLeft     int    `json:"split_left,omitempty"`
+	SplitTop      int    `json:"split_top,omitempty"`
+	Gantt         bool   `json:"gantt,omitempty"`
+	Grouped       bool   `json:"grouped,omitempty"`
+	AbsoluteTimes bool   `json:"absolute_times,omitempty"`
+	TimeLayout    string `json:"time_layout,omitempty"`
+	Theme         Theme  `json:"theme,omitempty"`
+}
+
+// LoadPreferences reads preferences from path. A missing file is not an
+// error. A corrupt file returns the zero Preferences, which means
+// defaults, together with the parse error.
+func LoadPreferences(path string) (Preferences, error) {
+	var p Preferences
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return p, fmt.Errorf("reading preferences: %w", err)
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Preferences{}, fmt.Errorf("parsing preferences %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// SavePreferences writes preferences to path, creating its directory.
+func SavePreferences(path string, p Preferences) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating preferences directory: %w", err)
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding preferences: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing preferences: %w", err)
+	}
+	return nil
+}
+
+// ── Model integration ──
+
+type prefsLoadedMsg struct{ prefs Preferences }
+
+// loadPrefs reads the preferences file. A corrupt file is reported in
+// the status line and the defaults are kept.
+func (m Model) loadPrefs() tea.Cmd {
+	path := m.opts.PrefsPath
+	return func() tea.Msg {
+		p, err := LoadPreferences(path)
+		if err != nil {
+			return errMsg{err}
+		}
+		return prefsLoadedMsg{p}
+	}
+}
+
+// savePrefs writes the given preferences to the preferences file.
+func (m Model) savePrefs(p Preferences) tea.Cmd {
+	path := m.opts.PrefsPath
+	return func() tea.Msg {
+		if err := SavePreferences(path, p); err != nil {
+			return errMsg{err}
+		}
+		return nil
+	}
+}
+
+// preferences captures the model's current view settings.
+func (m *Model) preferences() Preferences {
+	return Preferences{
+		SplitLeft:     m.splitLeft,
+		SplitTop:      m.splitTop,
+		Gantt:         m.ganttMode,
+		Grouped:       m.groupMode,
+		AbsoluteTimes: m.absoluteTimes,
+		TimeLayout:    m.opts.TimeLayout,
+		Theme:         m.theme,
+	}
+}
+
+// applyPreferences restores saved view settings. Out-of-range splits
+// are clamped, and a time layout or theme given on the command line
+// wins.
+func (m *Model) applyPreferences(p Preferences) {
+	if p.SplitLeft != 0 {
+		m.splitLeft = clamp(p.SplitLeft, minSplitLeft, maxSplitLeft)
+	}
+	if p.SplitTop != 0 {
+		m.splitTop = clamp(p.SplitTop, minSplitTop, maxSplitTop)
+	}
+	m.ganttMode = p.Gantt
+	m.groupMode = p.Grouped
+	m.absoluteTimes = p.AbsoluteTimes
+	if m.opts.Theme == ThemeDefault {
+		m.theme = p.Theme
+	}
+	applyTheme(m.theme)
+	if m.opts.TimeLayout == "" {
+		m.opts.TimeLayout = p.TimeLayout
+	}
+}
@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runCmd executes cmd and any batched commands it expands to, returning
+// the messages they produced.
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	batch, ok := msg.(tea.BatchMsg)
+	if !ok {
+		return []tea.Msg{msg}
+	}
+	var msgs []tea.Msg
+	for _, c := range batch {
+		msgs = append(msgs, runCmd(c)...)
+	}
+	return msgs
+}
+
+// TestPreferencesRoundTrip verifies that saved preferences load back
+// unchanged, creating the directory as needed.
+func TestPreferencesRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "tui.json")
+	want := Preferences{
+		SplitLeft:     60,
+		SplitTop:      40,
+		Gantt:         true,
+		Grouped:       true,
+		AbsoluteTimes: true,
+		TimeLayout:    "15:04",
+	}
+
+	if err := SavePreferences(path, want); err != nil {
+		t.Fatalf("SavePreferences failed: %v", err)
+	}
+	got, err := LoadPreferences(path)
+	if err != nil {
+		t.Fatalf("LoadPreferences failed: %v", err)
+	}
+	if got != want {
+		t.Errorf("round trip: got %+v, want %+v", got, want)
+	}
+}
+
+// TestPreferencesMissingOrCorrupt verifies that a missing file quietly
+// yields defaults and a corrupt one yields defaults with an error.
+func TestPreferencesMissingOrCorrupt(t *testing.T) {
+	dir := t.TempDir()
+
+	got, err := LoadPreferences(filepath.Join(dir, "absent.json"))
+	if err != nil || got != (Preferences{}) {
+		t.Errorf("missing file: got %+v, %v", got, err)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	os.WriteFile(corrupt, []byte(`{"split_left": 60,`), 0644)
+	got, err = LoadPreferences(corrupt)
+	if err == nil || got != (Preferences{}) {
+		t.Errorf("corrupt file: expected defaults and an error, got %+v, %v", got, err)
+	}
+
+	// Defaults leave the model untouched; wild values are clamped
+	m := newTestModel()
+	m.applyPreferences(got)
+	if m.splitLeft != defaultSplitLeft || m.splitTop != defaultSplitTop {
+		t.Errorf("expected default splits, got %d/%d", m.splitLeft, m.splitTop)
+	}
+	m.applyPreferences(Preferences{SplitLeft: 500, SplitTop: 1})
+	if m.splitLeft != maxSplitLeft || m.splitTop != minSplitTop {
+		t.Errorf("expected clamped splits, got %d/%d", m.splitLeft, m.splitTop)
+	}
+}
+
+// TestPreferencesSavedOnChange verifies that a key that changes a view
+// setting writes the preferences file, and a fresh model restores it.
+func TestPreferencesSavedOnChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tui.json")
+	m := newTestModel(newTestSpan("s0", "", "LLM", 0, 10))
+	m.opts.PrefsPath = path
+
+	updated, cmd := m.Update(keyMsg(">"))
+	if cmd == nil {
+		t.Fatal("expected a save command after changing the split")
+	}
+	for _, msg := range runCmd(cmd) {
+		if err, ok := msg.(errMsg); ok {
+			t.Fatalf("save failed: %v", err)
+		}
+	}
+
+	fresh := NewModelWithOptions(nil, Options{PrefsPath: path})
+	restored, _ := fresh.Update(fresh.loadPrefs()())
+	if got := restored.(Model).splitLeft; got != updated.(Model).splitLeft {
+		t.Errorf("expected splitLeft %d restored, got %d", updated.(Model).splitLeft, got)
+	}
+
+	// Navigation leaves the preferences alone
+	if _, cmd := updated.(Model).Update(keyMsg("j")); cmd != nil {
+		t.Error("expected no save command for a navigation key")
+	}
+}
@@ -1,6 +1,10 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+)
 
 // ────────────────────────────────────────────────────────────
 // Color Palette — GitHub Dark aesthetic
@@ -121,6 +125,15 @@ var (
 
 	treeDurationStyle = lipgloss.NewStyle().
 				Foreground(colorTextDim)
+
+	bookmarkStyle = lipgloss.NewStyle().
+			Foreground(colorYellow)
+
+	spanCycleStyle = lipgloss.NewStyle().
+			Foreground(colorRed)
+
+	memoryBadgeStyle = lipgloss.NewStyle().
+				Foreground(colorYellow)
 )
 
 // Detail pane
@@ -163,6 +176,24 @@ var (
 			Bold(true)
 )
 
+// JSON syntax highlighting
+var (
+	jsonKeyStyle = lipgloss.NewStyle().
+			Foreground(colorBlue)
+
+	jsonStringStyle = lipgloss.NewStyle().
+			Foreground(colorGreen)
+
+	jsonNumberStyle = lipgloss.NewStyle().
+			Foreground(colorCyan)
+
+	jsonLiteralStyle = lipgloss.NewStyle().
+				Foreground(colorPurple)
+
+	jsonPunctStyle = lipgloss.NewStyle().
+			Foreground(colorTextMuted)
+)
+
 // Footer / status bar
 var (
 	statusStyle = lipgloss.NewStyle().
@@ -220,7 +251,84 @@ var (
 			Background(colorBgSurface).
 			Padding(0, 1)
 
+	filterBadgeStyle = lipgloss.NewStyle().
+				Foreground(colorYellow).
+				Background(colorBgSurface).
+				Padding(0, 1)
+
 	searchCursorStyle = lipgloss.NewStyle().
 				Background(colorBlue).
 				Foreground(colorBg)
+
+	searchMatchStyle = lipgloss.NewStyle().
+				Background(colorYellow).
+				Foreground(colorBg)
+)
+
+// ────────────────────────────────────────────────────────────
+// Themes
+// ────────────────────────────────────────────────────────────
+
+// Theme names a color palette for status and diff polarity.
+type Theme string
+
+const (
+	ThemeDefault    Theme = ""
+	ThemeColorBlind Theme = "colorblind"
 )
+
+// String returns the theme's display name.
+func (t Theme) String() string {
+	if t == ThemeDefault {
+		return "default"
+	}
+	return string(t)
+}
+
+// ParseTheme looks up a theme by its display name.
+func ParseTheme(name string) (Theme, error) {
+	switch name {
+	case "", ThemeDefault.String():
+		return ThemeDefault, nil
+	case string(ThemeColorBlind):
+		return ThemeColorBlind, nil
+	}
+	return ThemeDefault, fmt.Errorf("unknown theme %q: want default or colorblind", name)
+}
+
+// Color-blind-safe accents from the Okabe–Ito palette. Blue and orange
+// stay distinguishable under red-green color blindness.
+var (
+	colorSafeBlue   = lipgloss.Color("#56b4e9")
+	colorSafeOrange = lipgloss.Color("#e69f00")
+)
+
+// polarityStyles are the styles that tell good from bad: added versus
+// deleted memory and completed versus failed spans and traces.
+type polarityStyles struct {
+	add, del lipgloss.Style
+	ok, fail lipgloss.Style
+}
+
+// themePolarity returns the polarity styles for t.
+func themePolarity(t Theme) polarityStyles {
+	good, bad := colorGreen, colorRed
+	if t == ThemeColorBlind {
+		good, bad = colorSafeBlue, colorSafeOrange
+	}
+	return polarityStyles{
+		add:  lipgloss.NewStyle().Foreground(good),
+		del:  lipgloss.NewStyle().Foreground(bad),
+		ok:   lipgloss.NewStyle().Foreground(good),
+		fail: lipgloss.NewStyle().Foreground(bad),
+	}
+}
+
+// applyTheme swaps the polarity styles for those of t. Symbols (+/−,
+// ✓/✗) carry the same meaning in every theme.
+func applyTheme(t Theme) {
+	p := themePolarity(t)
+	diffAddStyle, diffDelStyle = p.add, p.del
+	traceStatusOk, traceStatusFail = p.ok, p.fail
+	spanCycleStyle = p.fail
+}
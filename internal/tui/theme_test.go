@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestColorBlindTheme verifies that the color-blind-safe variant draws
+// additions and deletions in blue and orange rather than green and red,
+// and that T toggles it.
+func TestColorBlindTheme(t *testing.T) {
+	defer applyTheme(ThemeDefault)
+
+	def, safe := themePolarity(ThemeDefault), themePolarity(ThemeColorBlind)
+	if safe.add.GetForeground() != colorSafeBlue || safe.del.GetForeground() != colorSafeOrange {
+		t.Errorf("expected blue/orange polarity, got %v/%v", safe.add.GetForeground(), safe.del.GetForeground())
+	}
+	for _, c := range []any{safe.add.GetForeground(), safe.del.GetForeground(), safe.ok.GetForeground(), safe.fail.GetForeground()} {
+		if c == colorGreen || c == colorRed {
+			t.Errorf("expected no red or green in the color-blind theme, got %v", c)
+		}
+	}
+	if def.add.GetForeground() != colorGreen || def.del.GetForeground() != colorRed {
+		t.Error("expected the default theme to keep green/red")
+	}
+
+	m := newTestModel(newTestSpan("s0", "", "LLM", 0, 10))
+	m, _ = press(m, "T")
+	if m.theme != ThemeColorBlind || diffAddStyle.GetForeground() != colorSafeBlue {
+		t.Fatalf("expected T to apply the color-blind theme, got %q", m.theme)
+	}
+	if !strings.Contains(m.statusMsg, "colorblind") {
+		t.Errorf("expected the theme in the status line, got %q", m.statusMsg)
+	}
+	m, _ = press(m, "T")
+	if m.theme != ThemeDefault || diffAddStyle.GetForeground() != colorGreen {
+		t.Error("expected a second T to restore the default theme")
+	}
+}
+
+// TestThemeOption verifies that a theme passed in Options is applied at
+// startup and wins over the saved preference.
+func TestThemeOption(t *testing.T) {
+	defer applyTheme(ThemeDefault)
+
+	m := NewModelWithOptions(&fakeStore{}, Options{Theme: ThemeColorBlind})
+	if m.theme != ThemeColorBlind || diffAddStyle.GetForeground() != colorSafeBlue {
+		t.Fatalf("expected the color-blind theme from Options, got %q", m.theme)
+	}
+	m.applyPreferences(Preferences{Theme: ThemeDefault})
+	if m.theme != ThemeColorBlind {
+		t.Error("expected the Options theme to win over the saved preference")
+	}
+
+	if _, err := ParseTheme("colorblind"); err != nil {
+		t.Errorf("ParseTheme(colorblind): %v", err)
+	}
+	if _, err := ParseTheme("neon"); err == nil {
+		t.Error("expected an error for an unknown theme")
+	}
+}
@@ -32,11 +32,8 @@ func renderTimeline(m *Model, width, height int) string {
 
 	contentHeight := height - 2
 
-	// Scroll so selected span is visible
-	scrollStart := 0
-	if m.selectedSpan >= contentHeight {
-		scrollStart = m.selectedSpan - contentHeight + 1
-	}
+	// Follow the selection; the stored offset keeps the window steady
+	scrollStart := scrollWindow(m.scrollOffset, m.selectedSpan, contentHeight, len(m.spanTree))
 
 	end := scrollStart + contentHeight
 	if end > len(m.spanTree) {
@@ -70,13 +67,40 @@ func renderTimeline(m *Model, width, height int) string {
 		// Duration
 		dur := treeDurationStyle.Render(timeutil.FormatDuration(node.span.DurationMs))
 
-		line := fmt.Sprintf("%s%s %s %s %s", indent, connector, tag, name, dur)
+		// Bookmark and parent-cycle markers
+		mark := ""
+		if m.bookmarks[node.span.SpanID] {
+			mark = bookmarkStyle.Render("★") + " "
+		}
+		if node.cycle {
+			mark += spanCycleStyle.Render("↻") + " "
+		}
+		if spanFailed(node.span) {
+			mark += traceStatusFail.Render("✗") + " "
+		}
+
+		// Memory mutation badge
+		badge := ""
+		if n := node.span.MemoryEventCount; n > 0 {
+			badge = " " + memoryBadgeStyle.Render(fmt.Sprintf("\u0394%d", n))
+		}
+
+		line := fmt.Sprintf("%s%s %s%s %s %s%s", indent, connector, mark, tag, name, dur, badge)
+
+		// Error message, in whatever room the line leaves
+		errMsg := ""
+		if node.span.ErrorMessage != nil && *node.span.ErrorMessage != "" {
+			if room := width - lipgloss.Width(line) - 1; room >= 8 {
+				errMsg = " " + truncate(*node.span.ErrorMessage, room)
+			}
+		}
 
 		if i == m.selectedSpan {
 			line = spanSelectedStyle.Width(width).Render(
-				fmt.Sprintf("%s%s %s %s %s", indent, "\u251c\u2500", opTag(node.span.OperationType), name, timeutil.FormatDuration(node.span.DurationMs)))
+				fmt.Sprintf("%s%s %s%s %s %s%s%s", indent, "\u251c\u2500", mark, opTag(node.span.OperationType), name, timeutil.FormatDuration(node.span.DurationMs), badge, errMsg))
 		} else {
-			line = opStyle(node.span.OperationType).Render(line)
+			line = opStyle(node.span.OperationType).Render(line) +
+				traceStatusFail.Render(errMsg)
 		}
 
 		lines = append(lines, line)
@@ -98,7 +122,14 @@ func renderTimeline(m *Model, width, height int) string {
 
 // renderTimelinePanel wraps the timeline in a styled panel.
 func renderTimelinePanel(m *Model, width, height int) string {
-	content := renderTimeline(m, width-4, height-2)
+	var content string
+	if m.ganttMode {
+		content = renderGantt(m, width-4, height-2)
+	} else if m.groupMode {
+		content = renderGrouped(m, width-4, height-2)
+	} else {
+		content = renderTimeline(m, width-4, height-2)
+	}
 
 	style := panelStyle
 	if m.activePane == PaneTimeline {
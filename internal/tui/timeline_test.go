@@ -0,0 +1,184 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+)
+
+// manySpans returns n sequential root spans named span-00, span-01, ...
+func manySpans(n int) []*database.Span {
+	spans := make([]*database.Span, n)
+	for i := range spans {
+		spans[i] = newTestSpan(fmt.Sprintf("span-%02d", i), "", "LLM", int64(i*10), 10)
+	}
+	return spans
+}
+
+// TestScrollWindow verifies that the window only moves when the
+// selection leaves it, in either direction.
+func TestScrollWindow(t *testing.T) {
+	tests := []struct {
+		offset, selected, want int
+	}{
+		{0, 3, 0},    // inside the window
+		{0, 12, 8},   // below: scroll down until visible
+		{8, 10, 8},   // inside again: window stays put
+		{8, 5, 5},    // above: scroll up to include it
+		{15, 19, 15}, // last row
+	}
+	for _, tt := range tests {
+		if got := scrollWindow(tt.offset, tt.selected, 5, 20); got != tt.want {
+			t.Errorf("scrollWindow(%d, %d, 5, 20) = %d, want %d",
+				tt.offset, tt.selected, got, tt.want)
+		}
+	}
+}
+
+// TestTimelineScrollsUp verifies that selecting a span above the
+// visible window scrolls the timeline back up to show it.
+func TestTimelineScrollsUp(t *testing.T) {
+	m := newTestModel(manySpans(40)...)
+	m.height = 20
+
+	for i := 0; i < 30; i++ {
+		m, _ = press(m, "j")
+	}
+	if m.scrollOffset == 0 {
+		t.Fatal("expected the timeline to scroll down")
+	}
+	top := m.scrollOffset
+
+	// Moving up inside the window keeps it steady
+	m, _ = press(m, "k")
+	if m.scrollOffset != top {
+		t.Errorf("expected offset to stay at %d, got %d", top, m.scrollOffset)
+	}
+
+	// Jumping above the window scrolls up to include the selection
+	m, _ = press(m, ":")
+	m, _ = press(m, strings.Split("span-02", "")...)
+	m, _ = press(m, "enter")
+	out := renderTimeline(&m, 60, m.timelineRows()+2)
+	if !strings.Contains(out, "span-02") {
+		t.Errorf("expected span-02 to be visible after scrolling up, got:\n%s", out)
+	}
+	if m.scrollOffset > 2 {
+		t.Errorf("expected offset <= 2, got %d", m.scrollOffset)
+	}
+}
+
+// TestTimelineErrorMarker verifies that failed spans carry the error
+// marker and their message, while ok spans do not.
+func TestTimelineErrorMarker(t *testing.T) {
+	failed := newTestSpan("fetch", "", "TOOL", 10, 5)
+	failed.Status = "error"
+	msg := "connection refused"
+	failed.ErrorMessage = &msg
+
+	m := newTestModel(newTestSpan("plan", "", "PLANNING", 0, 5), failed)
+	lines := strings.Split(renderTimeline(&m, 80, 20), "\n")
+
+	var okLine, failedLine string
+	for _, l := range lines {
+		switch {
+		case strings.Contains(l, "plan"):
+			okLine = l
+		case strings.Contains(l, "fetch"):
+			failedLine = l
+		}
+	}
+
+	if strings.Contains(okLine, "✗") {
+		t.Errorf("ok span should not carry the error marker: %q", okLine)
+	}
+	if !strings.Contains(failedLine, "✗") {
+		t.Errorf("failed span should carry the error marker: %q", failedLine)
+	}
+	if !strings.Contains(failedLine, msg) {
+		t.Errorf("failed span should show its error message: %q", failedLine)
+	}
+}
+
+// TestMinDurationFilter verifies that raising the duration threshold
+// hides fast spans and clamps the selection into the filtered set.
+func TestMinDurationFilter(t *testing.T) {
+	m := newTestModel(
+		newTestSpan("slow", "", "PLANNING", 0, 500),
+		newTestSpan("fast", "slow", "MEMORY", 10, 0),
+		newTestSpan("medium", "slow", "LLM", 20, 40),
+	)
+	m.selectedSpan = 1 // fast
+
+	m, _ = press(m, "m") // >1ms
+	if got := strings.Join(treeIDs(m.spanTree), ","); got != "slow,medium" {
+		t.Fatalf("expected sub-millisecond span hidden, got %s", got)
+	}
+	if m.selectedSpan >= len(m.spanTree) {
+		t.Fatalf("selection %d outside filtered tree", m.selectedSpan)
+	}
+
+	m, _ = press(m, "m", "m") // >100ms
+	if got := strings.Join(treeIDs(m.spanTree), ","); got != "slow" {
+		t.Fatalf("expected only slow span at 100ms, got %s", got)
+	}
+	if m.selectedSpan != 0 {
+		t.Errorf("expected selection clamped to 0, got %d", m.selectedSpan)
+	}
+	if footer := renderFooter(&m); !strings.Contains(footer, "filtered: >100ms") {
+		t.Errorf("expected filter badge in footer, got %q", footer)
+	}
+
+	m, _ = press(m, "m", "m") // wraps back to off
+	if len(m.spanTree) != 3 {
+		t.Errorf("expected all spans after the filter is cleared, got %d", len(m.spanTree))
+	}
+}
+
+// TestTimelineMemoryBadge verifies that spans with memory events carry
+// a Δ badge with the count and spans without any do not.
+func TestTimelineMemoryBadge(t *testing.T) {
+	writer := newTestSpan("writer", "", "MEMORY", 0, 5)
+	writer.MemoryEventCount = 3
+	m := newTestModel(writer, newTestSpan("reader", "", "LLM", 10, 5))
+
+	for _, l := range strings.Split(renderTimeline(&m, 80, 20), "\n") {
+		switch {
+		case strings.Contains(l, "writer") && !strings.Contains(l, "Δ3"):
+			t.Errorf("expected Δ3 badge: %q", l)
+		case strings.Contains(l, "reader") && strings.Contains(l, "Δ"):
+			t.Errorf("expected no badge: %q", l)
+		}
+	}
+}
+
+// TestTimelinePaging verifies that G and g jump to the last and first
+// span and that PgDn/PgUp move by one screenful.
+func TestTimelinePaging(t *testing.T) {
+	m := newTestModel(manySpans(100)...)
+
+	m, cmd := press(m, "G")
+	if m.selectedSpan != 99 || cmd == nil {
+		t.Fatalf("expected G to select the last span and load its diffs, got %d", m.selectedSpan)
+	}
+	if m.scrollOffset == 0 {
+		t.Error("expected the timeline to scroll to the last span")
+	}
+
+	m, _ = press(m, "g")
+	if m.selectedSpan != 0 || m.scrollOffset != 0 {
+		t.Fatalf("expected g to select the first span, got %d (offset %d)", m.selectedSpan, m.scrollOffset)
+	}
+
+	rows := m.timelineRows()
+	m, _ = press(m, "pgdown")
+	if m.selectedSpan != rows {
+		t.Errorf("expected PgDn to move %d spans, got %d", rows, m.selectedSpan)
+	}
+	m, _ = press(m, "pgup", "pgup")
+	if m.selectedSpan != 0 {
+		t.Errorf("expected PgUp to clamp at the first span, got %d", m.selectedSpan)
+	}
+}
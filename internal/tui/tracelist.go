@@ -4,10 +4,45 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/Mr-Dark-debug/oculo/internal/database"
 	"github.com/Mr-Dark-debug/oculo/pkg/timeutil"
 	"github.com/charmbracelet/lipgloss"
 )
 
+// traceAgentMaxWidth caps the agent name column in the trace list.
+const traceAgentMaxWidth = 24
+
+// traceListWindow returns the range of trace indices visible in the
+// trace list, scrolled so the selected trace stays on screen.
+func traceListWindow(m *Model) (start, end int) {
+	maxVisible := m.height - 6
+	if maxVisible < 5 {
+		maxVisible = 5
+	}
+
+	if m.selectedTrace >= maxVisible {
+		start = m.selectedTrace - maxVisible + 1
+	}
+	end = minInt(start+maxVisible, len(m.visibleTraces()))
+	return start, end
+}
+
+// visibleTraces returns the traces shown in the trace list: those whose
+// ID starts with the search query, or all of them without one.
+func (m *Model) visibleTraces() []*database.Trace {
+	if m.searchQuery == "" {
+		return m.traces
+	}
+	prefix := strings.ToLower(m.searchQuery)
+	var traces []*database.Trace
+	for _, t := range m.traces {
+		if strings.HasPrefix(strings.ToLower(t.TraceID), prefix) {
+			traces = append(traces, t)
+		}
+	}
+	return traces
+}
+
 // renderTraceList renders the trace selection screen.
 func renderTraceList(m *Model) string {
 	if len(m.traces) == 0 {
@@ -24,39 +59,41 @@ func renderTraceList(m *Model) string {
 		)
 	}
 
+	traces := m.visibleTraces()
 	title := panelTitleStyle.Render("Traces")
 	count := traceDimStyle.Render(fmt.Sprintf("  %d total", len(m.traces)))
+	if m.searchQuery != "" {
+		count = traceDimStyle.Render(fmt.Sprintf("  %d of %d", len(traces), len(m.traces))) +
+			filterBadgeStyle.Render("id: "+m.searchQuery+"\u2026")
+	}
 	heading := title + count
 
 	var lines []string
 	lines = append(lines, heading)
 	lines = append(lines, "")
-
-	// Visible range for scrolling
-	maxVisible := m.height - 6
-	if maxVisible < 5 {
-		maxVisible = 5
+	if len(traces) == 0 {
+		lines = append(lines, traceDimStyle.Render("  No trace IDs start with "+m.searchQuery))
 	}
 
-	startIdx := 0
-	if m.selectedTrace >= maxVisible {
-		startIdx = m.selectedTrace - maxVisible + 1
-	}
-	endIdx := startIdx + maxVisible
-	if endIdx > len(m.traces) {
-		endIdx = len(m.traces)
+	startIdx, endIdx := traceListWindow(m)
+
+	// Pad agent names to the longest visible one so columns line up
+	agentWidth := 0
+	for _, t := range traces[startIdx:endIdx] {
+		agentWidth = maxInt(agentWidth, len([]rune(t.AgentName)))
 	}
+	agentWidth = minInt(agentWidth, traceAgentMaxWidth)
 
 	for i := startIdx; i < endIdx; i++ {
-		t := m.traces[i]
+		t := traces[i]
 
 		// Status indicator
 		var statusDot string
 		switch t.Status {
 		case "completed":
-			statusDot = traceStatusOk.Render("\u25cf")
+			statusDot = traceStatusOk.Render("\u2713")
 		case "failed":
-			statusDot = traceStatusFail.Render("\u25cf")
+			statusDot = traceStatusFail.Render("\u2717")
 		case "running":
 			statusDot = traceStatusRunning.Render("\u25cb")
 		default:
@@ -64,9 +101,16 @@ func renderTraceList(m *Model) string {
 		}
 
 		id := traceDimStyle.Render(shortID(t.TraceID, 10))
-		ts := traceDimStyle.Render(timeutil.FormatTimestampFull(t.StartTime))
+		ts := timeutil.RelativeTime(t.StartTime)
+		if m.absoluteTimes {
+			ts = m.formatTime(t.StartTime)
+		}
+		ts = traceDimStyle.Render(ts)
+
+		agent := fmt.Sprintf("%-*s", agentWidth, truncate(t.AgentName, agentWidth))
+		size := traceDimStyle.Render(fmt.Sprintf("%5d spans  %8d tok", t.SpanCount, t.TotalTokens))
 
-		content := fmt.Sprintf("%s  %s  %s  %s", statusDot, t.AgentName, id, ts)
+		content := fmt.Sprintf("%s  %s  %s  %s  %s", statusDot, agent, id, size, ts)
 
 		if i == m.selectedTrace {
 			line := traceSelectedStyle.Width(m.width - 4).Render(content)
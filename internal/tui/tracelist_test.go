@@ -0,0 +1,145 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mr-Dark-debug/oculo/internal/database"
+	"github.com/Mr-Dark-debug/oculo/pkg/timeutil"
+)
+
+// TestTraceListRelativeTimes verifies that trace start times read as
+// relative times by default and as full timestamps after t.
+func TestTraceListRelativeTimes(t *testing.T) {
+	old := time.Now().Add(-72 * time.Hour).UnixNano()
+	m := NewModel(nil)
+	m.width = 120
+	m.height = 40
+	m.traces = []*database.Trace{
+		{TraceID: "trace-new", AgentName: "fresh-bot", StartTime: time.Now().UnixNano(), Status: "running"},
+		{TraceID: "trace-old", AgentName: "stale-bot", StartTime: old, Status: "completed"},
+	}
+
+	lines := strings.Split(renderTraceList(&m), "\n")
+	row := func(agent string) string {
+		for _, l := range lines {
+			if strings.Contains(l, agent) {
+				return l
+			}
+		}
+		t.Fatalf("no row for %s", agent)
+		return ""
+	}
+	if !strings.Contains(row("fresh-bot"), "just now") {
+		t.Errorf("expected new trace to read \"just now\": %q", row("fresh-bot"))
+	}
+	if !strings.Contains(row("stale-bot"), "3d ago") {
+		t.Errorf("expected old trace to read \"3d ago\": %q", row("stale-bot"))
+	}
+
+	m, _ = press(m, "t")
+	lines = strings.Split(renderTraceList(&m), "\n")
+	if !strings.Contains(row("stale-bot"), timeutil.FormatTimestampFull(old)) {
+		t.Errorf("expected absolute timestamp after toggle: %q", row("stale-bot"))
+	}
+}
+
+// TestTraceListSpanCount verifies that each trace row shows its span
+// count and that the columns after the agent name line up.
+func TestTraceListSpanCount(t *testing.T) {
+	now := time.Now().UnixNano()
+	m := NewModel(nil)
+	m.width = 120
+	m.height = 40
+	m.traces = []*database.Trace{
+		{TraceID: "trace-a", AgentName: "bot", StartTime: now, Status: "completed", SpanCount: 42, TotalTokens: 1200},
+		{TraceID: "trace-b", AgentName: "research-bot", StartTime: now, Status: "completed", SpanCount: 7},
+	}
+
+	lines := strings.Split(renderTraceList(&m), "\n")[2:]
+	if !strings.Contains(lines[0], "42 spans") || !strings.Contains(lines[0], "1200 tok") {
+		t.Errorf("expected span count and tokens in row: %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "7 spans") {
+		t.Errorf("expected span count in row: %q", lines[1])
+	}
+
+	col := func(line string) int { return strings.Index(line, "trace-") }
+	if col(lines[0]) != col(lines[1]) {
+		t.Errorf("expected trace IDs aligned, got columns %d and %d", col(lines[0]), col(lines[1]))
+	}
+}
+
+// TestCustomTimeLayout verifies that a configured time layout is used
+// by the trace list, the detail pane and the memory diff.
+func TestCustomTimeLayout(t *testing.T) {
+	const layout = "Jan 2 3:04PM"
+	start := time.Date(2024, 3, 9, 14, 30, 0, 0, time.Local).UnixNano()
+	want := "Mar 9 2:30PM"
+
+	span := newTestSpan("s0", "", "MEMORY", 0, 10)
+	span.StartTime = start
+	m := newTestModel(span)
+	m.opts.TimeLayout = layout
+	m.absoluteTimes = true
+	m.traces = []*database.Trace{{TraceID: "trace-a", AgentName: "bot", StartTime: start, Status: "completed"}}
+	val := "v"
+	m.memoryDiffs = []*database.MemoryEvent{{
+		SpanID: "s0", Operation: "ADD", Key: "k", Namespace: "default",
+		NewValue: &val, Timestamp: start,
+	}}
+
+	if out := renderTraceList(&m); !strings.Contains(out, want) {
+		t.Errorf("trace list: expected %q, got:\n%s", want, out)
+	}
+	if out := renderDetail(&m, 60, 30); !strings.Contains(out, want) {
+		t.Errorf("detail: expected %q, got:\n%s", want, out)
+	}
+	if out := renderDiffView(&m, 80, 10); !strings.Contains(out, want) {
+		t.Errorf("diff: expected %q, got:\n%s", want, out)
+	}
+}
+
+// TestTraceListIDFilter verifies that typing after / narrows the trace
+// list to IDs with that prefix, enter opens the match, and esc clears
+// the filter with the selection kept.
+func TestTraceListIDFilter(t *testing.T) {
+	m := NewModel(nil)
+	m.width = 120
+	m.height = 40
+	m.traces = []*database.Trace{
+		{TraceID: "a1b2c3", AgentName: "alpha-bot"},
+		{TraceID: "f00d01", AgentName: "beta-bot"},
+		{TraceID: "a1ffee", AgentName: "gamma-bot"},
+		{TraceID: "f00d02", AgentName: "delta-bot"},
+	}
+
+	m, _ = press(m, "/")
+	m, _ = press(m, strings.Split("F00D", "")...)
+	m, _ = press(m, "enter")
+
+	out := renderTraceList(&m)
+	for _, agent := range []string{"beta-bot", "delta-bot"} {
+		if !strings.Contains(out, agent) {
+			t.Errorf("expected %s to match the prefix, got:\n%s", agent, out)
+		}
+	}
+	for _, agent := range []string{"alpha-bot", "gamma-bot"} {
+		if strings.Contains(out, agent) {
+			t.Errorf("expected %s to be filtered out, got:\n%s", agent, out)
+		}
+	}
+	if !strings.Contains(out, "2 of 4") {
+		t.Errorf("expected a match count, got:\n%s", out)
+	}
+
+	m, _ = press(m, "j")
+	m, _ = press(m, "esc")
+	if m.searchQuery != "" || len(m.visibleTraces()) != 4 {
+		t.Fatal("expected esc to clear the filter")
+	}
+	if got := m.traces[m.selectedTrace].TraceID; got != "f00d02" {
+		t.Errorf("expected the selection to stay on f00d02, got %s", got)
+	}
+}
@@ -5,24 +5,275 @@
 package jsonutil
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math/big"
 	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 // PrettyJSON formats a JSON string with indentation for display.
 // Returns the original string if it's not valid JSON.
 func PrettyJSON(s string) string {
-	var obj interface{}
-	if err := json.Unmarshal([]byte(s), &obj); err != nil {
+	var buf bytes.Buffer
+	if err := PrettyJSONStream(strings.NewReader(s), &buf); err != nil {
 		return s
 	}
-	pretty, err := json.MarshalIndent(obj, "", "  ")
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// PrettyJSONStream reads one JSON value from r and writes it to w
+// indented by two spaces, followed by a newline. It works token by
+// token, so large documents are never held in memory as a whole, object
+// keys keep their document order, and numbers are written exactly as
+// they appear in the input.
+func PrettyJSONStream(r io.Reader, w io.Writer) error {
+	return prettyStream(r, w, ColorFns{})
+}
+
+// ColorFns wrap each kind of JSON token for PrettyJSONColored, e.g.
+// with ANSI escapes or lipgloss styles. A nil function leaves that kind
+// of token as it is.
+type ColorFns struct {
+	Key     func(string) string // object keys, with their quotes
+	String  func(string) string // string values, with their quotes
+	Number  func(string) string
+	Literal func(string) string // true, false and null
+	Punct   func(string) string // braces, brackets, commas and colons
+}
+
+// PrettyJSONColored is PrettyJSON with each token passed through the
+// matching color function. With no functions set the output is exactly
+// PrettyJSON's. Returns the original string if it's not valid JSON.
+func PrettyJSONColored(jsonStr string, colors ColorFns) string {
+	var buf bytes.Buffer
+	if err := prettyStream(strings.NewReader(jsonStr), &buf, colors); err != nil {
+		return jsonStr
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func prettyStream(r io.Reader, w io.Writer, colors ColorFns) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	p := &prettyPrinter{dec: dec, w: bufio.NewWriter(w), colors: colors}
+
+	if err := p.document(); err != nil {
+		return err
+	}
+	p.w.WriteByte('\n')
+	if err := p.w.Flush(); err != nil {
+		return fmt.Errorf("writing JSON: %w", err)
+	}
+	return nil
+}
+
+// truncatedMarker is the line PrettyJSONLimit ends cut-short output with.
+const truncatedMarker = "// …truncated"
+
+// errTruncated stops a size-limited pretty-print once the limit is passed.
+var errTruncated = errors.New("output limit reached")
+
+// PrettyJSONLimit is PrettyJSON for documents that may be too big to
+// display. It stops once the output passes maxBytes and reports whether
+// it did. Cut output ends after the last top-level member that fits, or
+// failing that after the last whole line, followed by a
+// "// …truncated" line. Invalid JSON is cut at maxBytes the same way.
+// A maxBytes of zero or less means no limit.
+func PrettyJSONLimit(s string, maxBytes int) (string, bool) {
+	if maxBytes <= 0 {
+		return PrettyJSON(s), false
+	}
+
+	var buf bytes.Buffer
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	p := &prettyPrinter{dec: dec, w: bufio.NewWriter(&buf), limit: maxBytes}
+	p.size = func() int { return buf.Len() + p.w.Buffered() }
+
+	err := p.document()
+	p.w.Flush()
+	out := buf.String()
+	switch {
+	case err != nil && !errors.Is(err, errTruncated):
+		out, p.boundary = s, 0
+		if len(out) <= maxBytes {
+			return out, false
+		}
+	case len(out) <= maxBytes:
+		return out, false
+	}
+
+	cut := p.boundary
+	if cut == 0 {
+		cut = strings.LastIndexByte(out[:maxBytes], '\n')
+	}
+	if cut <= 0 {
+		cut = maxBytes
+		for cut > 0 && !utf8.RuneStart(out[cut]) {
+			cut--
+		}
+	}
+	return out[:cut] + "\n" + truncatedMarker, true
+}
+
+// prettyPrinter writes indented JSON as it reads tokens. Write errors
+// are sticky in the bufio.Writer and surface at Flush.
+type prettyPrinter struct {
+	dec    *json.Decoder
+	w      *bufio.Writer
+	colors ColorFns
+
+	// When size is set, output is limited to limit bytes: printing
+	// stops with errTruncated once size passes it, and boundary is the
+	// size after the last top-level member that fit.
+	size     func() int
+	limit    int
+	boundary int
+}
+
+// document writes the single JSON value the decoder holds.
+func (p *prettyPrinter) document() error {
+	tok, err := p.dec.Token()
 	if err != nil {
-		return s
+		return fmt.Errorf("reading JSON: %w", err)
+	}
+	if err := p.value(tok, 0); err != nil {
+		return fmt.Errorf("reading JSON: %w", err)
+	}
+	if _, err := p.dec.Token(); err != io.EOF {
+		return fmt.Errorf("reading JSON: unexpected data after JSON value")
 	}
-	return string(pretty)
+	return nil
+}
+
+// value writes the value that starts with tok, depth levels deep.
+func (p *prettyPrinter) value(tok json.Token, depth int) error {
+	switch t := tok.(type) {
+	case json.Delim:
+		return p.container(t, depth)
+	case string:
+		p.string(t, p.colors.String)
+	case json.Number:
+		p.paint(p.colors.Number, t.String())
+	case bool:
+		p.paint(p.colors.Literal, strconv.FormatBool(t))
+	case nil:
+		p.paint(p.colors.Literal, "null")
+	}
+	return nil
+}
+
+// container writes an object or array whose opening delimiter has just
+// been read. Empty ones stay on one line, as MarshalIndent writes them.
+func (p *prettyPrinter) container(open json.Delim, depth int) error {
+	closing := "]"
+	if open == '{' {
+		closing = "}"
+	}
+	p.paint(p.colors.Punct, open.String())
+
+	first := true
+	for p.dec.More() {
+		if !first {
+			p.paint(p.colors.Punct, ",")
+		}
+		first = false
+		p.newline(depth + 1)
+
+		if open == '{' {
+			key, err := p.dec.Token()
+			if err != nil {
+				return err
+			}
+			p.string(key.(string), p.colors.Key)
+			p.paint(p.colors.Punct, ":")
+			p.w.WriteByte(' ')
+		}
+		tok, err := p.dec.Token()
+		if err != nil {
+			return err
+		}
+		if err := p.value(tok, depth+1); err != nil {
+			return err
+		}
+		if p.size != nil {
+			n := p.size()
+			if depth == 0 && n <= p.limit {
+				p.boundary = n
+			}
+			if n > p.limit {
+				return errTruncated
+			}
+		}
+	}
+	if !first {
+		p.newline(depth)
+	}
+	p.paint(p.colors.Punct, closing)
+
+	// Consume the closing delimiter
+	_, err := p.dec.Token()
+	return err
+}
+
+func (p *prettyPrinter) newline(depth int) {
+	p.w.WriteByte('\n')
+	for i := 0; i < depth; i++ {
+		p.w.WriteString("  ")
+	}
+}
+
+// paint writes s through color, or as-is when color is nil.
+func (p *prettyPrinter) paint(color func(string) string, s string) {
+	if color != nil {
+		s = color(s)
+	}
+	p.w.WriteString(s)
+}
+
+// string writes s as a JSON string without escaping HTML characters.
+func (p *prettyPrinter) string(s string, color func(string) string) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	p.paint(color, strings.TrimSuffix(buf.String(), "\n"))
+}
+
+// Validate checks that jsonStr is a single well-formed JSON value. For a
+// syntax error it reports where the problem is as a line, column and
+// byte offset, so a rejected payload can be located without a debugger.
+func Validate(jsonStr string) error {
+	var raw json.RawMessage
+	err := json.Unmarshal([]byte(jsonStr), &raw)
+	if err == nil {
+		return nil
+	}
+
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	// Offset counts the bytes read when the error was found, so the
+	// offending byte is the one before it
+	offset := int(syntaxErr.Offset)
+	line, col := 1, 1
+	for _, c := range []byte(jsonStr[:max(offset-1, 0)]) {
+		if c == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return fmt.Errorf("invalid JSON at line %d, column %d (byte offset %d): %w", line, col, offset, err)
 }
 
 // CompactJSON minifies a JSON string by removing whitespace.
@@ -34,6 +285,17 @@ func CompactJSON(s string) string {
 	return buf.String()
 }
 
+// Equal reports whether two JSON strings hold the same value, ignoring
+// whitespace and object key order. Numbers compare numerically. Invalid
+// JSON is never equal to anything, itself included.
+func Equal(a, b string) bool {
+	var av, bv interface{}
+	if decodeJSON(a, &av) != nil || decodeJSON(b, &bv) != nil {
+		return false
+	}
+	return valuesEqual(av, bv)
+}
+
 // SafeUnmarshal attempts to unmarshal JSON into a map.
 // Returns an empty map on error instead of failing.
 func SafeUnmarshal(s string) map[string]interface{} {
@@ -59,39 +321,147 @@ func MustMarshal(v interface{}) string {
 // Returns a list of changes with their paths and values.
 type JSONDiff struct {
 	Path     string `json:"path"`
-	Type     string `json:"type"` // "add", "update", "delete"
+	Type     string `json:"type"` // "add", "update", "type_change", "delete", "move"
 	OldValue string `json:"old_value,omitempty"`
 	NewValue string `json:"new_value,omitempty"`
+
+	// FromPath is where a moved value used to be; Path is where it is now.
+	FromPath string `json:"from_path,omitempty"`
 }
 
-// ComputeJSONDiff compares two JSON objects and returns the differences.
+// ComputeJSONDiff compares two JSON values and returns the differences.
 // This is used for generating memory mutation diffs when the memory
-// state is stored as JSON.
+// state is stored as JSON. Either side may be any JSON type; objects and
+// arrays are compared by key and index, and a changed top-level scalar
+// is reported as an update at the empty path. An empty string is
+// treated as an empty object.
 func ComputeJSONDiff(oldJSON, newJSON string) ([]JSONDiff, error) {
-	var oldMap, newMap map[string]interface{}
+	return ComputeJSONDiffWithOptions(oldJSON, newJSON, DiffOptions{})
+}
 
-	if oldJSON != "" {
-		if err := json.Unmarshal([]byte(oldJSON), &oldMap); err != nil {
-			return nil, fmt.Errorf("parsing old JSON: %w", err)
-		}
-	} else {
-		oldMap = make(map[string]interface{})
+// DefaultMaxDiffDepth is the nesting depth ComputeJSONDiff recurses to
+// when DiffOptions.MaxDepth is not set.
+const DefaultMaxDiffDepth = 64
+
+// DiffOptions tunes ComputeJSONDiffWithOptions.
+type DiffOptions struct {
+	// MaxDepth bounds how many levels of objects and arrays are
+	// recursed into. A differing subtree below it is reported as a
+	// single update of the whole value. Zero means DefaultMaxDiffDepth.
+	MaxDepth int
+
+	// DetectMoves reports a value that was deleted in one place and
+	// added unchanged in another as a single "move". Arrays are aligned
+	// on their unchanged elements first, so a relocated element shows
+	// as a move rather than as updates to every index in between.
+	DetectMoves bool
+}
+
+// differ carries the options through a recursive diff.
+type differ struct {
+	opts DiffOptions
+
+	// pointer makes paths JSON Pointers ("/a/0/b") instead of the
+	// dotted form ("a[0].b").
+	pointer bool
+}
+
+// keyPath is the path of member key of the object at prefix.
+func (d *differ) keyPath(prefix, key string) string {
+	if d.pointer {
+		return prefix + "/" + pointerEscaper.Replace(key)
+	}
+	if prefix == "" {
+		return key
 	}
+	return prefix + "." + key
+}
 
-	if newJSON != "" {
-		if err := json.Unmarshal([]byte(newJSON), &newMap); err != nil {
-			return nil, fmt.Errorf("parsing new JSON: %w", err)
-		}
-	} else {
-		newMap = make(map[string]interface{})
+// indexPath is the path of element i of the array at prefix.
+func (d *differ) indexPath(prefix string, i int) string {
+	if d.pointer {
+		return prefix + "/" + strconv.Itoa(i)
+	}
+	return fmt.Sprintf("%s[%d]", prefix, i)
+}
+
+// pointerEscaper escapes a key for use as a JSON Pointer token.
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// ComputeJSONDiffWithOptions is ComputeJSONDiff with explicit options.
+func ComputeJSONDiffWithOptions(oldJSON, newJSON string, opts DiffOptions) ([]JSONDiff, error) {
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = DefaultMaxDiffDepth
+	}
+
+	oldVal, err := decodeDiffSide(oldJSON)
+	if err != nil {
+		return nil, fmt.Errorf("parsing old JSON: %w", err)
+	}
+	newVal, err := decodeDiffSide(newJSON)
+	if err != nil {
+		return nil, fmt.Errorf("parsing new JSON: %w", err)
 	}
 
+	d := &differ{opts: opts}
 	var diffs []JSONDiff
-	diffs = diffMaps("", oldMap, newMap, diffs)
+	diffs = d.diffValues("", 0, oldVal, newVal, diffs)
+	if opts.DetectMoves {
+		diffs = pairMoves(diffs)
+	}
 	return diffs, nil
 }
 
-func diffMaps(prefix string, oldMap, newMap map[string]interface{}, diffs []JSONDiff) []JSONDiff {
+// patchOp is one JSON Patch (RFC 6902) operation.
+type patchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ComputeJSONPatch compares two JSON values like ComputeJSONDiff and
+// returns the changes as a JSON Patch (RFC 6902) document: an array of
+// "add", "remove" and "replace" operations whose paths are JSON
+// Pointers. Applying the patch to oldJSON yields newJSON. Unchanged
+// values give an empty array.
+func ComputeJSONPatch(oldJSON, newJSON string) ([]byte, error) {
+	oldVal, err := decodeDiffSide(oldJSON)
+	if err != nil {
+		return nil, fmt.Errorf("parsing old JSON: %w", err)
+	}
+	newVal, err := decodeDiffSide(newJSON)
+	if err != nil {
+		return nil, fmt.Errorf("parsing new JSON: %w", err)
+	}
+
+	d := &differ{opts: DiffOptions{MaxDepth: DefaultMaxDiffDepth}, pointer: true}
+	ops := []patchOp{}
+	for _, diff := range d.diffValues("", 0, oldVal, newVal, nil) {
+		switch diff.Type {
+		case "add":
+			ops = append(ops, patchOp{Op: "add", Path: diff.Path, Value: json.RawMessage(diff.NewValue)})
+		case "delete":
+			ops = append(ops, patchOp{Op: "remove", Path: diff.Path})
+		default: // "update", "type_change"
+			ops = append(ops, patchOp{Op: "replace", Path: diff.Path, Value: json.RawMessage(diff.NewValue)})
+		}
+	}
+	return json.Marshal(ops)
+}
+
+// decodeDiffSide parses one side of a diff.
+func decodeDiffSide(s string) (interface{}, error) {
+	if s == "" {
+		return map[string]interface{}{}, nil
+	}
+	var v interface{}
+	if err := decodeJSON(s, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (d *differ) diffMaps(prefix string, depth int, oldMap, newMap map[string]interface{}, diffs []JSONDiff) []JSONDiff {
 	// Collect all keys
 	allKeys := make(map[string]bool)
 	for k := range oldMap {
@@ -109,10 +479,7 @@ func diffMaps(prefix string, oldMap, newMap map[string]interface{}, diffs []JSON
 	sort.Strings(keys)
 
 	for _, k := range keys {
-		path := k
-		if prefix != "" {
-			path = prefix + "." + k
-		}
+		path := d.keyPath(prefix, k)
 
 		oldVal, oldExists := oldMap[k]
 		newVal, newExists := newMap[k]
@@ -131,34 +498,498 @@ func diffMaps(prefix string, oldMap, newMap map[string]interface{}, diffs []JSON
 				OldValue: toJSONStr(oldVal),
 			})
 		case oldExists && newExists:
-			oldStr := toJSONStr(oldVal)
-			newStr := toJSONStr(newVal)
-			if oldStr != newStr {
-				// Check if both are maps for recursive diff
-				oldChild, oldIsMap := oldVal.(map[string]interface{})
-				newChild, newIsMap := newVal.(map[string]interface{})
-				if oldIsMap && newIsMap {
-					diffs = diffMaps(path, oldChild, newChild, diffs)
-				} else {
-					diffs = append(diffs, JSONDiff{
-						Path:     path,
-						Type:     "update",
-						OldValue: oldStr,
-						NewValue: newStr,
-					})
-				}
+			diffs = d.diffValues(path, depth, oldVal, newVal, diffs)
+		}
+	}
+
+	return diffs
+}
+
+// diffArrays compares two JSON arrays element by element. Elements are
+// addressed as path[i]; a longer new array reports its tail as adds and
+// a shorter one reports the removed tail as deletes.
+func (d *differ) diffArrays(prefix string, depth int, oldArr, newArr []interface{}, diffs []JSONDiff) []JSONDiff {
+	if d.opts.DetectMoves && len(oldArr)*len(newArr) <= maxAlignCells {
+		return d.diffArraysAligned(prefix, depth, oldArr, newArr, diffs)
+	}
+	for i := 0; i < len(oldArr) || i < len(newArr); i++ {
+		path := d.indexPath(prefix, i)
+		switch {
+		case i >= len(oldArr):
+			diffs = append(diffs, JSONDiff{
+				Path:     path,
+				Type:     "add",
+				NewValue: toJSONStr(newArr[i]),
+			})
+		case i >= len(newArr):
+			diffs = append(diffs, JSONDiff{
+				Path:     path,
+				Type:     "delete",
+				OldValue: toJSONStr(oldArr[i]),
+			})
+		default:
+			diffs = d.diffValues(path, depth, oldArr[i], newArr[i], diffs)
+		}
+	}
+	if d.pointer && len(oldArr) > len(newArr) {
+		// JSON Patch applies operations in turn, so the removed tail
+		// must go from the end or each removal shifts the next index.
+		tail := diffs[len(diffs)-(len(oldArr)-len(newArr)):]
+		for l, r := 0, len(tail)-1; l < r; l, r = l+1, r-1 {
+			tail[l], tail[r] = tail[r], tail[l]
+		}
+	}
+	return diffs
+}
+
+// maxAlignCells caps the size of the table diffArraysAligned builds;
+// larger arrays are compared by index.
+const maxAlignCells = 1 << 20
+
+// diffArraysAligned compares two arrays after matching up their longest
+// common subsequence of equal elements. Between matched elements, the
+// remaining old and new elements are paired off in order and compared;
+// any left over are reported as deletes (at their old index) or adds (at
+// their new index), ready for pairMoves.
+func (d *differ) diffArraysAligned(prefix string, depth int, oldArr, newArr []interface{}, diffs []JSONDiff) []JSONDiff {
+	// lcs[i][j] is the common subsequence length of oldArr[i:], newArr[j:]
+	lcs := make([][]int, len(oldArr)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newArr)+1)
+	}
+	for i := len(oldArr) - 1; i >= 0; i-- {
+		for j := len(newArr) - 1; j >= 0; j-- {
+			if valuesEqual(oldArr[i], newArr[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
 			}
 		}
 	}
 
+	var gapOld, gapNew []int
+	flush := func() {
+		n := min(len(gapOld), len(gapNew))
+		for k := 0; k < n; k++ {
+			path := d.indexPath(prefix, gapNew[k])
+			diffs = d.diffValues(path, depth, oldArr[gapOld[k]], newArr[gapNew[k]], diffs)
+		}
+		for _, i := range gapOld[n:] {
+			diffs = append(diffs, JSONDiff{
+				Path:     d.indexPath(prefix, i),
+				Type:     "delete",
+				OldValue: toJSONStr(oldArr[i]),
+			})
+		}
+		for _, j := range gapNew[n:] {
+			diffs = append(diffs, JSONDiff{
+				Path:     d.indexPath(prefix, j),
+				Type:     "add",
+				NewValue: toJSONStr(newArr[j]),
+			})
+		}
+		gapOld, gapNew = gapOld[:0], gapNew[:0]
+	}
+
+	i, j := 0, 0
+	for i < len(oldArr) || j < len(newArr) {
+		switch {
+		case i < len(oldArr) && j < len(newArr) && valuesEqual(oldArr[i], newArr[j]):
+			flush()
+			i++
+			j++
+		case j == len(newArr) || (i < len(oldArr) && lcs[i+1][j] >= lcs[i][j+1]):
+			gapOld = append(gapOld, i)
+			i++
+		default:
+			gapNew = append(gapNew, j)
+			j++
+		}
+	}
+	flush()
 	return diffs
 }
 
+// pairMoves replaces each delete whose value reappears in an add with a
+// single move, reported where the add was. Each add is paired with at
+// most one delete, in the order they appear.
+func pairMoves(diffs []JSONDiff) []JSONDiff {
+	decode := func(s string) interface{} {
+		var v interface{}
+		decodeJSON(s, &v)
+		return v
+	}
+
+	moved := make(map[int]bool)
+	var out []JSONDiff
+	for idx, add := range diffs {
+		if add.Type != "add" {
+			continue
+		}
+		addVal := decode(add.NewValue)
+		for k, del := range diffs {
+			if del.Type != "delete" || moved[k] || !valuesEqual(decode(del.OldValue), addVal) {
+				continue
+			}
+			moved[k] = true
+			diffs[idx] = JSONDiff{
+				Path:     add.Path,
+				Type:     "move",
+				FromPath: del.Path,
+				OldValue: del.OldValue,
+				NewValue: add.NewValue,
+			}
+			break
+		}
+	}
+	for k, d := range diffs {
+		if !moved[k] {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// diffValues compares two values found at the same path, depth levels
+// below the root. Objects recurse by key and arrays by index; a value
+// whose JSON type changed is a single "type_change", and any other
+// difference is a whole-value update.
+func (d *differ) diffValues(path string, depth int, oldVal, newVal interface{}, diffs []JSONDiff) []JSONDiff {
+	if valuesEqual(oldVal, newVal) {
+		return diffs
+	}
+	oldStr := toJSONStr(oldVal)
+	newStr := toJSONStr(newVal)
+
+	if jsonKind(oldVal) != jsonKind(newVal) {
+		return append(diffs, JSONDiff{
+			Path:     path,
+			Type:     "type_change",
+			OldValue: oldStr,
+			NewValue: newStr,
+		})
+	}
+	if depth < d.opts.MaxDepth {
+		switch oldChild := oldVal.(type) {
+		case map[string]interface{}:
+			return d.diffMaps(path, depth+1, oldChild, newVal.(map[string]interface{}), diffs)
+		case []interface{}:
+			return d.diffArrays(path, depth+1, oldChild, newVal.([]interface{}), diffs)
+		}
+	}
+	return append(diffs, JSONDiff{
+		Path:     path,
+		Type:     "update",
+		OldValue: oldStr,
+		NewValue: newStr,
+	})
+}
+
+// jsonKind names the JSON type of a decoded value.
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case json.Number, float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
+
+// FormatDiff renders diffs as plain unified-style text, one line per
+// change sorted by path. Additions read "+ path: value", deletions
+// "- path: value", updates "~ path: old -> new", type changes
+// "~ path: old -> new (object -> array)" and moves
+// "> from -> path: value". A change to the
+// whole document is shown at path "$".
+func FormatDiff(diffs []JSONDiff) string {
+	sorted := make([]JSONDiff, len(diffs))
+	copy(sorted, diffs)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	lines := make([]string, 0, len(sorted))
+	for _, d := range sorted {
+		path := d.Path
+		if path == "" {
+			path = "$"
+		}
+		switch d.Type {
+		case "add":
+			lines = append(lines, fmt.Sprintf("+ %s: %s", path, d.NewValue))
+		case "delete":
+			lines = append(lines, fmt.Sprintf("- %s: %s", path, d.OldValue))
+		case "move":
+			lines = append(lines, fmt.Sprintf("> %s -> %s: %s", d.FromPath, path, d.NewValue))
+		case "type_change":
+			lines = append(lines, fmt.Sprintf("~ %s: %s -> %s (%s -> %s)", path, d.OldValue, d.NewValue,
+				kindOf(d.OldValue), kindOf(d.NewValue)))
+		default:
+			lines = append(lines, fmt.Sprintf("~ %s: %s -> %s", path, d.OldValue, d.NewValue))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// kindOf names the JSON type of an encoded value.
+func kindOf(s string) string {
+	var v interface{}
+	decodeJSON(s, &v)
+	return jsonKind(v)
+}
+
+// decodeJSON unmarshals s into v, keeping numbers as json.Number so
+// large integers are compared exactly rather than as float64.
+func decodeJSON(s string, v interface{}) error {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("unexpected data after JSON value")
+	}
+	return nil
+}
+
+// valuesEqual reports whether two decoded JSON values are the same:
+// numbers compare numerically (1 equals 1.0), objects compare regardless
+// of key order, and values of different JSON types are never equal.
+func valuesEqual(a, b interface{}) bool {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			w, exists := bv[k]
+			if !exists || !valuesEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !valuesEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number, float64:
+		x, okA := numberRat(a)
+		y, okB := numberRat(b)
+		return okA && okB && x.Cmp(y) == 0
+	default:
+		// Strings, booleans and null
+		return a == b
+	}
+}
+
+// numberRat converts a decoded JSON number to an exact rational.
+func numberRat(v interface{}) (*big.Rat, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		return new(big.Rat).SetString(string(n))
+	case float64:
+		r := new(big.Rat)
+		if r.SetFloat64(n) == nil {
+			return nil, false
+		}
+		return r, true
+	}
+	return nil, false
+}
+
 func toJSONStr(v interface{}) string {
 	b, _ := json.Marshal(v)
 	return string(b)
 }
 
+// GetPath extracts a nested value from a JSON document. The path uses
+// dot-separated keys and [index] segments, e.g. "result.items[0].id";
+// an empty path returns the whole document. The bool reports whether
+// the value exists; invalid JSON, a malformed path, a missing key or an
+// out-of-range index all report false.
+func GetPath(jsonStr, path string) (interface{}, bool) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(jsonStr), &v); err != nil {
+		return nil, false
+	}
+
+	for path != "" {
+		switch {
+		case path[0] == '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return nil, false
+			}
+			idx, err := strconv.Atoi(path[1:end])
+			arr, ok := v.([]interface{})
+			if err != nil || !ok || idx < 0 || idx >= len(arr) {
+				return nil, false
+			}
+			v = arr[idx]
+			path = path[end+1:]
+		case path[0] == '.':
+			path = path[1:]
+			if path == "" || path[0] == '.' || path[0] == '[' {
+				return nil, false
+			}
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if v, ok = obj[path[:end]]; !ok {
+				return nil, false
+			}
+			path = path[end:]
+		}
+	}
+	return v, true
+}
+
+// RedactedValue replaces the values of redacted keys.
+const RedactedValue = "[redacted]"
+
+// Redact replaces the value of every object key in keys, at any depth
+// and matched case-insensitively, with RedactedValue. The result is
+// valid JSON with the original structure. Input that is not valid JSON
+// is returned unchanged.
+func Redact(jsonStr string, keys []string) string {
+	var v interface{}
+	if err := decodeJSON(jsonStr, &v); err != nil {
+		return jsonStr
+	}
+	sensitive := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		sensitive[strings.ToLower(k)] = true
+	}
+	return toJSONStr(redactValue(v, sensitive))
+}
+
+func redactValue(v interface{}, sensitive map[string]bool) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if sensitive[strings.ToLower(k)] {
+				val[k] = RedactedValue
+			} else {
+				val[k] = redactValue(child, sensitive)
+			}
+		}
+	case []interface{}:
+		for i, child := range val {
+			val[i] = redactValue(child, sensitive)
+		}
+	}
+	return v
+}
+
+// ApplyMergePatch applies a JSON Merge Patch (RFC 7386) to target and
+// returns the result. Object members in the patch are merged
+// recursively, a null member deletes the key, and any other patch value
+// replaces the target outright. An empty target is treated as null.
+func ApplyMergePatch(target, patch string) (string, error) {
+	var t, p interface{}
+	if target != "" {
+		if err := decodeJSON(target, &t); err != nil {
+			return "", fmt.Errorf("parsing target JSON: %w", err)
+		}
+	}
+	if err := decodeJSON(patch, &p); err != nil {
+		return "", fmt.Errorf("parsing patch JSON: %w", err)
+	}
+	return toJSONStr(mergePatch(t, p)), nil
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{})
+	}
+	for k, v := range patchObj {
+		if v == nil {
+			delete(targetObj, k)
+		} else {
+			targetObj[k] = mergePatch(targetObj[k], v)
+		}
+	}
+	return targetObj
+}
+
+// SizeInfo reports the size of a JSON document: its length in bytes,
+// the total number of object keys at every level, and the maximum
+// nesting depth of objects and arrays (0 for a scalar). It streams
+// tokens rather than building the value, so it is cheap to run on large
+// payloads. Invalid JSON reports zero keys and depth.
+func SizeInfo(jsonStr string) (bytes int, keys int, maxDepth int) {
+	bytes = len(jsonStr)
+
+	// For each open container, whether it is an object and, if so,
+	// whether its next token is a key
+	type level struct{ object, wantKey bool }
+	var stack []level
+	values := 0
+
+	dec := json.NewDecoder(strings.NewReader(jsonStr))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return bytes, 0, 0
+		}
+		if len(stack) == 0 {
+			values++
+		}
+
+		// Any token other than a closing delimiter fills the slot the
+		// enclosing object was waiting on
+		top := len(stack) - 1
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			stack = stack[:top]
+			continue
+		}
+		if top >= 0 && stack[top].object {
+			if stack[top].wantKey {
+				keys++
+			}
+			stack[top].wantKey = !stack[top].wantKey
+		}
+		if d, ok := tok.(json.Delim); ok {
+			stack = append(stack, level{object: d == '{', wantKey: d == '{'})
+			maxDepth = max(maxDepth, len(stack))
+		}
+	}
+	// Truncated documents end with containers open; the decoder also
+	// accepts a stream of values, which is not one document
+	if len(stack) > 0 || values != 1 {
+		return bytes, 0, 0
+	}
+	return bytes, keys, maxDepth
+}
+
 // TruncateString truncates a string to maxLen characters, adding "..."
 // if truncation occurred. Used for display in the TUI.
 func TruncateString(s string, maxLen int) string {
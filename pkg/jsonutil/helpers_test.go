@@ -0,0 +1,606 @@
+package jsonutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// mustDiff runs ComputeJSONDiff and fails the test on a parse error.
+func mustDiff(t *testing.T, oldJSON, newJSON string) []JSONDiff {
+	t.Helper()
+	diffs, err := ComputeJSONDiff(oldJSON, newJSON)
+	if err != nil {
+		t.Fatalf("ComputeJSONDiff(%s, %s): %v", oldJSON, newJSON, err)
+	}
+	return diffs
+}
+
+func TestComputeJSONDiffArrayElementChange(t *testing.T) {
+	diffs := mustDiff(t,
+		`{"items":[{"id":1,"qty":2},{"id":2,"qty":1},"x"]}`,
+		`{"items":[{"id":1,"qty":5},{"id":2,"qty":1},"y"]}`)
+
+	want := []JSONDiff{
+		{Path: "items[0].qty", Type: "update", OldValue: "2", NewValue: "5"},
+		{Path: "items[2]", Type: "update", OldValue: `"x"`, NewValue: `"y"`},
+	}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("got %+v, want %+v", diffs, want)
+	}
+}
+
+func TestComputeJSONDiffArrayAppend(t *testing.T) {
+	diffs := mustDiff(t, `{"tags":["a","b"]}`, `{"tags":["a","b","c"]}`)
+
+	want := []JSONDiff{{Path: "tags[2]", Type: "add", NewValue: `"c"`}}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("got %+v, want %+v", diffs, want)
+	}
+}
+
+func TestComputeJSONDiffArrayRemove(t *testing.T) {
+	diffs := mustDiff(t, `{"tags":["a","b","c"]}`, `{"tags":["a"]}`)
+
+	want := []JSONDiff{
+		{Path: "tags[1]", Type: "delete", OldValue: `"b"`},
+		{Path: "tags[2]", Type: "delete", OldValue: `"c"`},
+	}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("got %+v, want %+v", diffs, want)
+	}
+}
+
+func TestComputeJSONDiffNestedArrays(t *testing.T) {
+	diffs := mustDiff(t,
+		`{"facts":[["a","b"],["c"]],"items":[{"name":"x"},{"name":"y"},{"name":"z"}]}`,
+		`{"facts":[["a","B"],["c","d"]],"items":[{"name":"x"},{"name":"y"},{"name":"Z"}]}`)
+
+	want := []JSONDiff{
+		{Path: "facts[0][1]", Type: "update", OldValue: `"b"`, NewValue: `"B"`},
+		{Path: "facts[1][1]", Type: "add", NewValue: `"d"`},
+		{Path: "items[2].name", Type: "update", OldValue: `"z"`, NewValue: `"Z"`},
+	}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("got %+v, want %+v", diffs, want)
+	}
+}
+
+func TestComputeJSONDiffTypeAware(t *testing.T) {
+	equal := [][2]string{
+		{`{"a":1.0}`, `{"a":1}`},
+		{`{"a":1e2}`, `{"a":100}`},
+		{`{"o":{"x":1,"y":[1,2]}}`, `{"o":{"y":[1.0,2],"x":1}}`},
+	}
+	for _, tc := range equal {
+		if diffs := mustDiff(t, tc[0], tc[1]); len(diffs) != 0 {
+			t.Errorf("%s vs %s: expected no diff, got %+v", tc[0], tc[1], diffs)
+		}
+	}
+
+	differ := []struct{ old, new, typ string }{
+		{`{"a":1}`, `{"a":"1"}`, "type_change"},
+		{`{"a":true}`, `{"a":"true"}`, "type_change"},
+		{`{"a":null}`, `{"a":0}`, "type_change"},
+		// Beyond float64 precision
+		{`{"id":9007199254740993}`, `{"id":9007199254740992}`, "update"},
+	}
+	for _, tc := range differ {
+		if diffs := mustDiff(t, tc.old, tc.new); len(diffs) != 1 || diffs[0].Type != tc.typ {
+			t.Errorf("%s vs %s: expected one %s, got %+v", tc.old, tc.new, tc.typ, diffs)
+		}
+	}
+}
+
+func TestComputeJSONDiffTopLevelArray(t *testing.T) {
+	diffs := mustDiff(t, `[1,{"a":1},3]`, `[1,{"a":2}]`)
+
+	want := []JSONDiff{
+		{Path: "[1].a", Type: "update", OldValue: "1", NewValue: "2"},
+		{Path: "[2]", Type: "delete", OldValue: "3"},
+	}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("got %+v, want %+v", diffs, want)
+	}
+}
+
+func TestComputeJSONDiffTopLevelScalar(t *testing.T) {
+	diffs := mustDiff(t, `"draft"`, `"final"`)
+	want := []JSONDiff{{Path: "", Type: "update", OldValue: `"draft"`, NewValue: `"final"`}}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("got %+v, want %+v", diffs, want)
+	}
+
+	for _, tc := range [][2]string{{`42`, `42.0`}, {`null`, `null`}, {`true`, `true`}} {
+		if diffs := mustDiff(t, tc[0], tc[1]); len(diffs) != 0 {
+			t.Errorf("%s vs %s: expected no diff, got %+v", tc[0], tc[1], diffs)
+		}
+	}
+
+	if _, err := ComputeJSONDiff(`[1,`, `[1]`); err == nil {
+		t.Error("expected an error for malformed JSON")
+	}
+}
+
+func TestGetPath(t *testing.T) {
+	doc := `{"result":{"items":[{"id":"a1"},{"id":"b2","tags":["x","y"]}],"count":2},"ok":true}`
+
+	cases := []struct {
+		path string
+		want interface{}
+	}{
+		{"result.count", float64(2)},
+		{"ok", true},
+		{"result.items[0].id", "a1"},
+		{"result.items[1].tags[1]", "y"},
+	}
+	for _, tc := range cases {
+		got, ok := GetPath(doc, tc.path)
+		if !ok || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("GetPath(%q) = %v, %v; want %v", tc.path, got, ok, tc.want)
+		}
+	}
+
+	if got, ok := GetPath(`[{"id":7}]`, "[0].id"); !ok || got != float64(7) {
+		t.Errorf("expected a top-level index to resolve, got %v, %v", got, ok)
+	}
+
+	for _, path := range []string{
+		"result.missing.id",
+		"result.items[5].id",
+		"result.items[-1]",
+		"result.count.deeper",
+		"ok[0]",
+		"result..count",
+		"result.items[0",
+	} {
+		if got, ok := GetPath(doc, path); ok {
+			t.Errorf("GetPath(%q) = %v; expected it to be missing", path, got)
+		}
+	}
+	if _, ok := GetPath(`{not json`, "a"); ok {
+		t.Error("expected invalid JSON to report missing")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	in := `{"api_key":"sk-123","config":{"API_KEY":"sk-456","model":"gpt-4"},` +
+		`"tools":[{"name":"search","api_key":{"nested":"sk-789"}}],"count":3}`
+
+	out := Redact(in, []string{"api_key"})
+	for _, secret := range []string{"sk-123", "sk-456", "sk-789"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("expected %s to be redacted, got %s", secret, out)
+		}
+	}
+
+	for path, want := range map[string]interface{}{
+		"api_key":          RedactedValue,
+		"config.API_KEY":   RedactedValue,
+		"tools[0].api_key": RedactedValue,
+		"config.model":     "gpt-4",
+		"tools[0].name":    "search",
+		"count":            float64(3),
+	} {
+		if got, ok := GetPath(out, path); !ok || got != want {
+			t.Errorf("%s: got %v, %v; want %v", path, got, ok, want)
+		}
+	}
+
+	if got := Redact("not json", []string{"api_key"}); got != "not json" {
+		t.Errorf("expected invalid JSON unchanged, got %q", got)
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	cases := []struct {
+		name, target, patch, want string
+	}{
+		{"add key", `{"a":1}`, `{"b":2}`, `{"a":1,"b":2}`},
+		{"nested merge", `{"cfg":{"model":"gpt-4","temp":0.2}}`, `{"cfg":{"temp":0.7,"top_p":1}}`,
+			`{"cfg":{"model":"gpt-4","temp":0.7,"top_p":1}}`},
+		{"null deletes", `{"a":1,"cfg":{"x":1,"y":2}}`, `{"a":null,"cfg":{"y":null}}`, `{"cfg":{"x":1}}`},
+		{"array replaces", `{"tags":["a","b"]}`, `{"tags":["c"]}`, `{"tags":["c"]}`},
+		{"non-object patch replaces", `{"a":1}`, `["x"]`, `["x"]`},
+		{"empty target", ``, `{"a":{"b":null,"c":1}}`, `{"a":{"c":1}}`},
+	}
+	for _, tc := range cases {
+		got, err := ApplyMergePatch(tc.target, tc.patch)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if !valuesEqualJSON(t, got, tc.want) {
+			t.Errorf("%s: got %s, want %s", tc.name, got, tc.want)
+		}
+	}
+
+	if _, err := ApplyMergePatch(`{"a":1}`, `{bad`); err == nil {
+		t.Error("expected an error for a malformed patch")
+	}
+}
+
+// valuesEqualJSON reports whether two JSON documents hold the same value.
+func valuesEqualJSON(t *testing.T, a, b string) bool {
+	t.Helper()
+	return Equal(a, b)
+}
+
+func TestSizeInfo(t *testing.T) {
+	doc := `{"a":1,"b":{"c":[1,{"d":{"e":true}}],"f":"x"},"g":[]}`
+
+	bytes, keys, depth := SizeInfo(doc)
+	if bytes != len(doc) {
+		t.Errorf("expected %d bytes, got %d", len(doc), bytes)
+	}
+	// a, b, c, d, e, f, g
+	if keys != 7 {
+		t.Errorf("expected 7 keys, got %d", keys)
+	}
+	// {} > b{} > c[] > {} > d{}
+	if depth != 5 {
+		t.Errorf("expected depth 5, got %d", depth)
+	}
+
+	if _, keys, depth := SizeInfo(`"scalar"`); keys != 0 || depth != 0 {
+		t.Errorf("expected a scalar to have no keys or depth, got %d, %d", keys, depth)
+	}
+	for _, bad := range []string{`{"a":`, `{"a":1} {"b":2}`, ``} {
+		if _, keys, depth := SizeInfo(bad); keys != 0 || depth != 0 {
+			t.Errorf("%q: expected invalid JSON to report zeros, got %d, %d", bad, keys, depth)
+		}
+	}
+}
+
+func TestFormatDiff(t *testing.T) {
+	diffs := mustDiff(t,
+		`{"status":"draft","tags":["a"],"old":1}`,
+		`{"status":"final","tags":["a","b"],"author":"kim"}`)
+
+	want := strings.Join([]string{
+		`+ author: "kim"`,
+		`- old: 1`,
+		`~ status: "draft" -> "final"`,
+		`+ tags[1]: "b"`,
+	}, "\n")
+	if got := FormatDiff(diffs); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := FormatDiff(mustDiff(t, `1`, `2`)); got != "~ $: 1 -> 2" {
+		t.Errorf("expected a root change at $, got %q", got)
+	}
+	if got := FormatDiff(nil); got != "" {
+		t.Errorf("expected no output for no diffs, got %q", got)
+	}
+}
+
+func TestComputeJSONDiffMaxDepth(t *testing.T) {
+	nested := func(depth int, leaf string) string {
+		return strings.Repeat(`{"n":`, depth) + leaf + strings.Repeat("}", depth)
+	}
+
+	// Far deeper than the default limit: one bounded update
+	oldJSON, newJSON := nested(1000, "1"), nested(1000, "2")
+	diffs := mustDiff(t, oldJSON, newJSON)
+	if len(diffs) != 1 || diffs[0].Type != "update" {
+		t.Fatalf("expected a single update, got %d diffs", len(diffs))
+	}
+	if got := strings.Count(diffs[0].Path, "n"); got != DefaultMaxDiffDepth {
+		t.Errorf("expected the update %d levels down, got %d", DefaultMaxDiffDepth, got)
+	}
+
+	diffs, err := ComputeJSONDiffWithOptions(
+		`{"a":{"b":{"c":1,"d":1}}}`, `{"a":{"b":{"c":2,"d":2}}}`, DiffOptions{MaxDepth: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []JSONDiff{{Path: "a.b", Type: "update", OldValue: `{"c":1,"d":1}`, NewValue: `{"c":2,"d":2}`}}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("got %+v, want %+v", diffs, want)
+	}
+}
+
+func TestPrettyJSONStream(t *testing.T) {
+	// Keys are sorted so the in-memory MarshalIndent output, which
+	// sorts them, is comparable
+	doc := `{"agent":"research-bot","note":null,"steps":[{"id":1,"ok":true},{"id":2,"ok":false}],"usage":{"completion":48,"prompt":312}}`
+
+	var buf bytes.Buffer
+	if err := PrettyJSONStream(strings.NewReader(doc), &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := json.MarshalIndent(v, "", "  ")
+	if buf.String() != string(want)+"\n" {
+		t.Errorf("stream output differs from MarshalIndent:\n%s\nwant:\n%s", buf.String(), want)
+	}
+	if got := PrettyJSON(doc); got != string(want) {
+		t.Errorf("expected PrettyJSON to match, got:\n%s", got)
+	}
+
+	if err := PrettyJSONStream(strings.NewReader(`{"a":`), &bytes.Buffer{}); err == nil {
+		t.Error("expected an error for truncated JSON")
+	}
+	if got := PrettyJSON(`{not json`); got != `{not json` {
+		t.Errorf("expected invalid JSON unchanged, got %q", got)
+	}
+}
+
+func TestPrettyJSONKeepsKeyOrder(t *testing.T) {
+	doc := `{"zeta":1,"alpha":{"yank":true,"bravo":[],"x":{}},"mid":"<b>&</b>","big":12345678901234567890,"f":1.50}`
+
+	want := `{
+  "zeta": 1,
+  "alpha": {
+    "yank": true,
+    "bravo": [],
+    "x": {}
+  },
+  "mid": "<b>&</b>",
+  "big": 12345678901234567890,
+  "f": 1.50
+}`
+	for i := 0; i < 5; i++ {
+		if got := PrettyJSON(doc); got != want {
+			t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+		}
+	}
+}
+
+func TestComputeJSONDiffDetectMoves(t *testing.T) {
+	oldJSON := `{"queue":[{"id":"x","pri":1},{"id":"a"},{"id":"b"}]}`
+	newJSON := `{"queue":[{"id":"a"},{"id":"b"},{"id":"x","pri":1}]}`
+
+	diffs, err := ComputeJSONDiffWithOptions(oldJSON, newJSON, DiffOptions{DetectMoves: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []JSONDiff{{
+		Path:     "queue[2]",
+		Type:     "move",
+		FromPath: "queue[0]",
+		OldValue: `{"id":"x","pri":1}`,
+		NewValue: `{"id":"x","pri":1}`,
+	}}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("got %+v, want %+v", diffs, want)
+	}
+	if got := FormatDiff(diffs); got != `> queue[0] -> queue[2]: {"id":"x","pri":1}` {
+		t.Errorf("unexpected formatted move: %q", got)
+	}
+
+	// Without the option the same change is reported index by index
+	if diffs := mustDiff(t, oldJSON, newJSON); len(diffs) < 2 {
+		t.Errorf("expected index-wise changes without DetectMoves, got %+v", diffs)
+	}
+
+	// A value renamed to another key is a move; an edited element is
+	// still diffed in place
+	diffs, _ = ComputeJSONDiffWithOptions(
+		`{"draft":{"t":"hi"},"items":[1,{"n":1},3]}`,
+		`{"final":{"t":"hi"},"items":[1,{"n":2},3]}`,
+		DiffOptions{DetectMoves: true})
+	want = []JSONDiff{
+		{Path: "final", Type: "move", FromPath: "draft", OldValue: `{"t":"hi"}`, NewValue: `{"t":"hi"}`},
+		{Path: "items[1].n", Type: "update", OldValue: "1", NewValue: "2"},
+	}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("got %+v, want %+v", diffs, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(`{"ok": [1, 2, {"a": null}]}`); err != nil {
+		t.Errorf("expected valid JSON to pass, got %v", err)
+	}
+
+	broken := "{\n  \"a\": 1,\n  \"b\": tru\n}"
+	err := Validate(broken)
+	if err == nil {
+		t.Fatal("expected an error for broken JSON")
+	}
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("expected the syntax error to be wrapped, got %T", err)
+	}
+	for _, want := range []string{"line 3", "column 11", fmt.Sprintf("byte offset %d", syntaxErr.Offset)} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %q", want, err.Error())
+		}
+	}
+
+	if err := Validate(`{"a":1} trailing`); err == nil {
+		t.Error("expected trailing data to be rejected")
+	}
+	if err := Validate(""); err == nil {
+		t.Error("expected empty input to be rejected")
+	}
+}
+
+func TestPrettyJSONColored(t *testing.T) {
+	doc := `{"name":"oculo","n":[1,2.5],"ok":true,"none":null}`
+	ansi := func(code string) func(string) string {
+		return func(s string) string { return "\x1b[" + code + "m" + s + "\x1b[0m" }
+	}
+
+	got := PrettyJSONColored(doc, ColorFns{
+		Key:     ansi("34"),
+		String:  ansi("32"),
+		Number:  ansi("36"),
+		Literal: ansi("35"),
+		Punct:   ansi("90"),
+	})
+	for _, want := range []string{
+		"\x1b[34m\"name\"\x1b[0m\x1b[90m:\x1b[0m \x1b[32m\"oculo\"\x1b[0m",
+		"\x1b[36m2.5\x1b[0m",
+		"\x1b[35mtrue\x1b[0m",
+		"\x1b[35mnull\x1b[0m",
+		"\x1b[90m{\x1b[0m",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in colored output:\n%q", want, got)
+		}
+	}
+
+	if plain := PrettyJSONColored(doc, ColorFns{}); plain != PrettyJSON(doc) {
+		t.Errorf("expected no-op colors to match PrettyJSON:\n%s\nvs\n%s", plain, PrettyJSON(doc))
+	}
+	if got := PrettyJSONColored(`{oops`, ColorFns{Key: ansi("34")}); got != `{oops` {
+		t.Errorf("expected invalid JSON unchanged, got %q", got)
+	}
+}
+
+func TestComputeJSONDiffTypeChange(t *testing.T) {
+	diffs := mustDiff(t,
+		`{"state":{"a":{"deep":[1,2,3]},"b":2},"tags":["x"]}`,
+		`{"state":[],"tags":["x"]}`)
+
+	want := []JSONDiff{{
+		Path:     "state",
+		Type:     "type_change",
+		OldValue: `{"a":{"deep":[1,2,3]},"b":2}`,
+		NewValue: `[]`,
+	}}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("got %+v, want %+v", diffs, want)
+	}
+	if got := FormatDiff(diffs); got != `~ state: {"a":{"deep":[1,2,3]},"b":2} -> [] (object -> array)` {
+		t.Errorf("unexpected formatted type change: %q", got)
+	}
+
+	// Arrays of objects still recurse by index, then by key
+	diffs = mustDiff(t, `{"l":[{"v":{}}]}`, `{"l":[{"v":[]}]}`)
+	if len(diffs) != 1 || diffs[0].Path != "l[0].v" || diffs[0].Type != "type_change" {
+		t.Errorf("expected a type change at l[0].v, got %+v", diffs)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	cases := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{"reordered keys", `{"a":1,"b":{"c":2,"d":3}}`, `{"b":{"d":3,"c":2},"a":1}`, true},
+		{"whitespace", `{"a":[1,2]}`, "{\n  \"a\": [ 1, 2 ]\n}\n", true},
+		{"number forms", `{"n":1}`, `{"n":1.0}`, true},
+		{"different value", `{"a":1}`, `{"a":2}`, false},
+		{"array order", `[1,2]`, `[2,1]`, false},
+		{"string vs number", `{"a":"1"}`, `{"a":1}`, false},
+		{"extra key", `{"a":1}`, `{"a":1,"b":null}`, false},
+		{"invalid", `{bad`, `{bad`, false},
+	}
+	for _, tc := range cases {
+		if got := Equal(tc.a, tc.b); got != tc.want {
+			t.Errorf("%s: Equal(%s, %s) = %v, want %v", tc.name, tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestComputeJSONPatch(t *testing.T) {
+	cases := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{"unchanged", `{"a":1}`, `{"a":1.0}`, `[]`},
+		{"add remove replace",
+			`{"keep":1,"gone":true,"n":1}`,
+			`{"keep":1,"n":2,"new":{"x":null}}`,
+			`[{"op":"remove","path":"/gone"},{"op":"replace","path":"/n","value":2},{"op":"add","path":"/new","value":{"x":null}}]`},
+		{"pointer escaping", `{}`, `{"a/b":{"c~d":1}}`,
+			`[{"op":"add","path":"/a~1b","value":{"c~d":1}}]`},
+		{"nested escaping", `{"a/b":{"c~d":1}}`, `{"a/b":{"c~d":2}}`,
+			`[{"op":"replace","path":"/a~1b/c~0d","value":2}]`},
+		{"array grows", `{"l":[1]}`, `{"l":[1,2,3]}`,
+			`[{"op":"add","path":"/l/1","value":2},{"op":"add","path":"/l/2","value":3}]`},
+		// Removals run from the end so earlier ones do not shift later indexes
+		{"array shrinks", `{"l":[0,1,2,3]}`, `{"l":[9]}`,
+			`[{"op":"replace","path":"/l/0","value":9},{"op":"remove","path":"/l/3"},{"op":"remove","path":"/l/2"},{"op":"remove","path":"/l/1"}]`},
+		{"type change", `{"s":{"a":1}}`, `{"s":[]}`, `[{"op":"replace","path":"/s","value":[]}]`},
+		{"whole document", `"draft"`, `"final"`, `[{"op":"replace","path":"","value":"final"}]`},
+		{"empty old side", ``, `{"a":1}`, `[{"op":"add","path":"/a","value":1}]`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ComputeJSONPatch(tc.old, tc.new)
+			if err != nil {
+				t.Fatalf("ComputeJSONPatch failed: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("got  %s\nwant %s", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := ComputeJSONPatch(`{"a":`, `{}`); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
+
+func TestPrettyJSONLimit(t *testing.T) {
+	doc := `{"a":1,"b":"two","c":[1,2,3],"d":{"e":true}}`
+
+	// Fits: identical to PrettyJSON
+	if got, cut := PrettyJSONLimit(doc, 1000); cut || got != PrettyJSON(doc) {
+		t.Errorf("expected the full output, got %q (cut=%v)", got, cut)
+	}
+	if got, cut := PrettyJSONLimit(doc, 0); cut || got != PrettyJSON(doc) {
+		t.Errorf("expected no limit for 0, got %q (cut=%v)", got, cut)
+	}
+
+	// Cut after the last top-level member that fits
+	got, cut := PrettyJSONLimit(doc, 30)
+	want := "{\n  \"a\": 1,\n  \"b\": \"two\"\n// …truncated"
+	if !cut || got != want {
+		t.Errorf("got %q (cut=%v), want %q", got, cut, want)
+	}
+
+	// No top-level member fits, so cut at a line
+	got, cut = PrettyJSONLimit(`{"big":[1,2,3,4,5,6,7,8,9]}`, 30)
+	want = "{\n  \"big\": [\n    1,\n    2,\n// …truncated"
+	if !cut || got != want {
+		t.Errorf("got %q (cut=%v), want %q", got, cut, want)
+	}
+
+	// A single long scalar is cut at a rune boundary
+	got, cut = PrettyJSONLimit(`"ééééé"`, 4)
+	if !cut || got != "\"é\n// …truncated" {
+		t.Errorf("got %q (cut=%v)", got, cut)
+	}
+
+	// Invalid JSON comes back as-is, cut if too long
+	if got, cut := PrettyJSONLimit(`{oops`, 100); cut || got != `{oops` {
+		t.Errorf("got %q (cut=%v)", got, cut)
+	}
+	if got, cut := PrettyJSONLimit(`{oops, not json at all`, 5); !cut || got != "{oops\n// …truncated" {
+		t.Errorf("got %q (cut=%v)", got, cut)
+	}
+}
+
+func TestPrettyJSONLimitStopsEarly(t *testing.T) {
+	// A huge document must not be expanded in full
+	var b strings.Builder
+	b.WriteString(`{"items":[`)
+	for i := 0; i < 100000; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(`{"id":1,"name":"item"}`)
+	}
+	b.WriteString(`]}`)
+
+	got, cut := PrettyJSONLimit(b.String(), 1024)
+	if !cut || len(got) > 1024+len("\n"+truncatedMarker) {
+		t.Errorf("expected at most 1KiB plus the marker, got %d bytes (cut=%v)", len(got), cut)
+	}
+}
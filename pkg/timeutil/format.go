@@ -7,6 +7,8 @@ package timeutil
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -25,18 +27,96 @@ func NowNano() int64 {
 	return time.Now().UnixNano()
 }
 
+// TruncateTo rounds a Unix nanosecond timestamp down to a multiple of
+// d, e.g. the start of its second or minute, for bucketing events.
+// A non-positive d returns ns unchanged.
+func TruncateTo(ns int64, d time.Duration) int64 {
+	if d <= 0 {
+		return ns
+	}
+	rem := ns % int64(d)
+	if rem < 0 {
+		rem += int64(d)
+	}
+	return ns - rem
+}
+
+// Default display layouts, in time.Format syntax.
+const (
+	LayoutClock = "15:04:05.000"
+	LayoutFull  = "2006-01-02 15:04:05.000"
+)
+
+// location is the zone timestamps are formatted in. It is read without
+// locking, so set it before formatting starts.
+var location = time.Local
+
+// SetLocation sets the zone FormatTimestamp and friends render in, e.g.
+// time.UTC for reports shared between machines. nil restores local time.
+func SetLocation(loc *time.Location) {
+	if loc == nil {
+		loc = time.Local
+	}
+	location = loc
+}
+
+// Location returns the zone set by SetLocation.
+func Location() *time.Location {
+	return location
+}
+
 // FormatTimestamp formats a Unix nanosecond timestamp for display
 // in the TUI timeline view. Format: "HH:MM:SS.mmm"
 func FormatTimestamp(ns int64) string {
-	t := FromNano(ns)
-	return t.Format("15:04:05.000")
+	return FormatTimestampLayout(ns, LayoutClock)
+}
+
+// FormatTimestampIn is FormatTimestamp in the given zone.
+func FormatTimestampIn(ns int64, loc *time.Location) string {
+	return FormatTimestampLayoutIn(ns, LayoutClock, loc)
 }
 
 // FormatTimestampFull formats a Unix nanosecond timestamp with date.
 // Format: "2006-01-02 15:04:05.000"
 func FormatTimestampFull(ns int64) string {
-	t := FromNano(ns)
-	return t.Format("2006-01-02 15:04:05.000")
+	return FormatTimestampLayout(ns, LayoutFull)
+}
+
+// FormatTimestampFullIn is FormatTimestampFull in the given zone.
+func FormatTimestampFullIn(ns int64, loc *time.Location) string {
+	return FormatTimestampLayoutIn(ns, LayoutFull, loc)
+}
+
+// FormatTimestampLayout formats a Unix nanosecond timestamp with a
+// caller-supplied time.Format layout.
+func FormatTimestampLayout(ns int64, layout string) string {
+	return FormatTimestampLayoutIn(ns, layout, location)
+}
+
+// FormatTimestampLayoutIn is FormatTimestampLayout in the given zone.
+func FormatTimestampLayoutIn(ns int64, layout string, loc *time.Location) string {
+	return FromNano(ns).In(loc).Format(layout)
+}
+
+// FormatRange formats a time span as its endpoints and the duration
+// between them, e.g. "10:03:01–10:07:45 (4m 44.0s)". Dates are shown
+// when the endpoints fall on different days. A zero end means the span
+// is still open: "10:03:01 → running".
+func FormatRange(startNs, endNs int64) string {
+	layout := "15:04:05"
+	start := FromNano(startNs).In(location)
+	switch {
+	case endNs == 0:
+		return start.Format(layout) + " → running"
+	case endNs <= startNs:
+		return start.Format(layout) + " (0ms)"
+	}
+	end := FromNano(endNs).In(location)
+	if start.YearDay() != end.YearDay() || start.Year() != end.Year() {
+		layout = "2006-01-02 15:04:05"
+	}
+	return fmt.Sprintf("%s–%s (%s)", start.Format(layout), end.Format(layout),
+		FormatDuration((endNs-startNs)/int64(time.Millisecond)))
 }
 
 // FormatDuration formats a duration in milliseconds to a human-readable string.
@@ -54,22 +134,201 @@ func FormatDuration(ms int64) string {
 	return fmt.Sprintf("%dm %.1fs", minutes, remaining)
 }
 
+// FormatDurationCompact formats a duration in milliseconds as a single
+// token for dense tables, dropping precision as the unit grows.
+// Examples: "450ms", "1.2s", "2m15s", "3h4m"
+func FormatDurationCompact(ms int64) string {
+	switch {
+	case ms < 1000:
+		return fmt.Sprintf("%dms", ms)
+	case ms < 60*1000:
+		return fmt.Sprintf("%d.%ds", ms/1000, ms%1000/100)
+	case ms < 60*60*1000:
+		return compactPair(ms/1000, 60, "m", "s")
+	default:
+		return compactPair(ms/(60*1000), 60, "h", "m")
+	}
+}
+
+// compactPair renders n as a major and minor unit, omitting a zero
+// minor part: "2m15s", "3h".
+func compactPair(n, per int64, major, minor string) string {
+	if n%per == 0 {
+		return fmt.Sprintf("%d%s", n/per, major)
+	}
+	return fmt.Sprintf("%d%s%d%s", n/per, major, n%per, minor)
+}
+
+// FormatDurationNanos formats a duration in nanoseconds, keeping the
+// precision FormatDuration drops below a millisecond.
+// Examples: "850ns", "850µs", "450ms", "1.2s"
+func FormatDurationNanos(ns int64) string {
+	switch {
+	case ns < int64(time.Microsecond):
+		return fmt.Sprintf("%dns", ns)
+	case ns < int64(time.Millisecond):
+		return fmt.Sprintf("%dµs", ns/int64(time.Microsecond))
+	default:
+		return FormatDuration(ns / int64(time.Millisecond))
+	}
+}
+
+// ParseDuration parses a duration in the forms FormatDuration produces
+// ("450ms", "1.2s", "2m 15.3s") back into milliseconds. Components may
+// also be written without spaces ("2m15s") and use h, m, s, ms, us or ns
+// units; the total is rounded to the nearest millisecond.
+func ParseDuration(s string) (int64, error) {
+	parts := strings.Fields(s)
+	if len(parts) == 0 {
+		return 0, fmt.Errorf("invalid duration %q: empty", s)
+	}
+	var total time.Duration
+	for _, part := range parts {
+		d, err := time.ParseDuration(part)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: want forms like 450ms, 1.2s or 2m 15.3s", s)
+		}
+		if d < 0 {
+			return 0, fmt.Errorf("invalid duration %q: must not be negative", s)
+		}
+		total += d
+	}
+	return total.Round(time.Millisecond).Milliseconds(), nil
+}
+
+// Layouts ParseTimestamp tries, most specific first. Those without a
+// zone are read in the zone set by SetLocation.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// ParseTimestamp parses a human-written timestamp into Unix nanoseconds.
+// It accepts RFC3339 ("2024-03-01T10:03:01Z"), "2006-01-02 15:04:05",
+// a bare date ("2024-03-01", midnight), an offset from now ("-1h",
+// "-30m", "+5m", "-30d"), an age meaning that long ago ("30d" is the
+// same as "-30d") and raw Unix nanoseconds.
+func ParseTimestamp(s string) (int64, error) {
+	return parseTimestampAt(s, time.Now())
+}
+
+// parseTimestampAt is ParseTimestamp with offsets taken from now.
+func parseTimestampAt(s string, now time.Time) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("invalid timestamp: empty")
+	}
+	if s[0] == '-' || s[0] == '+' {
+		d, err := parseOffset(s[1:])
+		if err != nil {
+			return 0, fmt.Errorf("invalid relative timestamp %q: %w", s, err)
+		}
+		if s[0] == '-' {
+			d = -d
+		}
+		return now.Add(d).UnixNano(), nil
+	}
+	if ns, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return ns, nil
+	}
+	if d, err := parseOffset(s); err == nil {
+		return now.Add(-d).UnixNano(), nil
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, s, location); err == nil {
+			return t.UnixNano(), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid timestamp %q: want RFC3339, 2006-01-02 15:04:05, 2006-01-02 or an offset like -1h", s)
+}
+
+// parseOffset is time.ParseDuration for unsigned durations, plus a
+// leading whole-day component ("30d", "1d12h").
+func parseOffset(s string) (time.Duration, error) {
+	var days time.Duration
+	if i := strings.IndexByte(s, 'd'); i > 0 {
+		n, err := strconv.ParseUint(s[:i], 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid day count %q", s[:i])
+		}
+		days = time.Duration(n) * 24 * time.Hour
+		if s = s[i+1:]; s == "" {
+			return days, nil
+		}
+	}
+	if s == "" || s[0] < '0' || s[0] > '9' {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	return days + d, nil
+}
+
+// ParseRange parses the endpoints of a --since/--until style range into
+// Unix nanoseconds. Each endpoint is anything ParseTimestamp accepts or
+// "now". An empty endpoint leaves that side open and comes back as 0.
+// Both endpoints are resolved against the same instant, and since must
+// not be after until.
+func ParseRange(since, until string) (sinceNs, untilNs int64, err error) {
+	now := time.Now()
+	if sinceNs, err = parseRangeEnd(since, now); err != nil {
+		return 0, 0, fmt.Errorf("since: %w", err)
+	}
+	if untilNs, err = parseRangeEnd(until, now); err != nil {
+		return 0, 0, fmt.Errorf("until: %w", err)
+	}
+	if sinceNs != 0 && untilNs != 0 && sinceNs > untilNs {
+		return 0, 0, fmt.Errorf("since (%s) is after until (%s)",
+			FormatTimestampFull(sinceNs), FormatTimestampFull(untilNs))
+	}
+	return sinceNs, untilNs, nil
+}
+
+// parseRangeEnd parses one endpoint of ParseRange.
+func parseRangeEnd(s string, now time.Time) (int64, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "":
+		return 0, nil
+	case strings.EqualFold(s, "now"):
+		return now.UnixNano(), nil
+	}
+	return parseTimestampAt(s, now)
+}
+
 // RelativeTime returns a human-readable relative time string.
-// Examples: "just now", "5s ago", "2m ago", "1h ago"
+// Examples: "just now", "5s ago", "2m ago", "1h ago", "in 5s"
+//
+// Timestamps less than a second away either side, such as those from a
+// slightly skewed clock, read as "just now".
 func RelativeTime(ns int64) string {
 	diff := time.Since(FromNano(ns))
+	if diff < 0 {
+		if -diff < time.Second {
+			return "just now"
+		}
+		return "in " + relativeSpan(-diff)
+	}
+	if diff < time.Second {
+		return "just now"
+	}
+	return relativeSpan(diff) + " ago"
+}
 
+// relativeSpan renders a positive duration in its largest whole unit.
+func relativeSpan(d time.Duration) string {
 	switch {
-	case diff < time.Second:
-		return "just now"
-	case diff < time.Minute:
-		return fmt.Sprintf("%ds ago", int(diff.Seconds()))
-	case diff < time.Hour:
-		return fmt.Sprintf("%dm ago", int(diff.Minutes()))
-	case diff < 24*time.Hour:
-		return fmt.Sprintf("%dh ago", int(diff.Hours()))
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
 	default:
-		days := int(diff.Hours() / 24)
-		return fmt.Sprintf("%dd ago", days)
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
 	}
 }
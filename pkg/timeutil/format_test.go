@@ -0,0 +1,326 @@
+package timeutil
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDurationRoundTrip(t *testing.T) {
+	for _, ms := range []int64{0, 7, 450, 999, 1000, 1200, 59900, 60000, 135300, 3723400} {
+		s := FormatDuration(ms)
+		got, err := ParseDuration(s)
+		if err != nil {
+			t.Errorf("ParseDuration(%q): %v", s, err)
+			continue
+		}
+		if got != ms {
+			t.Errorf("ParseDuration(%q) = %d, want %d", s, got, ms)
+		}
+	}
+}
+
+func TestParseDurationForms(t *testing.T) {
+	cases := map[string]int64{
+		"450ms":     450,
+		"1.2s":      1200,
+		"2m15s":     135000,
+		" 2m  15s ": 135000,
+		"1h 2m":     3720000,
+		"1500us":    2,
+	}
+	for in, want := range cases {
+		got, err := ParseDuration(in)
+		if err != nil {
+			t.Errorf("ParseDuration(%q): %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseDuration(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestParseDurationMalformed(t *testing.T) {
+	for _, in := range []string{"", "   ", "abc", "12", "1.2x", "-5s", "2m -1s"} {
+		if _, err := ParseDuration(in); err == nil {
+			t.Errorf("ParseDuration(%q): expected an error", in)
+		}
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	local := func(year int, month time.Month, day, hour, min, sec int) int64 {
+		return time.Date(year, month, day, hour, min, sec, 0, time.Local).UnixNano()
+	}
+	cases := map[string]int64{
+		"2024-03-01T10:03:01Z":      time.Date(2024, 3, 1, 10, 3, 1, 0, time.UTC).UnixNano(),
+		"2024-03-01T10:03:01+02:00": time.Date(2024, 3, 1, 8, 3, 1, 0, time.UTC).UnixNano(),
+		"2024-03-01 10:03:01":       local(2024, 3, 1, 10, 3, 1),
+		"2024-03-01":                local(2024, 3, 1, 0, 0, 0),
+		"1709287381000000000":       1709287381000000000,
+	}
+	for in, want := range cases {
+		got, err := ParseTimestamp(in)
+		if err != nil {
+			t.Errorf("ParseTimestamp(%q): %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseTimestamp(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestParseTimestampRelative(t *testing.T) {
+	for in, offset := range map[string]time.Duration{
+		"-1h":    -time.Hour,
+		"-30m":   -30 * time.Minute,
+		"+5m":    5 * time.Minute,
+		"1h":     -time.Hour,
+		"-30d":   -30 * 24 * time.Hour,
+		"30d":    -30 * 24 * time.Hour,
+		"1d12h":  -36 * time.Hour,
+		"+2d30m": 48*time.Hour + 30*time.Minute,
+	} {
+		before := time.Now().Add(offset).UnixNano()
+		got, err := ParseTimestamp(in)
+		after := time.Now().Add(offset).UnixNano()
+		if err != nil {
+			t.Errorf("ParseTimestamp(%q): %v", in, err)
+			continue
+		}
+		if got < before || got > after {
+			t.Errorf("ParseTimestamp(%q) = %d, want within [%d, %d]", in, got, before, after)
+		}
+	}
+}
+
+func TestParseTimestampInvalid(t *testing.T) {
+	for _, in := range []string{"", "yesterday", "2024-13-01", "-1x", "10:03", "d", "-1.5d", "1d-5h", "--1h"} {
+		if _, err := ParseTimestamp(in); err == nil {
+			t.Errorf("ParseTimestamp(%q): expected an error", in)
+		}
+	}
+}
+
+func TestSetLocation(t *testing.T) {
+	defer SetLocation(nil)
+	ns := time.Date(2024, 3, 1, 22, 30, 15, 250e6, time.UTC).UnixNano()
+	plus5 := time.FixedZone("UTC+5", 5*60*60)
+
+	SetLocation(time.UTC)
+	if got := FormatTimestamp(ns); got != "22:30:15.250" {
+		t.Errorf("UTC: got %q", got)
+	}
+	if got := FormatTimestampFull(ns); got != "2024-03-01 22:30:15.250" {
+		t.Errorf("UTC full: got %q", got)
+	}
+
+	SetLocation(plus5)
+	if got := FormatTimestamp(ns); got != "03:30:15.250" {
+		t.Errorf("UTC+5: got %q", got)
+	}
+	if got := FormatTimestampFull(ns); got != "2024-03-02 03:30:15.250" {
+		t.Errorf("UTC+5 full: got %q", got)
+	}
+
+	// Per-call variants ignore the package setting
+	if got := FormatTimestampIn(ns, time.UTC); got != "22:30:15.250" {
+		t.Errorf("FormatTimestampIn: got %q", got)
+	}
+	if got := FormatTimestampFullIn(ns, time.UTC); got != "2024-03-01 22:30:15.250" {
+		t.Errorf("FormatTimestampFullIn: got %q", got)
+	}
+
+	SetLocation(nil)
+	if Location() != time.Local {
+		t.Errorf("SetLocation(nil) should restore local time, got %v", Location())
+	}
+}
+
+func TestFormatDurationNanos(t *testing.T) {
+	cases := map[int64]string{
+		0:               "0ns",
+		850:             "850ns",
+		999:             "999ns",
+		1000:            "1µs",
+		850_000:         "850µs",
+		999_999:         "999µs",
+		1_000_000:       "1ms",
+		450_000_000:     "450ms",
+		1_200_000_000:   "1.2s",
+		135_300_000_000: "2m 15.3s",
+	}
+	for ns, want := range cases {
+		if got := FormatDurationNanos(ns); got != want {
+			t.Errorf("FormatDurationNanos(%d) = %q, want %q", ns, got, want)
+		}
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name   string
+		offset time.Duration
+		want   string
+	}{
+		{"now", 0, "just now"},
+		{"past seconds", -5500 * time.Millisecond, "5s ago"},
+		{"past minutes", -2*time.Minute - 10*time.Second, "2m ago"},
+		{"past days", -50 * time.Hour, "2d ago"},
+		{"future seconds", 5500 * time.Millisecond, "in 5s"},
+		{"future minutes", 2*time.Minute + 10*time.Second, "in 2m"},
+		{"future hours", 3*time.Hour + 10*time.Minute, "in 3h"},
+		{"clock skew", 300 * time.Millisecond, "just now"},
+	}
+	for _, tc := range cases {
+		if got := RelativeTime(now.Add(tc.offset).UnixNano()); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFormatRange(t *testing.T) {
+	SetLocation(time.UTC)
+	defer SetLocation(nil)
+	at := func(day, hour, min, sec int) int64 {
+		return time.Date(2024, 3, day, hour, min, sec, 0, time.UTC).UnixNano()
+	}
+
+	cases := []struct {
+		name       string
+		start, end int64
+		want       string
+	}{
+		{"normal", at(1, 10, 3, 1), at(1, 10, 7, 45), "10:03:01–10:07:45 (4m 44.0s)"},
+		{"across midnight", at(1, 23, 59, 0), at(2, 0, 1, 0), "2024-03-01 23:59:00–2024-03-02 00:01:00 (2m 0.0s)"},
+		{"equal", at(1, 10, 3, 1), at(1, 10, 3, 1), "10:03:01 (0ms)"},
+		{"open-ended", at(1, 10, 3, 1), 0, "10:03:01 → running"},
+	}
+	for _, tc := range cases {
+		if got := FormatRange(tc.start, tc.end); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestTruncateTo(t *testing.T) {
+	ns := time.Date(2024, 3, 1, 10, 3, 1, 750e6, time.UTC).UnixNano()
+	cases := []struct {
+		d    time.Duration
+		want time.Time
+	}{
+		{time.Second, time.Date(2024, 3, 1, 10, 3, 1, 0, time.UTC)},
+		{time.Minute, time.Date(2024, 3, 1, 10, 3, 0, 0, time.UTC)},
+		{time.Hour, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		if got := TruncateTo(ns, tc.d); got != tc.want.UnixNano() {
+			t.Errorf("TruncateTo(%v) = %v, want %v", tc.d, FromNano(got).UTC(), tc.want)
+		}
+	}
+
+	if got := TruncateTo(ns, 0); got != ns {
+		t.Errorf("TruncateTo with a zero duration should return the input, got %d", got)
+	}
+	// Before the epoch rounds down too, not towards zero
+	if got := TruncateTo(-1, time.Second); got != -int64(time.Second) {
+		t.Errorf("TruncateTo(-1, 1s) = %d, want %d", got, -int64(time.Second))
+	}
+}
+
+func TestFormatTimestampLayout(t *testing.T) {
+	SetLocation(time.UTC)
+	defer SetLocation(nil)
+	ns := time.Date(2024, 3, 1, 10, 3, 1, 250e6, time.UTC).UnixNano()
+
+	if got := FormatTimestampLayout(ns, "Jan 2 15:04"); got != "Mar 1 10:03" {
+		t.Errorf("custom layout: got %q", got)
+	}
+	if got, want := FormatTimestamp(ns), FormatTimestampLayout(ns, LayoutClock); got != want || got != "10:03:01.250" {
+		t.Errorf("FormatTimestamp should use LayoutClock: got %q, want %q", got, want)
+	}
+	if got := FormatTimestampFull(ns); got != FormatTimestampLayout(ns, LayoutFull) {
+		t.Errorf("FormatTimestampFull should use LayoutFull: got %q", got)
+	}
+}
+
+func TestFormatDurationCompact(t *testing.T) {
+	cases := map[int64]string{
+		0:          "0ms",
+		450:        "450ms",
+		1000:       "1.0s",
+		1234:       "1.2s",
+		59_960:     "59.9s",
+		60_000:     "1m",
+		135_300:    "2m15s",
+		3_599_999:  "59m59s",
+		3_600_000:  "1h",
+		11_040_000: "3h4m",
+		93_780_000: "26h3m",
+	}
+	for ms, want := range cases {
+		got := FormatDurationCompact(ms)
+		if got != want {
+			t.Errorf("FormatDurationCompact(%d) = %q, want %q", ms, got, want)
+		}
+		if strings.ContainsAny(got, " \t") {
+			t.Errorf("FormatDurationCompact(%d) = %q contains whitespace", ms, got)
+		}
+	}
+}
+
+func TestParseRangeRelative(t *testing.T) {
+	before := time.Now()
+	since, until, err := ParseRange("1h", "now")
+	after := time.Now()
+	if err != nil {
+		t.Fatalf("ParseRange: %v", err)
+	}
+	if until < before.UnixNano() || until > after.UnixNano() {
+		t.Errorf("until should be now, got %v", FromNano(until))
+	}
+	if got := time.Duration(until - since); got != time.Hour {
+		t.Errorf("expected since to be exactly an hour before until, got %v", got)
+	}
+
+	since, until, err = ParseRange("-1h", "now")
+	if err != nil || time.Duration(until-since) != time.Hour {
+		t.Errorf("-1h should match 1h: got %v, %v", time.Duration(until-since), err)
+	}
+}
+
+func TestParseRangeAbsolute(t *testing.T) {
+	since, until, err := ParseRange("2024-03-01T10:00:00Z", "2024-03-01T11:00:00Z")
+	if err != nil {
+		t.Fatalf("ParseRange: %v", err)
+	}
+	if want := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC).UnixNano(); since != want {
+		t.Errorf("since = %d, want %d", since, want)
+	}
+	if want := time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC).UnixNano(); until != want {
+		t.Errorf("until = %d, want %d", until, want)
+	}
+
+	// Empty endpoints stay open
+	since, until, err = ParseRange("2024-03-01T10:00:00Z", "")
+	if err != nil || since == 0 || until != 0 {
+		t.Errorf("open until: got %d, %d, %v", since, until, err)
+	}
+}
+
+func TestParseRangeErrors(t *testing.T) {
+	cases := [][2]string{
+		{"2024-03-01T11:00:00Z", "2024-03-01T10:00:00Z"}, // inverted
+		{"now", "1h"}, // inverted, relative
+		{"yesterday", ""},
+		{"", "soon"},
+	}
+	for _, tc := range cases {
+		if _, _, err := ParseRange(tc[0], tc[1]); err == nil {
+			t.Errorf("ParseRange(%q, %q): expected an error", tc[0], tc[1])
+		}
+	}
+}